@@ -2,67 +2,278 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/card"
 	"transaction-routine/internal/clock"
 	"transaction-routine/internal/config"
 	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/fraud"
+	"transaction-routine/internal/logger"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/outbox"
+	"transaction-routine/internal/ratelimit"
+	"transaction-routine/internal/scheduler"
 	"transaction-routine/internal/server"
 	"transaction-routine/internal/service"
+	"transaction-routine/internal/stream"
+	"transaction-routine/internal/tracing"
+	"transaction-routine/internal/webhook"
 )
 
 func main() {
 	appCtx, appStopCtx := context.WithCancel(context.Background())
-	cl := clock.New()
 	cfg, err := config.New()
 	if err != nil {
 		log.Fatalf("cannot load config: %s", err)
 	}
+	lg, err := logger.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("cannot create logger: %s", err)
+	}
+	slog.SetDefault(lg)
+
+	shutdownTracing, err := tracing.Setup(appCtx, cfg)
+	if err != nil {
+		fatal("cannot setup tracing", err)
+	}
+
+	cl, err := clock.New(cfg.TimeZone)
+	if err != nil {
+		fatal("cannot load time zone", err)
+	}
 	db, err := database.New(appCtx, cfg)
 	if err != nil {
-		log.Fatalf("cannot connect to database: %s", err)
+		fatal("cannot connect to database", err)
+	}
+	db = database.NewInstrumented(db)
+	if err := metrics.RegisterPool(db.Stat); err != nil {
+		fatal("cannot register database pool metrics", err)
 	}
 	opTypes, err := db.FindOperationType(appCtx)
 	if err != nil {
-		log.Fatalf("cannot get operation types to initialize application: %s", err)
+		fatal("cannot get operation types to initialize application", err)
+	}
+
+	jwtVerifier, err := auth.NewJWTVerifier(cfg.JWTSecret, cfg.JWTPublicKeyFile, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		fatal("cannot load jwt configuration", err)
+	}
+	if cfg.AuthDisabled {
+		slog.Warn("authentication is disabled, every request is granted admin scope")
+	}
+
+	limits, err := ratelimit.ParsePolicies(cfg.RateLimits)
+	if err != nil {
+		fatal("cannot parse rate limits", err)
+	}
+	limiter := ratelimit.New(cl, limits)
+
+	amountLimits, err := entity.ParseAmountLimits(cfg.AmountLimits)
+	if err != nil {
+		fatal("cannot parse amount limits", err)
+	}
+	for id := range amountLimits.OperationTypes {
+		if _, ok := opTypes[id]; !ok {
+			fatal("cannot parse amount limits", fmt.Errorf("unknown operation type %d", id))
+		}
 	}
 
-	healthSvc := service.NewHealthService(db)
+	authSvc := service.NewAuthService(db, jwtVerifier)
+	healthSvc := service.NewHealthService(db, opTypes)
 	accsvc := service.NewAccountService(db)
 	opsvc := service.NewOpTypeService(db, opTypes)
-	txsvc := service.NewTransactionService(cl, db, opTypes)
-	srv := server.NewServer(appCtx, cfg, healthSvc, accsvc, opsvc, txsvc)
+	var screener service.Screener
+	var rules *fraud.Engine
+	if cfg.FraudRulesFile != "" {
+		rules, err = fraud.NewEngine(db, cl, cfg.FraudRulesFile)
+		if err != nil {
+			fatal("cannot load fraud rules", err)
+		}
+		screener = rules
+	} else {
+		slog.Warn("no fraud rules file configured, transactions are not screened")
+	}
+	txsvc := service.NewTransactionService(cl, db, opTypes, amountLimits, screener)
+	whsvc := service.NewWebhookService(db)
+	schedsvc := service.NewScheduleService(cl, db, opTypes)
+	dispsvc := service.NewDisputeService(cl, db, opTypes, cfg.DisputeCreditBy, cfg.DisputeResolveBy)
+	fxsvc := service.NewFXService(cl, db)
+	custsvc := service.NewCustomerService(cl, db)
+	var issuer service.CardIssuer
+	if cfg.CardKey != "" {
+		issuer, err = newCardIssuer(cfg)
+		if err != nil {
+			fatal("cannot configure card issuing", err)
+		}
+	} else {
+		slog.Warn("no card encryption key configured, cards cannot be issued")
+	}
+	cardsvc := service.NewCardService(cl, db, issuer)
+	hub := stream.NewHub(db, cl, cfg.StreamInterval, cfg.StreamGapWait)
+	srv := server.NewServer(appCtx, cfg, server.Deps{
+		Limiter:      limiter,
+		Auth:         authSvc,
+		Health:       healthSvc,
+		Accounts:     accsvc,
+		OpTypes:      opsvc,
+		Transactions: txsvc,
+		Webhooks:     whsvc,
+		Schedules:    schedsvc,
+		Disputes:     dispsvc,
+		FX:           fxsvc,
+		Customers:    custsvc,
+		Cards:        cardsvc,
+		Hub:          hub,
+	})
+
+	publisher, closePublisher, err := outbox.NewPublisher(cfg)
+	if err != nil {
+		fatal("cannot create outbox publisher", err)
+	}
+	var publishers outbox.Fanout
+	if cfg.WebhooksEnabled {
+		publishers = append(publishers, webhook.NewDispatcher(db))
+	}
+	if publisher != nil {
+		publishers = append(publishers, publisher)
+	}
+	relayCtx, stopRelay := context.WithCancel(appCtx)
+	relayDone := make(chan struct{})
+	senderDone := make(chan struct{})
+	hubDone := make(chan struct{})
+	schedulerDone := make(chan struct{})
+	disputesDone := make(chan struct{})
+	go func() {
+		defer close(hubDone)
+		hub.Run(relayCtx)
+	}()
+	if len(publishers) > 0 {
+		go func() {
+			defer close(relayDone)
+			outbox.NewRelay(db, publishers, cfg.OutboxInterval, cfg.OutboxGapWait, cfg.OutboxBatchSize).Run(relayCtx)
+		}()
+	} else {
+		close(relayDone)
+		slog.Warn("no outbox publisher configured, events are kept in the outbox")
+	}
+	if cfg.WebhooksEnabled {
+		sender := webhook.NewSender(db, cl, webhook.Config{
+			Interval:    cfg.WebhookInterval,
+			BatchSize:   cfg.WebhookBatchSize,
+			Timeout:     cfg.WebhookTimeout,
+			MaxAttempts: cfg.WebhookAttempts,
+			Backoff:     cfg.WebhookBackoff,
+			MaxBackoff:  cfg.WebhookMaxDelay,
+		})
+		go func() {
+			defer close(senderDone)
+			sender.Run(relayCtx)
+		}()
+	} else {
+		close(senderDone)
+	}
+	if cfg.SchedulerEnabled {
+		worker := scheduler.NewWorker(db, txsvc, cl, scheduler.Config{
+			Interval:   cfg.SchedulerPoll,
+			BatchSize:  cfg.SchedulerBatch,
+			RunTimeout: cfg.SchedulerTimeout,
+		})
+		go func() {
+			defer close(schedulerDone)
+			worker.Run(relayCtx)
+		}()
+		go func() {
+			defer close(disputesDone)
+			scheduler.NewDisputeWorker(dispsvc, cfg.DisputePoll).Run(relayCtx)
+		}()
+	} else {
+		close(schedulerDone)
+		close(disputesDone)
+	}
+
+	if rules != nil {
+		go rules.Watch(relayCtx, cfg.FraudReload)
+	}
 
 	// Graceful shutdown
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
 		<-sig
+		// Fail readiness first and give load balancers time to stop
+		// routing traffic before connections are closed.
+		healthSvc.Drain()
+		slog.Info("draining before shutdown", "period", cfg.ShutdownDrain)
+		time.Sleep(cfg.ShutdownDrain)
+
 		shutdownCtx, cancel := context.WithTimeout(appCtx, 30*time.Second)
 		defer cancel()
 
 		go func() {
 			<-shutdownCtx.Done()
 			if shutdownCtx.Err() == context.DeadlineExceeded {
-				log.Fatal("graceful shutdown timed out.. forcing exit.")
+				fatal("graceful shutdown timed out.. forcing exit.", shutdownCtx.Err())
 			}
 		}()
 
 		err := srv.Shutdown(shutdownCtx)
 		if err != nil {
-			log.Fatal(err)
+			fatal("cannot shutdown server", err)
+		}
+		// Events not published yet stay in the outbox, and deliveries not
+		// sent yet stay pending, for the next start. The scheduler only
+		// finishes the run it is posting, within SCHEDULER_RUN_TIMEOUT; the
+		// runs it claimed after it are resumed later.
+		stopRelay()
+		<-relayDone
+		<-senderDone
+		<-hubDone
+		<-schedulerDone
+		<-disputesDone
+		if closePublisher != nil {
+			if err := closePublisher.Close(); err != nil {
+				slog.Error("cannot close outbox publisher", "error", err)
+			}
+		}
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("cannot flush traces", "error", err)
 		}
 		appStopCtx()
 	}()
 
-	log.Printf("Server running on port %s", srv.Addr)
+	slog.Info("server running", "addr", srv.Addr)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server terminated with error: %s", err)
+		fatal("server terminated with error", err)
 	}
 
 	<-appCtx.Done()
 }
+
+// newCardIssuer returns an issuer of the configured BIN, encrypting PANs
+// with the configured key.
+func newCardIssuer(cfg *config.Config) (*card.Issuer, error) {
+	key, err := card.ParseKey(cfg.CardKey)
+	if err != nil {
+		return nil, err
+	}
+	vault, err := card.NewVault(key, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return card.NewIssuer(cfg.CardBIN, cfg.CardPANLength, cfg.CardValidity, vault, rand.Reader)
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
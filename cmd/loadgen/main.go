@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-routine/internal/client"
+	"transaction-routine/internal/loadgen"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context) int {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	apiURL := fs.String("url", envOr("LOADGEN_URL", "http://localhost:8080"), "API base URL")
+	apiKey := fs.String("api-key", os.Getenv("LOADGEN_API_KEY"), "API key")
+	token := fs.String("token", os.Getenv("LOADGEN_TOKEN"), "bearer token")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request")
+	accounts := fs.Int("accounts", 2, "fixture accounts to create and load")
+	concurrency := fs.Int("concurrency", 50, "requests in flight at once")
+	rps := fs.Float64("rps", 0, "target requests per second, 0 for as fast as the concurrency allows")
+	duration := fs.Duration("duration", 30*time.Second, "how long to send load, 0 for no limit")
+	requests := fs.Int("requests", 0, "how many requests to send, 0 for no limit")
+	mix := fs.String("mix", "transaction=8,balance=1,account=1", "weight of each operation")
+	opTypes := fs.String("operation-types", "", "comma separated operation type ids of the transactions created, every type clients may post by default")
+	out := fs.String("out", "", "file to write the JSON results to (default loadgen-<time>.json)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+
+	cfg := loadgen.Config{
+		Client:      client.Config{URL: *apiURL, APIKey: *apiKey, Token: *token, Timeout: *timeout},
+		Accounts:    *accounts,
+		Concurrency: *concurrency,
+		RPS:         *rps,
+		Duration:    *duration,
+		Requests:    *requests,
+	}
+	var err error
+	if cfg.Mix, err = loadgen.ParseMix(*mix); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %s\n", err)
+		return 2
+	}
+	if *opTypes != "" {
+		for _, s := range strings.Split(*opTypes, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "loadgen: invalid operation type id %q\n", s)
+				return 2
+			}
+			cfg.OperationTypes = append(cfg.OperationTypes, id)
+		}
+	}
+
+	result, err := loadgen.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %s\n", err)
+		return 1
+	}
+	if err := result.WriteSummary(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %s\n", err)
+		return 1
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("loadgen-%s.json", result.StartedAt.UTC().Format("20060102T150405Z"))
+	}
+	b, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		err = os.WriteFile(*out, append(b, '\n'), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: cannot write results: %s\n", err)
+		return 1
+	}
+	fmt.Printf("results written to %s\n", *out)
+
+	if !result.Balances.Passed {
+		return 1
+	}
+	return 0
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
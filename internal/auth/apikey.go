@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+const apiKeyBytes = 32
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// NewAPIKey generates a random API key. Only its hash should be persisted.
+func NewAPIKey() (string, error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of key. API keys are random and
+// long enough that a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
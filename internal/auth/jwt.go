@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+// JWTVerifier validates HS256 and RS256 tokens. Each algorithm is only
+// accepted when its key is configured.
+type JWTVerifier struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	parser     *jwt.Parser
+}
+
+func NewJWTVerifier(hmacSecret, rsaPublicKeyFile, issuer, audience string) (*JWTVerifier, error) {
+	v := &JWTVerifier{}
+	methods := []string{}
+	if hmacSecret != "" {
+		v.hmacSecret = []byte(hmacSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if rsaPublicKeyFile != "" {
+		pem, err := os.ReadFile(rsaPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read jwt public key: %w", err)
+		}
+		v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse jwt public key: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify validates token and returns the principal it identifies. Scopes are
+// read from the space separated "scope" claim.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, v.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	return &Principal{
+		Subject: c.Subject,
+		Method:  MethodJWT,
+		Scopes:  strings.Fields(c.Scope),
+	}, nil
+}
+
+func (v *JWTVerifier) key(t *jwt.Token) (any, error) {
+	switch t.Method {
+	case jwt.SigningMethodHS256:
+		return v.hmacSecret, nil
+	case jwt.SigningMethodRS256:
+		return v.rsaKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", t.Header["alg"])
+}
@@ -0,0 +1,57 @@
+package card
+
+import (
+	"encoding/hex"
+	"io"
+	"time"
+	"transaction-routine/internal/entity"
+)
+
+const tokenBytes = 16
+
+// Issuer generates the cards of a BIN, valid for a number of months.
+type Issuer struct {
+	bin      string
+	length   int
+	validity int
+	vault    *Vault
+	rand     io.Reader
+}
+
+func NewIssuer(bin string, length, validityMonths int, vault *Vault, r io.Reader) (*Issuer, error) {
+	if err := checkBIN(bin, length); err != nil {
+		return nil, err
+	}
+	if validityMonths <= 0 {
+		validityMonths = 1
+	}
+	return &Issuer{bin: bin, length: length, validity: validityMonths, vault: vault, rand: r}, nil
+}
+
+// Issue returns a new active card of the account, expiring validity months
+// after now. Only its encrypted PAN and fingerprint are kept.
+func (i *Issuer) Issue(accountID int, now time.Time) (entity.Card, error) {
+	pan, err := GeneratePAN(i.bin, i.length, i.rand)
+	if err != nil {
+		return entity.Card{}, err
+	}
+	encrypted, err := i.vault.Seal(pan)
+	if err != nil {
+		return entity.Card{}, err
+	}
+	b := make([]byte, tokenBytes)
+	if _, err := io.ReadFull(i.rand, b); err != nil {
+		return entity.Card{}, err
+	}
+	expiry := time.Date(now.Year(), now.Month()+time.Month(i.validity), 1, 0, 0, 0, 0, now.Location())
+	return entity.Card{
+		Token:        "card_" + hex.EncodeToString(b),
+		AccountID:    accountID,
+		MaskedPAN:    Mask(pan),
+		ExpiryMonth:  int(expiry.Month()),
+		ExpiryYear:   expiry.Year(),
+		Status:       entity.CardActive,
+		EncryptedPAN: encrypted,
+		Fingerprint:  i.vault.Fingerprint(pan),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package card
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidBIN       = errors.New("invalid bin, expected 6 to 8 digits")
+	ErrInvalidPANLength = errors.New("invalid pan length, expected 12 to 19 digits and more than the bin")
+)
+
+// GeneratePAN returns a random PAN of length digits starting with bin and
+// ending with its Luhn check digit, reading randomness from r.
+func GeneratePAN(bin string, length int, r io.Reader) (string, error) {
+	if err := checkBIN(bin, length); err != nil {
+		return "", err
+	}
+	digits := []byte(bin)
+	ten := big.NewInt(10)
+	for len(digits) < length-1 {
+		n, err := rand.Int(r, ten)
+		if err != nil {
+			return "", err
+		}
+		digits = append(digits, byte('0'+n.Int64()))
+	}
+	return string(append(digits, checkDigit(string(digits)))), nil
+}
+
+func checkBIN(bin string, length int) error {
+	if len(bin) < 6 || len(bin) > 8 || !isDigits(bin) {
+		return ErrInvalidBIN
+	}
+	if length < 12 || length > 19 || length <= len(bin)+1 {
+		return ErrInvalidPANLength
+	}
+	return nil
+}
+
+// LuhnValid reports whether pan is all digits and passes the Luhn check.
+func LuhnValid(pan string) bool {
+	if len(pan) < 2 || !isDigits(pan) {
+		return false
+	}
+	return checkDigit(pan[:len(pan)-1]) == pan[len(pan)-1]
+}
+
+// checkDigit returns the Luhn check digit to append to digits.
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// Doubling starts from the rightmost digit, which sits next to
+		// the check digit.
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// Mask keeps the first six and last four digits of pan, as allowed for
+// display, and hides the others.
+func Mask(pan string) string {
+	if len(pan) < 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
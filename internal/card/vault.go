@@ -0,0 +1,81 @@
+package card
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+const keyBytes = 32
+
+var (
+	ErrInvalidKey        = errors.New("invalid card encryption key, expected 32 bytes encoded in base64")
+	ErrInvalidCiphertext = errors.New("invalid card ciphertext")
+)
+
+// Vault encrypts PANs with AES-256-GCM. Ciphertexts are prefixed with their
+// random nonce. Fingerprints are HMAC-SHA256 of the PAN under a key derived
+// from the encryption key, so equal PANs can be found without decrypting.
+type Vault struct {
+	aead           cipher.AEAD
+	fingerprintKey []byte
+	rand           io.Reader
+}
+
+// ParseKey decodes a base64 encoded 32 byte key.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != keyBytes {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// NewVault returns a vault using key, reading nonces from r.
+func NewVault(key []byte, r io.Reader) (*Vault, error) {
+	if len(key) != keyBytes {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("pan-fingerprint"))
+	return &Vault{aead: aead, fingerprintKey: h.Sum(nil), rand: r}, nil
+}
+
+func (v *Vault) Seal(pan string) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := io.ReadFull(v.rand, nonce); err != nil {
+		return nil, err
+	}
+	return v.aead.Seal(nonce, nonce, []byte(pan), nil), nil
+}
+
+func (v *Vault) Open(ciphertext []byte) (string, error) {
+	n := v.aead.NonceSize()
+	if len(ciphertext) < n {
+		return "", ErrInvalidCiphertext
+	}
+	pan, err := v.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(pan), nil
+}
+
+func (v *Vault) Fingerprint(pan string) string {
+	h := hmac.New(sha256.New, v.fingerprintKey)
+	h.Write([]byte(pan))
+	return hex.EncodeToString(h.Sum(nil))
+}
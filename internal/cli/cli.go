@@ -0,0 +1,229 @@
+// Package cli implements trctl, the command line tool operators use to manage
+// accounts, transactions and operation types through the HTTP API.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-routine/internal/client"
+)
+
+// Exit codes, so scripts can tell API errors apart.
+const (
+	ExitOK           = 0
+	ExitError        = 1
+	ExitUsage        = 2
+	ExitInvalid      = 3
+	ExitUnauthorized = 4
+	ExitNotFound     = 5
+	ExitRateLimited  = 6
+	ExitServer       = 7
+	ExitMismatch     = 8
+)
+
+var (
+	errUsage        = errors.New("invalid usage")
+	errMismatch     = errors.New("responses differ from the recording")
+	errUnreconciled = errors.New("balances do not reconcile")
+)
+
+type command struct {
+	group, name string
+	args        string
+	summary     string
+	run         func(e *env, args []string) error
+}
+
+// env is what commands run with: the resolved client and output settings.
+type env struct {
+	ctx        context.Context
+	client     *client.Client
+	api        client.Config
+	out        printer
+	stderr     io.Writer
+	profiles   *Profiles
+	configPath string
+}
+
+// Run executes trctl with args, without the program name, and returns the
+// process exit code.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("trctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", defaultConfigPath(), "config file with the profiles")
+	profileName := fs.String("profile", os.Getenv("TRCTL_PROFILE"), "profile to use instead of the current one")
+	apiURL := fs.String("url", os.Getenv("TRCTL_URL"), "API base URL, overriding the profile")
+	apiKey := fs.String("api-key", os.Getenv("TRCTL_API_KEY"), "API key, overriding the profile")
+	token := fs.String("token", os.Getenv("TRCTL_TOKEN"), "bearer token, overriding the profile")
+	output := fs.String("o", outputTable, "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each API request")
+	fs.Usage = func() { usage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(stderr, "trctl: unknown output format %q\n", *output)
+		return ExitUsage
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		fs.Usage()
+		return ExitUsage
+	}
+	cmd := findCommand(rest[0], rest[1])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "trctl: unknown command %q\n", strings.Join(rest[:2], " "))
+		return ExitUsage
+	}
+
+	profiles, err := loadProfiles(*configPath)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	e := &env{
+		ctx:        ctx,
+		out:        printer{format: *output, w: stdout},
+		stderr:     stderr,
+		profiles:   profiles,
+		configPath: *configPath,
+	}
+	if cmd.group != "profiles" {
+		profile, err := profiles.resolve(*profileName)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		e.api = client.Config{
+			URL:     firstNonEmpty(*apiURL, profile.URL),
+			APIKey:  firstNonEmpty(*apiKey, profile.APIKey),
+			Token:   firstNonEmpty(*token, profile.Token),
+			Timeout: *timeout,
+		}
+		e.client = client.New(e.api)
+	}
+	if err := cmd.run(e, rest[2:]); err != nil {
+		return fail(stderr, err)
+	}
+	return ExitOK
+}
+
+func findCommand(group, name string) *command {
+	for i := range commands {
+		if commands[i].group == group && commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: trctl [flags] <group> <command> [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-40s %s\n", strings.TrimSpace(c.group+" "+c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+}
+
+// fail reports err and maps it to an exit code.
+func fail(w io.Writer, err error) int {
+	fmt.Fprintf(w, "trctl: %s\n", err)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		for _, fe := range apiErr.Errors {
+			if fe.Field != "" {
+				fmt.Fprintf(w, "  %s: %s\n", fe.Field, fe.Message)
+			} else {
+				fmt.Fprintf(w, "  %s\n", fe.Message)
+			}
+		}
+	}
+	return ExitCode(err)
+}
+
+// ExitCode maps an error to the exit code trctl finishes with.
+func ExitCode(err error) int {
+	var apiErr *client.APIError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, errMismatch), errors.Is(err, errUnreconciled):
+		return ExitMismatch
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.Status == http.StatusUnauthorized, apiErr.Status == http.StatusForbidden:
+			return ExitUnauthorized
+		case apiErr.Status == http.StatusNotFound:
+			return ExitNotFound
+		case apiErr.Status == http.StatusTooManyRequests:
+			return ExitRateLimited
+		case apiErr.Status >= http.StatusInternalServerError:
+			return ExitServer
+		}
+		return ExitInvalid
+	}
+	return ExitError
+}
+
+// flags returns the flag set of a command, reporting parse errors as usage
+// errors.
+func (e *env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %v", errUsage, fs.Args())
+	}
+	return nil
+}
+
+// idArg takes the leading id argument, returning the arguments left for the
+// command flags.
+func idArg(args []string) (int, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, fmt.Errorf("%w: missing id", errUsage)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, nil, fmt.Errorf("%w: invalid id %q", errUsage, args[0])
+	}
+	return id, args[1:], nil
+}
+
+// nameArg takes the leading name argument, returning the arguments left for
+// the command flags.
+func nameArg(args []string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, fmt.Errorf("%w: missing name", errUsage)
+	}
+	return args[0], args[1:], nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
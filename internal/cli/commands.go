@@ -0,0 +1,429 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/replay"
+
+	"github.com/shopspring/decimal"
+)
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"accounts", "create", "-document <number>", "create an account", createAccount},
+		{"accounts", "get", "<id>", "show an account", getAccount},
+		{"accounts", "balance", "<id>", "show the balance of an account", getBalance},
+		{"accounts", "reconcile", "[-closing <report>] [-report <file>] [-apply]", "recompute balances and report discrepancies", reconcile},
+		{"transactions", "create", "(-account <id> | -card <token>) -type <id> -amount <amount> [-currency <code>]", "create a transaction", createTransaction},
+		{"transactions", "update", "<id> [-account <id>] [-type <id>] [-amount <amount>] [-currency <code>] [-event-date <date>] [-replace]", "update a transaction", updateTransaction},
+		{"transactions", "list", "[-account <id>] [-type <id>]", "list transactions", listTransactions},
+		{"operation-types", "list", "", "list operation types", listOperationTypes},
+		{"operation-types", "create", "-description <text> [-positive]", "create an operation type", createOperationType},
+		{"traffic", "replay", "<file> [-speed <n>]", "replay recorded requests and report status differences", replayTraffic},
+		{"profiles", "list", "", "list the configured profiles", listProfiles},
+		{"profiles", "set", "<name> [-url <url>] [-api-key <key>] [-token <token>]", "create or change a profile", setProfile},
+		{"profiles", "use", "<name>", "make a profile the current one", useProfile},
+	}
+}
+
+var (
+	accountHeaders     = []string{"ID", "DOCUMENT NUMBER"}
+	transactionHeaders = []string{"ID", "ACCOUNT", "TYPE", "AMOUNT", "EVENT DATE"}
+)
+
+func accountRow(acc entity.Account) []string {
+	return []string{strconv.Itoa(acc.ID), acc.DocumentNumber}
+}
+
+func transactionRow(tx entity.Transaction) []string {
+	return []string{
+		strconv.Itoa(tx.ID),
+		strconv.Itoa(tx.AccountID),
+		strconv.Itoa(tx.OperationTypeID),
+		tx.Amount.String(),
+		tx.EventDate.Format(entity.TimeLayout),
+	}
+}
+
+func createAccount(e *env, args []string) error {
+	fs := e.flags("accounts create")
+	document := fs.String("document", "", "document number of the account holder")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *document == "" {
+		return fmt.Errorf("%w: -document is required", errUsage)
+	}
+	acc, err := e.client.CreateAccount(e.ctx, *document)
+	if err != nil {
+		return err
+	}
+	return e.out.print(acc, accountHeaders, [][]string{accountRow(acc)})
+}
+
+func getAccount(e *env, args []string) error {
+	id, args, err := idArg(args)
+	if err != nil {
+		return err
+	}
+	if err := parse(e.flags("accounts get"), args); err != nil {
+		return err
+	}
+	acc, err := e.client.GetAccount(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	return e.out.print(acc, accountHeaders, [][]string{accountRow(acc)})
+}
+
+func getBalance(e *env, args []string) error {
+	id, args, err := idArg(args)
+	if err != nil {
+		return err
+	}
+	if err := parse(e.flags("accounts balance"), args); err != nil {
+		return err
+	}
+	balance, err := e.client.GetBalance(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	v := map[string]any{"account_id": id, "balance": balance}
+	return e.out.print(v, []string{"ACCOUNT", "BALANCE"}, [][]string{{strconv.Itoa(id), balance.String()}})
+}
+
+// reconcile runs a reconciliation and writes its report to a file, which can
+// be given as -closing to the next run.
+func reconcile(e *env, args []string) error {
+	fs := e.flags("accounts reconcile")
+	closingPath := fs.String("closing", "", "report of a previous run whose balances must not have changed")
+	reportPath := fs.String("report", "", "file to write the report to (default reconciliation-<time>.json)")
+	apply := fs.Bool("apply", false, "post adjustment transactions fixing the sign violations")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	req := entity.ReconciliationRequest{Apply: *apply}
+	if *closingPath != "" {
+		b, err := os.ReadFile(*closingPath)
+		if err != nil {
+			return err
+		}
+		var previous entity.ReconciliationReport
+		if err := json.Unmarshal(b, &previous); err != nil {
+			return fmt.Errorf("invalid closing report %s: %w", *closingPath, err)
+		}
+		req.Closing = &entity.ClosingBalances{AsOf: previous.AsOf, Balances: previous.Balances}
+	}
+	report, err := e.client.Reconcile(e.ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if *reportPath == "" {
+		*reportPath = fmt.Sprintf("reconciliation-%s.json", report.AsOf.UTC().Format("20060102T150405Z"))
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*reportPath, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, d := range report.Drifts {
+		rows = append(rows, []string{"drift", strconv.Itoa(d.AccountID), "", d.Expected.String(), d.Actual.String()})
+	}
+	for _, v := range report.SignViolations {
+		rows = append(rows, []string{"sign", strconv.Itoa(v.AccountID), strconv.Itoa(v.TransactionID), "", v.Amount.String()})
+	}
+	for _, a := range report.Adjustments {
+		rows = append(rows, []string{"adjustment", strconv.Itoa(a.AccountID), strconv.Itoa(a.TransactionID), "", a.Amount.String()})
+	}
+	if err := e.out.print(report, []string{"KIND", "ACCOUNT", "TRANSACTION", "EXPECTED", "ACTUAL"}, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "%d accounts reconciled, report written to %s\n", len(report.Balances), *reportPath)
+	if !report.Clean() {
+		return errUnreconciled
+	}
+	return nil
+}
+
+func createTransaction(e *env, args []string) error {
+	fs := e.flags("transactions create")
+	accountID := fs.Int("account", 0, "account id")
+	cardToken := fs.String("card", "", "token of a card of the account, in place of -account")
+	opTypeID := fs.Int("type", 0, "operation type id")
+	amount := fs.String("amount", "", "amount, its sign is set by the operation type")
+	currency := fs.String("currency", "", "currency of the amount (default the account currency)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	value, err := decimal.NewFromString(*amount)
+	if err != nil {
+		return fmt.Errorf("%w: invalid -amount %q", errUsage, *amount)
+	}
+	tx, err := e.client.CreateTransaction(e.ctx, entity.Transaction{
+		AccountID:        *accountID,
+		CardToken:        *cardToken,
+		OperationTypeID:  *opTypeID,
+		Amount:           value,
+		OriginalCurrency: entity.Currency(*currency),
+	})
+	if err != nil {
+		return err
+	}
+	return e.out.print(tx, transactionHeaders, [][]string{transactionRow(tx)})
+}
+
+// updateTransaction patches the fields given as flags, or replaces the whole
+// transaction with -replace.
+func updateTransaction(e *env, args []string) error {
+	id, args, err := idArg(args)
+	if err != nil {
+		return err
+	}
+	fs := e.flags("transactions update")
+	accountID := fs.Int("account", 0, "account id")
+	opTypeID := fs.Int("type", 0, "operation type id")
+	amount := fs.String("amount", "", "amount")
+	currency := fs.String("currency", "", "currency of the amount (default the account currency)")
+	eventDate := fs.String("event-date", "", "event date in RFC 3339")
+	replace := fs.Bool("replace", false, "replace every field instead of patching the given ones")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	patch := map[string]any{}
+	tx := entity.Transaction{ID: id, AccountID: *accountID, OperationTypeID: *opTypeID, OriginalCurrency: entity.Currency(*currency)}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "account":
+			patch["account_id"] = *accountID
+		case "type":
+			patch["operation_type_id"] = *opTypeID
+		case "amount":
+			patch["amount"] = *amount
+		case "currency":
+			patch["currency"] = *currency
+		case "event-date":
+			patch["event_date"] = *eventDate
+		}
+	})
+	if *amount != "" {
+		if tx.Amount, err = decimal.NewFromString(*amount); err != nil {
+			return fmt.Errorf("%w: invalid -amount %q", errUsage, *amount)
+		}
+	}
+	if *eventDate != "" {
+		if tx.EventDate, err = time.Parse(time.RFC3339, *eventDate); err != nil {
+			return fmt.Errorf("%w: invalid -event-date %q", errUsage, *eventDate)
+		}
+	}
+
+	if *replace {
+		for _, field := range []string{"account_id", "operation_type_id", "amount", "event_date"} {
+			if _, ok := patch[field]; !ok {
+				return fmt.Errorf("%w: -replace requires -account, -type, -amount and -event-date", errUsage)
+			}
+		}
+		err = e.client.ReplaceTransaction(e.ctx, tx)
+	} else {
+		if len(patch) == 0 {
+			return fmt.Errorf("%w: nothing to update", errUsage)
+		}
+		err = e.client.PatchTransaction(e.ctx, id, patch)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "transaction %d updated\n", id)
+	return nil
+}
+
+func listTransactions(e *env, args []string) error {
+	fs := e.flags("transactions list")
+	accountID := fs.Int("account", 0, "only transactions of this account")
+	opTypeID := fs.Int("type", 0, "only transactions of this operation type")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	var filter entity.TransactionFilter
+	if *accountID != 0 {
+		filter.AccountID = accountID
+	}
+	if *opTypeID != 0 {
+		filter.OperationTypeID = opTypeID
+	}
+	txs, err := e.client.ListTransactions(e.ctx, filter)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(txs))
+	for i, tx := range txs {
+		rows[i] = transactionRow(tx)
+	}
+	return e.out.print(txs, transactionHeaders, rows)
+}
+
+func listOperationTypes(e *env, args []string) error {
+	if err := parse(e.flags("operation-types list"), args); err != nil {
+		return err
+	}
+	ops, err := e.client.ListOperationTypes(e.ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(ops))
+	for id := range ops {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	rows := make([][]string, len(ids))
+	for i, id := range ids {
+		rows[i] = []string{strconv.Itoa(id), ops[id].Description, strconv.FormatBool(ops[id].PositiveAmount)}
+	}
+	return e.out.print(ops, []string{"ID", "DESCRIPTION", "POSITIVE AMOUNT"}, rows)
+}
+
+func createOperationType(e *env, args []string) error {
+	fs := e.flags("operation-types create")
+	description := fs.String("description", "", "description of the operation type")
+	positive := fs.Bool("positive", false, "whether its amounts are positive")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := e.client.CreateOperationType(e.ctx, entity.Operation{Description: *description, PositiveAmount: *positive}); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "operation type %q created\n", *description)
+	return nil
+}
+
+// replayTraffic sends the requests recorded in a JSON lines capture to the
+// API, exiting with ExitMismatch when any status differs from the recording.
+func replayTraffic(e *env, args []string) error {
+	path, args, err := nameArg(args)
+	if err != nil {
+		return fmt.Errorf("%w: missing capture file", errUsage)
+	}
+	fs := e.flags("traffic replay")
+	speed := fs.Float64("speed", 1, "speed multiplier of the recorded timing, 0 to send without delays")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *speed < 0 {
+		return fmt.Errorf("%w: -speed must not be negative", errUsage)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := replay.New(replay.Config{
+		URL:     e.api.URL,
+		APIKey:  e.api.APIKey,
+		Token:   e.api.Token,
+		Timeout: e.api.Timeout,
+		Speed:   *speed,
+	}).Run(e.ctx, f)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(report.Diffs))
+	for i, d := range report.Diffs {
+		replayed := strconv.Itoa(d.Replayed)
+		if d.Error != "" {
+			replayed = d.Error
+		}
+		rows[i] = []string{strconv.Itoa(d.Line), d.Method, d.Path, strconv.Itoa(d.Recorded), replayed}
+	}
+	if err := e.out.print(report, []string{"LINE", "METHOD", "PATH", "RECORDED", "REPLAYED"}, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "%d requests replayed, %d matched\n", report.Total, report.Matched)
+	if len(report.Diffs) > 0 {
+		return fmt.Errorf("%w: %d of %d", errMismatch, len(report.Diffs), report.Total)
+	}
+	return nil
+}
+
+func listProfiles(e *env, args []string) error {
+	if err := parse(e.flags("profiles list"), args); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(e.profiles.Profiles))
+	for name := range e.profiles.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		p := e.profiles.Profiles[name]
+		auth := "none"
+		switch {
+		case p.APIKey != "":
+			auth = "api key"
+		case p.Token != "":
+			auth = "token"
+		}
+		current := ""
+		if name == e.profiles.Current {
+			current = "*"
+		}
+		rows[i] = []string{current, name, p.URL, auth}
+	}
+	// Secrets are never printed, not even as JSON.
+	v := map[string]any{"current": e.profiles.Current, "profiles": names}
+	return e.out.print(v, []string{"CURRENT", "NAME", "URL", "AUTH"}, rows)
+}
+
+func setProfile(e *env, args []string) error {
+	name, args, err := nameArg(args)
+	if err != nil {
+		return err
+	}
+	profile := e.profiles.Profiles[name]
+	fs := e.flags("profiles set")
+	fs.StringVar(&profile.URL, "url", profile.URL, "API base URL")
+	fs.StringVar(&profile.APIKey, "api-key", profile.APIKey, "API key")
+	fs.StringVar(&profile.Token, "token", profile.Token, "bearer token")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if profile.URL == "" {
+		return fmt.Errorf("%w: -url is required for a new profile", errUsage)
+	}
+	e.profiles.Profiles[name] = profile
+	if len(e.profiles.Profiles) == 1 {
+		e.profiles.Current = name
+	}
+	return e.profiles.save(e.configPath)
+}
+
+func useProfile(e *env, args []string) error {
+	name, args, err := nameArg(args)
+	if err != nil {
+		return err
+	}
+	if err := parse(e.flags("profiles use"), args); err != nil {
+		return err
+	}
+	if _, ok := e.profiles.Profiles[name]; !ok {
+		return fmt.Errorf("%w: unknown profile %q", errUsage, name)
+	}
+	e.profiles.Current = name
+	return e.profiles.save(e.configPath)
+}
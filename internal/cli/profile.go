@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const defaultProfile = "default"
+
+// Profile holds how to reach one environment of the API.
+type Profile struct {
+	URL    string `json:"url"`
+	APIKey string `json:"api_key,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// Profiles is the config file, keyed by profile name.
+type Profiles struct {
+	Current  string             `json:"current"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// defaultConfigPath is $TRCTL_CONFIG or trctl/config.json under the user
+// config directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("TRCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "trctl.json"
+	}
+	return filepath.Join(dir, "trctl", "config.json")
+}
+
+func loadProfiles(path string) (*Profiles, error) {
+	p := &Profiles{Current: defaultProfile, Profiles: map[string]Profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = map[string]Profile{}
+	}
+	return p, nil
+}
+
+// save writes the profiles readable only by the user, as they hold secrets.
+func (p *Profiles) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// resolve returns the named profile, or the current one when name is empty,
+// falling back to a local API when none is configured.
+func (p *Profiles) resolve(name string) (Profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+	profile, ok := p.Profiles[name]
+	if !ok {
+		if name != defaultProfile {
+			return Profile{}, fmt.Errorf("%w: unknown profile %q", errUsage, name)
+		}
+		profile = Profile{URL: "http://localhost:8080"}
+	}
+	return profile, nil
+}
@@ -0,0 +1,206 @@
+// Package client is a Go client for the transaction routine HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/shopspring/decimal"
+)
+
+const apiPrefix = "/v1"
+
+type Config struct {
+	URL     string
+	APIKey  string
+	Token   string
+	Timeout time.Duration
+}
+
+type Client struct {
+	baseURL string
+	apiKey  string
+	token   string
+	http    *http.Client
+}
+
+func New(cfg Config) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(cfg.URL, "/") + apiPrefix,
+		apiKey:  cfg.APIKey,
+		token:   cfg.Token,
+		http:    &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// FieldError is an invalid field reported by the API.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is returned for every response with a status of 400 or above.
+type APIError struct {
+	Status  int          `json:"-"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+func (c *Client) CreateAccount(ctx context.Context, documentNumber string) (entity.Account, error) {
+	var acc entity.Account
+	err := c.do(ctx, http.MethodPost, "/accounts", map[string]string{"document_number": documentNumber}, &acc)
+	return acc, err
+}
+
+func (c *Client) GetAccount(ctx context.Context, id int) (entity.Account, error) {
+	var acc entity.Account
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%d", id), nil, &acc)
+	return acc, err
+}
+
+func (c *Client) GetBalance(ctx context.Context, accountID int) (decimal.Decimal, error) {
+	var resp struct {
+		Balance decimal.Decimal `json:"balance"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%d/balance", accountID), nil, &resp)
+	return resp.Balance, err
+}
+
+// CreateTransaction posts tx to its account, or to the account of the card
+// whose token is in CardToken. The amount is in OriginalCurrency, or in the
+// account currency when it is empty.
+func (c *Client) CreateTransaction(ctx context.Context, tx entity.Transaction) (entity.Transaction, error) {
+	body := map[string]any{
+		"operation_type_id": tx.OperationTypeID,
+		"amount":            tx.Amount.String(),
+	}
+	if tx.CardToken != "" {
+		body["card_token"] = tx.CardToken
+	} else {
+		body["account_id"] = tx.AccountID
+	}
+	if tx.OriginalCurrency != "" {
+		body["currency"] = tx.OriginalCurrency
+	}
+	var created entity.Transaction
+	err := c.do(ctx, http.MethodPost, "/transactions", body, &created)
+	return created, err
+}
+
+// ReplaceTransaction overwrites every field of the transaction. An empty
+// OriginalCurrency puts the amount in the account currency.
+func (c *Client) ReplaceTransaction(ctx context.Context, tx entity.Transaction) error {
+	body := map[string]any{
+		"account_id":        tx.AccountID,
+		"operation_type_id": tx.OperationTypeID,
+		"amount":            tx.Amount.String(),
+		"event_date":        tx.EventDate.Format(entity.TimeLayout),
+	}
+	if tx.OriginalCurrency != "" {
+		body["currency"] = tx.OriginalCurrency
+	}
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/transactions/%d", tx.ID), body, nil)
+}
+
+// PatchTransaction sends a JSON merge patch: omitted fields are kept and
+// fields set to nil are cleared.
+func (c *Client) PatchTransaction(ctx context.Context, id int, patch map[string]any) error {
+	return c.do(ctx, http.MethodPatch, fmt.Sprintf("/transactions/%d", id), patch, nil)
+}
+
+func (c *Client) ListTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
+	query := url.Values{}
+	if filter.AccountID != nil {
+		query.Set("account_id", strconv.Itoa(*filter.AccountID))
+	}
+	if filter.OperationTypeID != nil {
+		query.Set("operation_type_id", strconv.Itoa(*filter.OperationTypeID))
+	}
+	path := "/transactions"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var resp struct {
+		Transactions []entity.Transaction `json:"transactions"`
+	}
+	err := c.do(ctx, http.MethodGet, path, nil, &resp)
+	return resp.Transactions, err
+}
+
+func (c *Client) ListOperationTypes(ctx context.Context) (entity.OperationType, error) {
+	var ops entity.OperationType
+	err := c.do(ctx, http.MethodGet, "/operation-types", nil, &ops)
+	return ops, err
+}
+
+func (c *Client) CreateOperationType(ctx context.Context, op entity.Operation) error {
+	return c.do(ctx, http.MethodPost, "/operation-types", op, nil)
+}
+
+// Reconcile recomputes the account balances on the server and reports the
+// problems found, applying adjustments when req.Apply is set.
+func (c *Client) Reconcile(ctx context.Context, req entity.ReconciliationRequest) (entity.ReconciliationReport, error) {
+	body := map[string]any{"apply": req.Apply}
+	if req.Closing != nil {
+		body["closing"] = req.Closing
+	}
+	var report entity.ReconciliationReport
+	err := c.do(ctx, http.MethodPost, "/reconciliations", body, &report)
+	return report, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{Status: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -5,22 +5,27 @@ import "time"
 
 type Clock interface {
 	Now() time.Time
+	Location() *time.Location
 }
 
 type clock struct {
 	loc *time.Location
 }
 
-func New() Clock {
-	loc, err := time.LoadLocation("America/Sao_Paulo")
+func New(timeZone string) (Clock, error) {
+	loc, err := time.LoadLocation(timeZone)
 	if err != nil {
-		loc = time.UTC
+		return nil, err
 	}
 	return &clock{
 		loc: loc,
-	}
+	}, nil
 }
 
 func (c *clock) Now() time.Time {
 	return time.Now().In(c.loc)
 }
+
+func (c *clock) Location() *time.Location {
+	return c.loc
+}
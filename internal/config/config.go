@@ -1,18 +1,65 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
-	AppEnv     string `envconfig:"APP_ENV" default:"development"`
-	Port       int    `envconfig:"PORT" default:"8080"`
-	DbHost     string `envconfig:"DB_HOST" default:"localhost"`
-	DbPort     int    `envconfig:"DB_PORT" default:"5432"`
-	DbName     string `envconfig:"DB_DATABASE" required:"true"`
-	DbUser     string `envconfig:"DB_USERNAME" required:"true"`
-	DbPassword string `envconfig:"DB_PASSWORD" required:"true"`
+	AppEnv           string        `envconfig:"APP_ENV" default:"development"`
+	Port             int           `envconfig:"PORT" default:"8080"`
+	TimeZone         string        `envconfig:"TIME_ZONE" default:"America/Sao_Paulo"`
+	LogLevel         string        `envconfig:"LOG_LEVEL" default:"info"`
+	TraceExporter    string        `envconfig:"TRACE_EXPORTER" default:"none"`
+	TraceFile        string        `envconfig:"TRACE_FILE" default:"traces.json"`
+	TraceSampleRatio float64       `envconfig:"TRACE_SAMPLE_RATIO" default:"1"`
+	ShutdownDrain    time.Duration `envconfig:"SHUTDOWN_DRAIN" default:"5s"`
+	AuthDisabled     bool          `envconfig:"AUTH_DISABLED" default:"false"`
+	JWTSecret        string        `envconfig:"JWT_HS256_SECRET"`
+	JWTPublicKeyFile string        `envconfig:"JWT_RS256_PUBLIC_KEY_FILE"`
+	JWTIssuer        string        `envconfig:"JWT_ISSUER"`
+	JWTAudience      string        `envconfig:"JWT_AUDIENCE"`
+	RateLimits       string        `envconfig:"RATE_LIMITS" default:"default=client:100/s;POST /transactions=client:50/s,account:10/s"`
+	AmountLimits     string        `envconfig:"AMOUNT_LIMITS"`
+	BatchMaxBytes    int64         `envconfig:"BATCH_MAX_BYTES" default:"16777216"`
+	BatchMaxItems    int           `envconfig:"BATCH_MAX_ITEMS" default:"10000"`
+	OutboxPublisher  string        `envconfig:"OUTBOX_PUBLISHER" default:"none"`
+	OutboxFile       string        `envconfig:"OUTBOX_FILE" default:"events.ndjson"`
+	OutboxURL        string        `envconfig:"OUTBOX_URL"`
+	OutboxTimeout    time.Duration `envconfig:"OUTBOX_TIMEOUT" default:"5s"`
+	OutboxInterval   time.Duration `envconfig:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	OutboxBatchSize  int           `envconfig:"OUTBOX_BATCH_SIZE" default:"100"`
+	OutboxGapWait    time.Duration `envconfig:"OUTBOX_GAP_WAIT" default:"5s"`
+	WebhooksEnabled  bool          `envconfig:"WEBHOOKS_ENABLED" default:"true"`
+	WebhookTimeout   time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookInterval  time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"1s"`
+	WebhookBatchSize int           `envconfig:"WEBHOOK_BATCH_SIZE" default:"50"`
+	WebhookAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookBackoff   time.Duration `envconfig:"WEBHOOK_BACKOFF" default:"30s"`
+	WebhookMaxDelay  time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+	StreamInterval   time.Duration `envconfig:"STREAM_POLL_INTERVAL" default:"500ms"`
+	StreamGapWait    time.Duration `envconfig:"STREAM_GAP_WAIT" default:"5s"`
+	StreamHeartbeat  time.Duration `envconfig:"STREAM_HEARTBEAT" default:"15s"`
+	SchedulerEnabled bool          `envconfig:"SCHEDULER_ENABLED" default:"true"`
+	SchedulerPoll    time.Duration `envconfig:"SCHEDULER_POLL_INTERVAL" default:"10s"`
+	SchedulerBatch   int           `envconfig:"SCHEDULER_BATCH_SIZE" default:"50"`
+	SchedulerTimeout time.Duration `envconfig:"SCHEDULER_RUN_TIMEOUT" default:"10s"`
+	DisputeCreditBy  time.Duration `envconfig:"DISPUTE_CREDIT_DEADLINE" default:"240h"`
+	DisputeResolveBy time.Duration `envconfig:"DISPUTE_RESOLUTION_DEADLINE" default:"1080h"`
+	DisputePoll      time.Duration `envconfig:"DISPUTE_POLL_INTERVAL" default:"1m"`
+	FraudRulesFile   string        `envconfig:"FRAUD_RULES_FILE"`
+	FraudReload      time.Duration `envconfig:"FRAUD_RULES_RELOAD" default:"30s"`
+	CardBIN          string        `envconfig:"CARD_BIN" default:"999999"`
+	CardPANLength    int           `envconfig:"CARD_PAN_LENGTH" default:"16"`
+	CardValidity     int           `envconfig:"CARD_VALIDITY_MONTHS" default:"48"`
+	CardKey          string        `envconfig:"CARD_ENCRYPTION_KEY"`
+	DbHost           string        `envconfig:"DB_HOST" default:"localhost"`
+	DbPort           int           `envconfig:"DB_PORT" default:"5432"`
+	DbName           string        `envconfig:"DB_DATABASE" required:"true"`
+	DbUser           string        `envconfig:"DB_USERNAME" required:"true"`
+	DbPassword       string        `envconfig:"DB_PASSWORD" required:"true"`
 }
 
 func New() (*Config, error) {
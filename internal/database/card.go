@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const cardTable = "pismo.card"
+
+const cardColumns = `
+	id,
+	token,
+	account_id,
+	masked_pan,
+	expiry_month,
+	expiry_year,
+	status,
+	created_at`
+
+// CreateCard stores c, failing with ErrCardExists if its token or PAN
+// fingerprint is taken.
+func (r *repo) CreateCard(ctx context.Context, c entity.Card) (entity.Card, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			token,
+			account_id,
+			pan_ciphertext,
+			pan_fingerprint,
+			masked_pan,
+			expiry_month,
+			expiry_year,
+			status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		cardTable,
+	)
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		c.Token, c.AccountID, c.EncryptedPAN, c.Fingerprint, c.MaskedPAN, c.ExpiryMonth, c.ExpiryYear, string(c.Status),
+	).Scan(&c.ID, &c.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return entity.Card{}, entity.ErrCardExists
+	}
+	return c, err
+}
+
+// FindCards returns the cards matching filter, in the order they were
+// issued. The encrypted PAN is not read.
+func (r *repo) FindCards(ctx context.Context, filter entity.CardFilter) ([]entity.Card, error) {
+	var conds []string
+	var args []any
+	if filter.Token != nil {
+		args = append(args, *filter.Token)
+		conds = append(conds, fmt.Sprintf("token = $%d", len(args)))
+	}
+	if filter.AccountID != nil {
+		args = append(args, *filter.AccountID)
+		conds = append(conds, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", cardColumns, cardTable)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id"
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanCard)
+}
+
+// UpdateCardStatus sets the status of the card with token, returning it, or
+// ErrCardNotFound.
+func (r *repo) UpdateCardStatus(ctx context.Context, token string, status entity.CardStatus) (entity.Card, error) {
+	query := fmt.Sprintf("UPDATE %s SET status = $2 WHERE token = $1 RETURNING %s", cardTable, cardColumns)
+	rows, err := r.pool.Query(ctx, query, token, string(status))
+	if err != nil {
+		return entity.Card{}, err
+	}
+	c, err := pgx.CollectOneRow(rows, scanCard)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Card{}, entity.ErrCardNotFound
+	}
+	return c, err
+}
+
+func scanCard(row pgx.CollectableRow) (entity.Card, error) {
+	var c entity.Card
+	err := row.Scan(&c.ID, &c.Token, &c.AccountID, &c.MaskedPAN, &c.ExpiryMonth, &c.ExpiryYear, &c.Status, &c.CreatedAt)
+	return c, err
+}
@@ -26,5 +26,6 @@ func poolConfig(connStr string) (*pgxpool.Config, error) {
 	dbConfig.MaxConnIdleTime = defaultMaxConnIdleTime
 	dbConfig.HealthCheckPeriod = defaultHealthCheckPeriod
 	dbConfig.ConnConfig.ConnectTimeout = defaultConnectTimeout
+	dbConfig.ConnConfig.Tracer = queryLogger{}
 	return dbConfig, nil
 }
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateCustomer stores c. A customer without a name holding the same
+// document number, created along with an account, is filled in with c
+// instead; any other one fails with ErrCustomerExists.
+func (r *repo) CreateCustomer(ctx context.Context, c entity.Customer) (entity.Customer, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s AS c (
+			document_number,
+			name,
+			kyc_status,
+			email,
+			phone
+		) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (document_number) DO UPDATE SET
+			name = EXCLUDED.name,
+			kyc_status = EXCLUDED.kyc_status,
+			email = EXCLUDED.email,
+			phone = EXCLUDED.phone
+		WHERE c.name = ''
+		RETURNING id, created_at`,
+		customerTable,
+	)
+	err := r.pool.QueryRow(ctx, query, c.DocumentNumber, c.Name, string(c.KYCStatus), c.Email, c.Phone).Scan(&c.ID, &c.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Customer{}, entity.ErrCustomerExists
+	}
+	return c, err
+}
+
+func (r *repo) FindCustomers(ctx context.Context, filter entity.CustomerFilter) ([]entity.Customer, error) {
+	var conds []string
+	var args []any
+	if filter.ID != nil {
+		args = append(args, *filter.ID)
+		conds = append(conds, fmt.Sprintf("id = $%d", len(args)))
+	}
+	if filter.DocumentNumber != nil {
+		args = append(args, *filter.DocumentNumber)
+		conds = append(conds, fmt.Sprintf("document_number = $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT id, document_number, name, kyc_status, email, phone, created_at FROM %s", customerTable)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id"
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Customer, error) {
+		var c entity.Customer
+		err := row.Scan(&c.ID, &c.DocumentNumber, &c.Name, &c.KYCStatus, &c.Email, &c.Phone, &c.CreatedAt)
+		return c, err
+	})
+}
+
+// FindCustomerBalances returns the balance of every account of the customer,
+// in the order they were opened.
+func (r *repo) FindCustomerBalances(ctx context.Context, customerID int) ([]entity.CustomerAccountBalance, error) {
+	query := fmt.Sprintf(`
+		SELECT a.id, a.currency, COALESCE(SUM(t.amount), 0)
+		FROM %s a
+		LEFT JOIN %s t ON t.account_id = a.id
+		WHERE a.customer_id = $1
+		GROUP BY a.id
+		ORDER BY a.id`,
+		accountTable, transactionTable,
+	)
+	rows, err := r.pool.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.CustomerAccountBalance, error) {
+		var b entity.CustomerAccountBalance
+		err := row.Scan(&b.AccountID, &b.Currency, &b.Balance)
+		return b, err
+	})
+}
@@ -5,31 +5,86 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"transaction-routine/internal/config"
 	"transaction-routine/internal/entity"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/shopspring/decimal"
 )
 
 const (
 	operationTypeTable = "pismo.operation_type"
 	accountTable       = "pismo.account"
+	customerTable      = "pismo.customer"
 	transactionTable   = "pismo.transaction"
+	migrationsTable    = "schema_migrations"
+	apiKeyTable        = "pismo.api_key"
+	adjustmentTable    = "pismo.adjustment"
+	outboxTable        = "pismo.outbox"
+	outboxRelayTable   = "pismo.outbox_relay"
 )
 
 type Repository interface {
 	Health(ctx context.Context) error
+	Stat() entity.PoolStats
+	MigrationVersion(ctx context.Context) (version int, dirty bool, err error)
 	CreateOperationType(ctx context.Context, op entity.Operation) error
 	FindOperationType(ctx context.Context) (entity.OperationType, error)
-	CreateAccount(ctx context.Context, acc entity.Account) error
+	CreateAccount(ctx context.Context, acc entity.Account) (entity.Account, error)
 	FindAccounts(ctx context.Context, filter entity.AccountFilter) ([]entity.Account, error)
-	CreateTransaction(ctx context.Context, tx entity.Transaction) error
+	FindAccountIDs(ctx context.Context, ids []int) ([]int, error)
+	FindAccountCurrencies(ctx context.Context, ids []int) (map[int]entity.Currency, error)
+	CreateTransaction(ctx context.Context, tx entity.Transaction) (int, error)
+	CreateTransactions(ctx context.Context, txs []entity.Transaction) ([]int, error)
 	FindTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error)
 	UpdateTransaction(ctx context.Context, tx entity.Transaction) error
+	AccountBalances(ctx context.Context, asOf *time.Time) ([]entity.AccountBalance, error)
+	FindSignViolations(ctx context.Context) ([]entity.SignViolation, error)
+	CreateAdjustments(ctx context.Context, adjs []entity.Adjustment) ([]int, error)
+	RelayEvents(ctx context.Context, limit int, gapWait time.Duration, publish func(entity.Event) error) (int, error)
+	FindEvents(ctx context.Context, filter entity.EventFilter) ([]entity.Event, error)
+	LastEventID(ctx context.Context) (int64, error)
+	CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	FindWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error)
+	FindWebhook(ctx context.Context, id int) (*entity.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	EnqueueDeliveries(ctx context.Context, event entity.Event) (int, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, filter entity.DeliveryFilter) ([]entity.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (entity.WebhookDelivery, error)
+	CreateSchedule(ctx context.Context, s entity.Schedule) (int, error)
+	FindSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error)
+	CancelSchedule(ctx context.Context, id int) error
+	ClaimScheduleRuns(ctx context.Context, now time.Time, limit int) ([]entity.ScheduleRun, error)
+	ReclaimScheduleRuns(ctx context.Context, before, now time.Time, limit int) ([]entity.ScheduleRun, error)
+	StartScheduleRun(ctx context.Context, id int64, at time.Time) error
+	FinishScheduleRun(ctx context.Context, run entity.ScheduleRun) error
+	FindScheduleRuns(ctx context.Context, scheduleID int) ([]entity.ScheduleRun, error)
+	CreateDispute(ctx context.Context, d entity.Dispute) (int, error)
+	FindDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error)
+	TransitionDispute(ctx context.Context, d entity.Dispute, from entity.DisputeStatus, posting *entity.Transaction) (entity.Dispute, error)
+	RecentTransactions(ctx context.Context, accountID int, since time.Time) ([]entity.Transaction, error)
+	CreateFraudDecision(ctx context.Context, d entity.FraudDecision) (int64, error)
+	FindFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error)
+	CreateFXRate(ctx context.Context, rate entity.FXRate) (entity.FXRate, error)
+	FindFXRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error)
+	FindFXRate(ctx context.Context, base, quote entity.Currency, at time.Time) (*entity.FXRate, error)
+	CreateCustomer(ctx context.Context, c entity.Customer) (entity.Customer, error)
+	FindCustomers(ctx context.Context, filter entity.CustomerFilter) ([]entity.Customer, error)
+	FindCustomerBalances(ctx context.Context, customerID int) ([]entity.CustomerAccountBalance, error)
+	CreateCard(ctx context.Context, c entity.Card) (entity.Card, error)
+	FindCards(ctx context.Context, filter entity.CardFilter) ([]entity.Card, error)
+	UpdateCardStatus(ctx context.Context, token string, status entity.CardStatus) (entity.Card, error)
+	CreateAPIKey(ctx context.Context, key entity.APIKey) error
+	FindAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error)
 }
 
 type repo struct {
@@ -74,6 +129,26 @@ func (r *repo) Health(ctx context.Context) error {
 	return nil
 }
 
+func (r *repo) Stat() entity.PoolStats {
+	stat := r.pool.Stat()
+	return entity.PoolStats{
+		Acquired: stat.AcquiredConns(),
+		Idle:     stat.IdleConns(),
+		Total:    stat.TotalConns(),
+		Max:      stat.MaxConns(),
+	}
+}
+
+func (r *repo) MigrationVersion(ctx context.Context) (int, bool, error) {
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", migrationsTable)
+	var version int
+	var dirty bool
+	if err := r.pool.QueryRow(ctx, query).Scan(&version, &dirty); err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
 func (r *repo) CreateOperationType(ctx context.Context, op entity.Operation) error {
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
@@ -115,30 +190,65 @@ func (r *repo) FindOperationType(ctx context.Context) (entity.OperationType, err
 	return ops, nil
 }
 
-func (r *repo) CreateAccount(ctx context.Context, acc entity.Account) error {
+// CreateAccount opens acc for its customer, or for the customer with its
+// document number when it has none, creating that customer without a name if
+// there is none yet. It fails with ErrCustomerNotFound when the customer of
+// acc does not exist.
+func (r *repo) CreateAccount(ctx context.Context, acc entity.Account) (entity.Account, error) {
+	upsertCustomer := fmt.Sprintf(`
+		INSERT INTO %s (document_number)
+		VALUES ($1)
+		ON CONFLICT (document_number) DO UPDATE SET document_number = EXCLUDED.document_number
+		RETURNING id`,
+		customerTable,
+	)
+	findCustomer := fmt.Sprintf("SELECT document_number FROM %s WHERE id = $1", customerTable)
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
-			document_number
-		) VALUES ($1)`,
+			document_number,
+			currency,
+			customer_id
+		) VALUES ($1, $2, $3)
+		RETURNING id`,
 		accountTable,
 	)
-	_, err := r.pool.Exec(
-		ctx,
-		query,
-		acc.DocumentNumber,
-	)
-	return err
+	err := r.inTx(ctx, func(dbtx pgx.Tx) error {
+		var err error
+		if acc.CustomerID == 0 {
+			err = dbtx.QueryRow(ctx, upsertCustomer, acc.DocumentNumber).Scan(&acc.CustomerID)
+		} else {
+			err = dbtx.QueryRow(ctx, findCustomer, acc.CustomerID).Scan(&acc.DocumentNumber)
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = entity.ErrCustomerNotFound
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := dbtx.QueryRow(ctx, query, acc.DocumentNumber, acc.Currency, acc.CustomerID).Scan(&acc.ID); err != nil {
+			return err
+		}
+		return insertEvents(ctx, dbtx, entity.NewAccountCreated(acc))
+	})
+	if err != nil {
+		return entity.Account{}, err
+	}
+	return acc, nil
 }
 
 func (r *repo) FindAccounts(ctx context.Context, filter entity.AccountFilter) ([]entity.Account, error) {
 	query := fmt.Sprintf(`
 		SELECT
 			id,
-			document_number
+			document_number,
+			currency,
+			customer_id
 		FROM %s
 		WHERE
 			(id = COALESCE($1, id))
 			AND (document_number = COALESCE($2, document_number))
+			AND (customer_id = COALESCE($3, customer_id))
+		ORDER BY id
 		`,
 		accountTable,
 	)
@@ -147,6 +257,7 @@ func (r *repo) FindAccounts(ctx context.Context, filter entity.AccountFilter) ([
 		query,
 		filter.ID,
 		filter.DocumentNumber,
+		filter.CustomerID,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -159,7 +270,7 @@ func (r *repo) FindAccounts(ctx context.Context, filter entity.AccountFilter) ([
 	accs := make([]entity.Account, 0)
 	for rows.Next() {
 		var acc entity.Account
-		err := rows.Scan(&acc.ID, &acc.DocumentNumber)
+		err := rows.Scan(&acc.ID, &acc.DocumentNumber, &acc.Currency, &acc.CustomerID)
 		if err != nil {
 			return nil, err
 		}
@@ -168,22 +279,145 @@ func (r *repo) FindAccounts(ctx context.Context, filter entity.AccountFilter) ([
 	return accs, err
 }
 
-func (r *repo) CreateTransaction(ctx context.Context, tx entity.Transaction) error {
+// FindAccountIDs returns which of the given account ids exist.
+func (r *repo) FindAccountIDs(ctx context.Context, ids []int) ([]int, error) {
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id = ANY($1)", accountTable)
+	rows, err := r.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// FindAccountCurrencies returns the currency of each of the given accounts
+// that exist.
+func (r *repo) FindAccountCurrencies(ctx context.Context, ids []int) (map[int]entity.Currency, error) {
+	query := fmt.Sprintf("SELECT id, currency FROM %s WHERE id = ANY($1)", accountTable)
+	rows, err := r.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := make(map[int]entity.Currency)
+	for rows.Next() {
+		var id int
+		var currency entity.Currency
+		if err := rows.Scan(&id, &currency); err != nil {
+			return nil, err
+		}
+		currencies[id] = currency
+	}
+	return currencies, rows.Err()
+}
+
+func (r *repo) CreateTransaction(ctx context.Context, tx entity.Transaction) (int, error) {
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
 			account_id,
 			operation_type_id,
 			amount,
-			event_date
-		) VALUES ($1, $2, $3, $4)`,
+			event_date,
+			original_amount,
+			original_currency,
+			fx_rate,
+			card_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
 		transactionTable,
 	)
-	_, err := r.pool.Exec(
+	err := r.inTx(ctx, func(dbtx pgx.Tx) error {
+		err := dbtx.QueryRow(
+			ctx,
+			query,
+			tx.AccountID, tx.OperationTypeID, tx.Amount, tx.EventDate, tx.OriginalAmount, tx.OriginalCurrency, tx.FXRate, tx.CardID,
+		).Scan(&tx.ID)
+		if err != nil {
+			return err
+		}
+		if tx.ScheduleRunID != nil {
+			if err := finishScheduleRun(ctx, dbtx, *tx.ScheduleRunID, tx.ID, tx.EventDate); err != nil {
+				return err
+			}
+		}
+		return insertEvents(ctx, dbtx, entity.NewTransactionCreated(tx))
+	})
+	return tx.ID, err
+}
+
+// insertPostingQuery inserts a transaction posted by the service itself,
+// such as an adjustment, whose amount is in the currency of the account.
+var insertPostingQuery = fmt.Sprintf(`
+	INSERT INTO %s (
+		account_id,
+		operation_type_id,
+		amount,
+		event_date,
+		original_amount,
+		original_currency,
+		fx_rate
+	)
+	SELECT $1, $2, $3, $4, $3, currency, 1
+	FROM %s
+	WHERE id = $1
+	RETURNING id`,
+	transactionTable, accountTable,
+)
+
+// numeric converts d for COPY, which does not take decimals.
+func numeric(d decimal.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+}
+
+// CreateTransactions inserts the transactions with COPY, and their events, in
+// a single database transaction and returns their ids, in order. Ids are
+// reserved from the table sequence beforehand since COPY cannot return them.
+func (r *repo) CreateTransactions(ctx context.Context, txs []entity.Transaction) ([]int, error) {
+	dbtx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dbtx.Rollback(ctx) }()
+
+	query := fmt.Sprintf(
+		"SELECT nextval(pg_get_serial_sequence('%s', 'id')) FROM generate_series(1, $1)",
+		transactionTable,
+	)
+	rows, err := dbtx.Query(ctx, query, len(txs))
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	copyRows := make([][]any, len(txs))
+	for i, tx := range txs {
+		copyRows[i] = []any{
+			ids[i], tx.AccountID, tx.OperationTypeID, numeric(tx.Amount), tx.EventDate,
+			numeric(tx.OriginalAmount), string(tx.OriginalCurrency), numeric(tx.FXRate), tx.CardID,
+		}
+	}
+	_, err = dbtx.CopyFrom(
 		ctx,
-		query,
-		tx.AccountID, tx.OperationTypeID, tx.Amount, tx.EventDate,
+		pgx.Identifier(strings.Split(transactionTable, ".")),
+		[]string{"id", "account_id", "operation_type_id", "amount", "event_date", "original_amount", "original_currency", "fx_rate", "card_id"},
+		pgx.CopyFromRows(copyRows),
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]entity.Event, len(txs))
+	for i, tx := range txs {
+		tx.ID = ids[i]
+		events[i] = entity.NewTransactionCreated(tx)
+	}
+	if err := insertEvents(ctx, dbtx, events...); err != nil {
+		return nil, err
+	}
+	return ids, dbtx.Commit(ctx)
 }
 
 func (r *repo) FindTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
@@ -193,7 +427,11 @@ func (r *repo) FindTransactions(ctx context.Context, filter entity.TransactionFi
 			account_id,
 			operation_type_id,
 			amount,
-			event_date
+			event_date,
+			original_amount,
+			original_currency,
+			fx_rate,
+			card_id
 		FROM %s
 		WHERE
 			(id = COALESCE($1, id))
@@ -224,7 +462,10 @@ func (r *repo) FindTransactions(ctx context.Context, filter entity.TransactionFi
 	txs := make([]entity.Transaction, 0)
 	for rows.Next() {
 		var tx entity.Transaction
-		err := rows.Scan(&tx.ID, &tx.AccountID, &tx.OperationTypeID, &tx.Amount, &tx.EventDate)
+		err := rows.Scan(
+			&tx.ID, &tx.AccountID, &tx.OperationTypeID, &tx.Amount, &tx.EventDate,
+			&tx.OriginalAmount, &tx.OriginalCurrency, &tx.FXRate, &tx.CardID,
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -233,21 +474,196 @@ func (r *repo) FindTransactions(ctx context.Context, filter entity.TransactionFi
 	return txs, err
 }
 
+// UpdateTransaction replaces the transaction and records the change, with
+// the previous values, in the outbox.
 func (r *repo) UpdateTransaction(ctx context.Context, tx entity.Transaction) error {
 	query := fmt.Sprintf(`
-		UPDATE %s
+		WITH previous AS (
+			SELECT id, account_id, operation_type_id, amount, event_date, original_amount, original_currency, fx_rate
+			FROM %[1]s
+			WHERE id = $8
+			FOR UPDATE
+		)
+		UPDATE %[1]s t
 		SET
 			account_id = $1,
 			operation_type_id = $2,
 			amount = $3,
-			event_date = $4
-		WHERE id = $5`,
+			event_date = $4,
+			original_amount = $5,
+			original_currency = $6,
+			fx_rate = $7
+		FROM previous
+		WHERE t.id = previous.id
+		RETURNING
+			previous.account_id,
+			previous.operation_type_id,
+			previous.amount,
+			previous.event_date,
+			previous.original_amount,
+			previous.original_currency,
+			previous.fx_rate`,
 		transactionTable,
 	)
+	return r.inTx(ctx, func(dbtx pgx.Tx) error {
+		previous := entity.Transaction{ID: tx.ID}
+		err := dbtx.QueryRow(
+			ctx,
+			query,
+			tx.AccountID, tx.OperationTypeID, tx.Amount, tx.EventDate, tx.OriginalAmount, tx.OriginalCurrency, tx.FXRate, tx.ID,
+		).Scan(
+			&previous.AccountID, &previous.OperationTypeID, &previous.Amount, &previous.EventDate,
+			&previous.OriginalAmount, &previous.OriginalCurrency, &previous.FXRate,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ErrTransactionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return insertEvents(ctx, dbtx, entity.NewTransactionUpdated(tx, previous))
+	})
+}
+
+// AccountBalances sums the transactions of every account, only those with an
+// event date up to asOf when given.
+func (r *repo) AccountBalances(ctx context.Context, asOf *time.Time) ([]entity.AccountBalance, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			a.id,
+			COALESCE(SUM(t.amount), 0)
+		FROM %s a
+		LEFT JOIN %s t ON t.account_id = a.id AND ($1::timestamptz IS NULL OR t.event_date <= $1)
+		GROUP BY a.id
+		ORDER BY a.id`,
+		accountTable, transactionTable,
+	)
+	rows, err := r.pool.Query(ctx, query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AccountBalance, error) {
+		var b entity.AccountBalance
+		err := row.Scan(&b.AccountID, &b.Balance)
+		return b, err
+	})
+}
+
+// FindSignViolations returns the transactions whose amount sign contradicts
+// their operation type and that no adjustment has corrected yet.
+func (r *repo) FindSignViolations(ctx context.Context) ([]entity.SignViolation, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			t.id,
+			t.account_id,
+			t.operation_type_id,
+			t.amount
+		FROM %s t
+		JOIN %s o ON o.id = t.operation_type_id
+		WHERE
+			((o.positive_amount AND t.amount < 0) OR (NOT o.positive_amount AND t.amount > 0))
+			AND NOT EXISTS (SELECT 1 FROM %s a WHERE a.adjusted_transaction_id = t.id)
+		ORDER BY t.id`,
+		transactionTable, operationTypeTable, adjustmentTable,
+	)
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.SignViolation, error) {
+		var v entity.SignViolation
+		err := row.Scan(&v.TransactionID, &v.AccountID, &v.OperationTypeID, &v.Amount)
+		return v, err
+	})
+}
+
+// CreateAdjustments inserts the adjustment transactions, their audit rows and
+// their events in a single database transaction, returning the transaction
+// ids in order.
+func (r *repo) CreateAdjustments(ctx context.Context, adjs []entity.Adjustment) ([]int, error) {
+	dbtx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dbtx.Rollback(ctx) }()
+
+	insertTx := insertPostingQuery
+	insertAudit := fmt.Sprintf(`
+		INSERT INTO %s (
+			transaction_id,
+			adjusted_transaction_id,
+			reason,
+			created_by
+		) VALUES ($1, $2, $3, $4)`,
+		adjustmentTable,
+	)
+	ids := make([]int, len(adjs))
+	for i, adj := range adjs {
+		err := dbtx.QueryRow(ctx, insertTx, adj.AccountID, adj.OperationTypeID, adj.Amount, adj.EventDate).Scan(&ids[i])
+		if err != nil {
+			return nil, err
+		}
+		_, err = dbtx.Exec(ctx, insertAudit, ids[i], adj.AdjustedTransactionID, adj.Reason, adj.CreatedBy)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, entity.ErrAlreadyAdjusted
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = insertEvents(ctx, dbtx, entity.NewTransactionCreated(entity.Transaction{
+			ID:              ids[i],
+			AccountID:       adj.AccountID,
+			OperationTypeID: adj.OperationTypeID,
+			Amount:          adj.Amount,
+			EventDate:       adj.EventDate,
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, dbtx.Commit(ctx)
+}
+
+func (r *repo) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			name,
+			key_hash,
+			scopes
+		) VALUES ($1, $2, $3)`,
+		apiKeyTable,
+	)
 	_, err := r.pool.Exec(
 		ctx,
 		query,
-		tx.AccountID, tx.OperationTypeID, tx.Amount, tx.EventDate, tx.ID,
+		key.Name, key.KeyHash, key.Scopes,
 	)
 	return err
 }
+
+func (r *repo) FindAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			id,
+			name,
+			key_hash,
+			scopes,
+			created_at,
+			revoked_at
+		FROM %s
+		WHERE key_hash = $1`,
+		apiKeyTable,
+	)
+	var key entity.APIKey
+	err := r.pool.QueryRow(ctx, query, hash).Scan(
+		&key.ID, &key.Name, &key.KeyHash, &key.Scopes, &key.CreatedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const disputeTable = "pismo.dispute"
+
+// uniqueViolation is the Postgres error code of a unique index conflict.
+const uniqueViolation = "23505"
+
+const disputeColumns = `
+	id,
+	transaction_id,
+	account_id,
+	amount,
+	reason,
+	status,
+	credit_transaction_id,
+	reversal_transaction_id,
+	credit_due_at,
+	resolve_by,
+	opened_by,
+	opened_at,
+	resolved_at`
+
+func (r *repo) CreateDispute(ctx context.Context, d entity.Dispute) (int, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			transaction_id,
+			account_id,
+			amount,
+			reason,
+			status,
+			credit_due_at,
+			resolve_by,
+			opened_by,
+			opened_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		disputeTable,
+	)
+	var id int
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		d.TransactionID, d.AccountID, d.Amount, d.Reason, string(d.Status), d.CreditDueAt, d.ResolveBy, d.OpenedBy, d.OpenedAt,
+	).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, entity.ErrAlreadyDisputed
+	}
+	return id, err
+}
+
+func (r *repo) FindDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error) {
+	var conds []string
+	var args []any
+	if filter.ID != nil {
+		args = append(args, *filter.ID)
+		conds = append(conds, fmt.Sprintf("id = $%d", len(args)))
+	}
+	if filter.AccountID != nil {
+		args = append(args, *filter.AccountID)
+		conds = append(conds, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Overdue {
+		args = append(args, filter.AsOf)
+		conds = append(conds, fmt.Sprintf(
+			"((status = 'opened' AND credit_due_at < $%[1]d) OR (status IN ('opened', 'provisional_credit') AND resolve_by < $%[1]d))",
+			len(args),
+		))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", disputeColumns, disputeTable)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id"
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Dispute, error) {
+		var d entity.Dispute
+		err := row.Scan(
+			&d.ID, &d.TransactionID, &d.AccountID, &d.Amount, &d.Reason, &d.Status, &d.CreditTransactionID,
+			&d.ReversalTransactionID, &d.CreditDueAt, &d.ResolveBy, &d.OpenedBy, &d.OpenedAt, &d.ResolvedAt,
+		)
+		return d, err
+	})
+}
+
+// TransitionDispute stores d, moved on from the status from, and posts its
+// credit or the reversal of its credit along in one database transaction.
+// The posting is the credit when d has none yet, and the reversal otherwise.
+// It fails with ErrInvalidDisputeTransition if the dispute was moved on
+// meanwhile.
+func (r *repo) TransitionDispute(ctx context.Context, d entity.Dispute, from entity.DisputeStatus, posting *entity.Transaction) (entity.Dispute, error) {
+	insertTx := insertPostingQuery
+	update := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $3, credit_transaction_id = $4, reversal_transaction_id = $5, resolved_at = $6
+		WHERE id = $1 AND status = $2`,
+		disputeTable,
+	)
+	err := r.inTx(ctx, func(dbtx pgx.Tx) error {
+		if posting != nil {
+			tx := *posting
+			err := dbtx.QueryRow(ctx, insertTx, tx.AccountID, tx.OperationTypeID, tx.Amount, tx.EventDate).Scan(&tx.ID)
+			if err != nil {
+				return err
+			}
+			event := entity.NewTransactionCreated(tx)
+			if d.CreditTransactionID == nil {
+				d.CreditTransactionID = &tx.ID
+			} else {
+				d.ReversalTransactionID = &tx.ID
+				event = entity.NewTransactionReversed(tx, *d.CreditTransactionID)
+			}
+			if err := insertEvents(ctx, dbtx, event); err != nil {
+				return err
+			}
+		}
+		tag, err := dbtx.Exec(ctx, update, d.ID, string(from), string(d.Status), d.CreditTransactionID, d.ReversalTransactionID, d.ResolvedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return entity.ErrInvalidDisputeTransition
+		}
+		return nil
+	})
+	if err != nil {
+		return entity.Dispute{}, err
+	}
+	return d, nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const fraudDecisionTable = "pismo.fraud_decision"
+
+// RecentTransactions returns the transactions of an account from since on,
+// oldest first.
+func (r *repo) RecentTransactions(ctx context.Context, accountID int, since time.Time) ([]entity.Transaction, error) {
+	query := fmt.Sprintf(`
+		SELECT id, account_id, operation_type_id, amount, event_date
+		FROM %s
+		WHERE account_id = $1 AND event_date >= $2
+		ORDER BY event_date, id`,
+		transactionTable,
+	)
+	rows, err := r.pool.Query(ctx, query, accountID, since)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Transaction, error) {
+		var tx entity.Transaction
+		err := row.Scan(&tx.ID, &tx.AccountID, &tx.OperationTypeID, &tx.Amount, &tx.EventDate)
+		return tx, err
+	})
+}
+
+func (r *repo) CreateFraudDecision(ctx context.Context, d entity.FraudDecision) (int64, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			transaction_id,
+			account_id,
+			operation_type_id,
+			amount,
+			action,
+			rule,
+			reason,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		fraudDecisionTable,
+	)
+	var id int64
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		d.TransactionID, d.AccountID, d.OperationTypeID, d.Amount, string(d.Action), d.Rule, d.Reason, d.CreatedAt,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *repo) FindFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error) {
+	var conds []string
+	var args []any
+	if filter.AccountID != nil {
+		args = append(args, *filter.AccountID)
+		conds = append(conds, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, string(filter.Action))
+		conds = append(conds, fmt.Sprintf("action = $%d", len(args)))
+	}
+	query := fmt.Sprintf(`
+		SELECT id, transaction_id, account_id, operation_type_id, amount, action, rule, reason, created_at
+		FROM %s`,
+		fraudDecisionTable,
+	)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = entity.DefaultFraudDecisionLimit
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.FraudDecision, error) {
+		var d entity.FraudDecision
+		err := row.Scan(
+			&d.ID, &d.TransactionID, &d.AccountID, &d.OperationTypeID, &d.Amount, &d.Action, &d.Rule, &d.Reason, &d.CreatedAt,
+		)
+		return d, err
+	})
+}
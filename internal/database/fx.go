@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const fxRateTable = "pismo.fx_rate"
+
+func (r *repo) CreateFXRate(ctx context.Context, rate entity.FXRate) (entity.FXRate, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			base_currency,
+			quote_currency,
+			rate,
+			effective_at
+		) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		fxRateTable,
+	)
+	err := r.pool.QueryRow(ctx, query, rate.Base, rate.Quote, rate.Rate, rate.EffectiveAt).Scan(&rate.ID, &rate.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return entity.FXRate{}, entity.ErrFXRateExists
+	}
+	return rate, err
+}
+
+// FindFXRates returns the rates matching filter, the latest first.
+func (r *repo) FindFXRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error) {
+	var conds []string
+	var args []any
+	if filter.Base != "" {
+		args = append(args, filter.Base)
+		conds = append(conds, fmt.Sprintf("base_currency = $%d", len(args)))
+	}
+	if filter.Quote != "" {
+		args = append(args, filter.Quote)
+		conds = append(conds, fmt.Sprintf("quote_currency = $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT id, base_currency, quote_currency, rate, effective_at, created_at FROM %s", fxRateTable)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY effective_at DESC, id DESC"
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanFXRate)
+}
+
+// FindFXRate returns the rate of the pair in effect at, or nil if there is
+// none.
+func (r *repo) FindFXRate(ctx context.Context, base, quote entity.Currency, at time.Time) (*entity.FXRate, error) {
+	query := fmt.Sprintf(`
+		SELECT id, base_currency, quote_currency, rate, effective_at, created_at
+		FROM %s
+		WHERE base_currency = $1 AND quote_currency = $2 AND effective_at <= $3
+		ORDER BY effective_at DESC
+		LIMIT 1`,
+		fxRateTable,
+	)
+	rows, err := r.pool.Query(ctx, query, base, quote, at)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := pgx.CollectOneRow(rows, scanFXRate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func scanFXRate(row pgx.CollectableRow) (entity.FXRate, error) {
+	var rate entity.FXRate
+	err := row.Scan(&rate.ID, &rate.Base, &rate.Quote, &rate.Rate, &rate.EffectiveAt, &rate.CreatedAt)
+	return rate, err
+}
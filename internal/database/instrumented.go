@@ -0,0 +1,354 @@
+package database
+
+import (
+	"context"
+	"time"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedRepo decorates a Repository recording the latency of every call
+// and wrapping it in a span.
+type instrumentedRepo struct {
+	next Repository
+}
+
+func NewInstrumented(next Repository) Repository {
+	return &instrumentedRepo{next: next}
+}
+
+func observe(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "Repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(method)),
+	)
+	return ctx, func(err error) {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		metrics.DBQueryDuration.WithLabelValues(method, status).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}
+}
+
+func (r *instrumentedRepo) Health(ctx context.Context) (err error) {
+	ctx, done := observe(ctx, "Health")
+	defer func() { done(err) }()
+	return r.next.Health(ctx)
+}
+
+func (r *instrumentedRepo) Stat() entity.PoolStats {
+	return r.next.Stat()
+}
+
+func (r *instrumentedRepo) MigrationVersion(ctx context.Context) (version int, dirty bool, err error) {
+	ctx, done := observe(ctx, "MigrationVersion")
+	defer func() { done(err) }()
+	return r.next.MigrationVersion(ctx)
+}
+
+func (r *instrumentedRepo) CreateOperationType(ctx context.Context, op entity.Operation) (err error) {
+	ctx, done := observe(ctx, "CreateOperationType")
+	defer func() { done(err) }()
+	return r.next.CreateOperationType(ctx, op)
+}
+
+func (r *instrumentedRepo) FindOperationType(ctx context.Context) (ops entity.OperationType, err error) {
+	ctx, done := observe(ctx, "FindOperationType")
+	defer func() { done(err) }()
+	return r.next.FindOperationType(ctx)
+}
+
+func (r *instrumentedRepo) CreateAccount(ctx context.Context, acc entity.Account) (created entity.Account, err error) {
+	ctx, done := observe(ctx, "CreateAccount")
+	defer func() { done(err) }()
+	return r.next.CreateAccount(ctx, acc)
+}
+
+func (r *instrumentedRepo) FindAccounts(ctx context.Context, filter entity.AccountFilter) (accs []entity.Account, err error) {
+	ctx, done := observe(ctx, "FindAccounts")
+	defer func() { done(err) }()
+	return r.next.FindAccounts(ctx, filter)
+}
+
+func (r *instrumentedRepo) FindAccountIDs(ctx context.Context, ids []int) (found []int, err error) {
+	ctx, done := observe(ctx, "FindAccountIDs")
+	defer func() { done(err) }()
+	return r.next.FindAccountIDs(ctx, ids)
+}
+
+func (r *instrumentedRepo) FindAccountCurrencies(ctx context.Context, ids []int) (currencies map[int]entity.Currency, err error) {
+	ctx, done := observe(ctx, "FindAccountCurrencies")
+	defer func() { done(err) }()
+	return r.next.FindAccountCurrencies(ctx, ids)
+}
+
+func (r *instrumentedRepo) CreateTransactions(ctx context.Context, txs []entity.Transaction) (ids []int, err error) {
+	ctx, done := observe(ctx, "CreateTransactions")
+	defer func() { done(err) }()
+	return r.next.CreateTransactions(ctx, txs)
+}
+
+func (r *instrumentedRepo) CreateTransaction(ctx context.Context, tx entity.Transaction) (id int, err error) {
+	ctx, done := observe(ctx, "CreateTransaction")
+	defer func() { done(err) }()
+	return r.next.CreateTransaction(ctx, tx)
+}
+
+func (r *instrumentedRepo) FindTransactions(ctx context.Context, filter entity.TransactionFilter) (txs []entity.Transaction, err error) {
+	ctx, done := observe(ctx, "FindTransactions")
+	defer func() { done(err) }()
+	return r.next.FindTransactions(ctx, filter)
+}
+
+func (r *instrumentedRepo) UpdateTransaction(ctx context.Context, tx entity.Transaction) (err error) {
+	ctx, done := observe(ctx, "UpdateTransaction")
+	defer func() { done(err) }()
+	return r.next.UpdateTransaction(ctx, tx)
+}
+
+func (r *instrumentedRepo) AccountBalances(ctx context.Context, asOf *time.Time) (balances []entity.AccountBalance, err error) {
+	ctx, done := observe(ctx, "AccountBalances")
+	defer func() { done(err) }()
+	return r.next.AccountBalances(ctx, asOf)
+}
+
+func (r *instrumentedRepo) FindSignViolations(ctx context.Context) (violations []entity.SignViolation, err error) {
+	ctx, done := observe(ctx, "FindSignViolations")
+	defer func() { done(err) }()
+	return r.next.FindSignViolations(ctx)
+}
+
+func (r *instrumentedRepo) CreateAdjustments(ctx context.Context, adjs []entity.Adjustment) (ids []int, err error) {
+	ctx, done := observe(ctx, "CreateAdjustments")
+	defer func() { done(err) }()
+	return r.next.CreateAdjustments(ctx, adjs)
+}
+
+func (r *instrumentedRepo) RelayEvents(ctx context.Context, limit int, gapWait time.Duration, publish func(entity.Event) error) (published int, err error) {
+	ctx, done := observe(ctx, "RelayEvents")
+	defer func() { done(err) }()
+	return r.next.RelayEvents(ctx, limit, gapWait, publish)
+}
+
+func (r *instrumentedRepo) FindEvents(ctx context.Context, filter entity.EventFilter) (events []entity.Event, err error) {
+	ctx, done := observe(ctx, "FindEvents")
+	defer func() { done(err) }()
+	return r.next.FindEvents(ctx, filter)
+}
+
+func (r *instrumentedRepo) LastEventID(ctx context.Context) (id int64, err error) {
+	ctx, done := observe(ctx, "LastEventID")
+	defer func() { done(err) }()
+	return r.next.LastEventID(ctx)
+}
+
+func (r *instrumentedRepo) CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (created entity.WebhookSubscription, err error) {
+	ctx, done := observe(ctx, "CreateWebhook")
+	defer func() { done(err) }()
+	return r.next.CreateWebhook(ctx, sub)
+}
+
+func (r *instrumentedRepo) FindWebhooks(ctx context.Context) (subs []entity.WebhookSubscription, err error) {
+	ctx, done := observe(ctx, "FindWebhooks")
+	defer func() { done(err) }()
+	return r.next.FindWebhooks(ctx)
+}
+
+func (r *instrumentedRepo) FindWebhook(ctx context.Context, id int) (sub *entity.WebhookSubscription, err error) {
+	ctx, done := observe(ctx, "FindWebhook")
+	defer func() { done(err) }()
+	return r.next.FindWebhook(ctx, id)
+}
+
+func (r *instrumentedRepo) DeleteWebhook(ctx context.Context, id int) (err error) {
+	ctx, done := observe(ctx, "DeleteWebhook")
+	defer func() { done(err) }()
+	return r.next.DeleteWebhook(ctx, id)
+}
+
+func (r *instrumentedRepo) EnqueueDeliveries(ctx context.Context, event entity.Event) (enqueued int, err error) {
+	ctx, done := observe(ctx, "EnqueueDeliveries")
+	defer func() { done(err) }()
+	return r.next.EnqueueDeliveries(ctx, event)
+}
+
+func (r *instrumentedRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []entity.WebhookDelivery, err error) {
+	ctx, done := observe(ctx, "ClaimDeliveries")
+	defer func() { done(err) }()
+	return r.next.ClaimDeliveries(ctx, limit, lease)
+}
+
+func (r *instrumentedRepo) UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) (err error) {
+	ctx, done := observe(ctx, "UpdateDelivery")
+	defer func() { done(err) }()
+	return r.next.UpdateDelivery(ctx, d)
+}
+
+func (r *instrumentedRepo) FindDeliveries(ctx context.Context, filter entity.DeliveryFilter) (deliveries []entity.WebhookDelivery, err error) {
+	ctx, done := observe(ctx, "FindDeliveries")
+	defer func() { done(err) }()
+	return r.next.FindDeliveries(ctx, filter)
+}
+
+func (r *instrumentedRepo) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (d entity.WebhookDelivery, err error) {
+	ctx, done := observe(ctx, "ReplayDelivery")
+	defer func() { done(err) }()
+	return r.next.ReplayDelivery(ctx, subscriptionID, id)
+}
+
+func (r *instrumentedRepo) CreateSchedule(ctx context.Context, sch entity.Schedule) (id int, err error) {
+	ctx, done := observe(ctx, "CreateSchedule")
+	defer func() { done(err) }()
+	return r.next.CreateSchedule(ctx, sch)
+}
+
+func (r *instrumentedRepo) FindSchedules(ctx context.Context, filter entity.ScheduleFilter) (schedules []entity.Schedule, err error) {
+	ctx, done := observe(ctx, "FindSchedules")
+	defer func() { done(err) }()
+	return r.next.FindSchedules(ctx, filter)
+}
+
+func (r *instrumentedRepo) CancelSchedule(ctx context.Context, id int) (err error) {
+	ctx, done := observe(ctx, "CancelSchedule")
+	defer func() { done(err) }()
+	return r.next.CancelSchedule(ctx, id)
+}
+
+func (r *instrumentedRepo) ClaimScheduleRuns(ctx context.Context, now time.Time, limit int) (runs []entity.ScheduleRun, err error) {
+	ctx, done := observe(ctx, "ClaimScheduleRuns")
+	defer func() { done(err) }()
+	return r.next.ClaimScheduleRuns(ctx, now, limit)
+}
+
+func (r *instrumentedRepo) ReclaimScheduleRuns(ctx context.Context, before, now time.Time, limit int) (runs []entity.ScheduleRun, err error) {
+	ctx, done := observe(ctx, "ReclaimScheduleRuns")
+	defer func() { done(err) }()
+	return r.next.ReclaimScheduleRuns(ctx, before, now, limit)
+}
+
+func (r *instrumentedRepo) StartScheduleRun(ctx context.Context, id int64, at time.Time) (err error) {
+	ctx, done := observe(ctx, "StartScheduleRun")
+	defer func() { done(err) }()
+	return r.next.StartScheduleRun(ctx, id, at)
+}
+
+func (r *instrumentedRepo) FinishScheduleRun(ctx context.Context, run entity.ScheduleRun) (err error) {
+	ctx, done := observe(ctx, "FinishScheduleRun")
+	defer func() { done(err) }()
+	return r.next.FinishScheduleRun(ctx, run)
+}
+
+func (r *instrumentedRepo) FindScheduleRuns(ctx context.Context, scheduleID int) (runs []entity.ScheduleRun, err error) {
+	ctx, done := observe(ctx, "FindScheduleRuns")
+	defer func() { done(err) }()
+	return r.next.FindScheduleRuns(ctx, scheduleID)
+}
+
+func (r *instrumentedRepo) CreateDispute(ctx context.Context, d entity.Dispute) (id int, err error) {
+	ctx, done := observe(ctx, "CreateDispute")
+	defer func() { done(err) }()
+	return r.next.CreateDispute(ctx, d)
+}
+
+func (r *instrumentedRepo) FindDisputes(ctx context.Context, filter entity.DisputeFilter) (disputes []entity.Dispute, err error) {
+	ctx, done := observe(ctx, "FindDisputes")
+	defer func() { done(err) }()
+	return r.next.FindDisputes(ctx, filter)
+}
+
+func (r *instrumentedRepo) TransitionDispute(ctx context.Context, d entity.Dispute, from entity.DisputeStatus, posting *entity.Transaction) (moved entity.Dispute, err error) {
+	ctx, done := observe(ctx, "TransitionDispute")
+	defer func() { done(err) }()
+	return r.next.TransitionDispute(ctx, d, from, posting)
+}
+
+func (r *instrumentedRepo) RecentTransactions(ctx context.Context, accountID int, since time.Time) (txs []entity.Transaction, err error) {
+	ctx, done := observe(ctx, "RecentTransactions")
+	defer func() { done(err) }()
+	return r.next.RecentTransactions(ctx, accountID, since)
+}
+
+func (r *instrumentedRepo) CreateFraudDecision(ctx context.Context, d entity.FraudDecision) (id int64, err error) {
+	ctx, done := observe(ctx, "CreateFraudDecision")
+	defer func() { done(err) }()
+	return r.next.CreateFraudDecision(ctx, d)
+}
+
+func (r *instrumentedRepo) FindFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) (decisions []entity.FraudDecision, err error) {
+	ctx, done := observe(ctx, "FindFraudDecisions")
+	defer func() { done(err) }()
+	return r.next.FindFraudDecisions(ctx, filter)
+}
+
+func (r *instrumentedRepo) CreateFXRate(ctx context.Context, rate entity.FXRate) (created entity.FXRate, err error) {
+	ctx, done := observe(ctx, "CreateFXRate")
+	defer func() { done(err) }()
+	return r.next.CreateFXRate(ctx, rate)
+}
+
+func (r *instrumentedRepo) FindFXRates(ctx context.Context, filter entity.FXRateFilter) (rates []entity.FXRate, err error) {
+	ctx, done := observe(ctx, "FindFXRates")
+	defer func() { done(err) }()
+	return r.next.FindFXRates(ctx, filter)
+}
+
+func (r *instrumentedRepo) FindFXRate(ctx context.Context, base, quote entity.Currency, at time.Time) (rate *entity.FXRate, err error) {
+	ctx, done := observe(ctx, "FindFXRate")
+	defer func() { done(err) }()
+	return r.next.FindFXRate(ctx, base, quote, at)
+}
+
+func (r *instrumentedRepo) CreateCustomer(ctx context.Context, c entity.Customer) (created entity.Customer, err error) {
+	ctx, done := observe(ctx, "CreateCustomer")
+	defer func() { done(err) }()
+	return r.next.CreateCustomer(ctx, c)
+}
+
+func (r *instrumentedRepo) FindCustomers(ctx context.Context, filter entity.CustomerFilter) (customers []entity.Customer, err error) {
+	ctx, done := observe(ctx, "FindCustomers")
+	defer func() { done(err) }()
+	return r.next.FindCustomers(ctx, filter)
+}
+
+func (r *instrumentedRepo) FindCustomerBalances(ctx context.Context, customerID int) (balances []entity.CustomerAccountBalance, err error) {
+	ctx, done := observe(ctx, "FindCustomerBalances")
+	defer func() { done(err) }()
+	return r.next.FindCustomerBalances(ctx, customerID)
+}
+
+func (r *instrumentedRepo) CreateCard(ctx context.Context, c entity.Card) (created entity.Card, err error) {
+	ctx, done := observe(ctx, "CreateCard")
+	defer func() { done(err) }()
+	return r.next.CreateCard(ctx, c)
+}
+
+func (r *instrumentedRepo) FindCards(ctx context.Context, filter entity.CardFilter) (cards []entity.Card, err error) {
+	ctx, done := observe(ctx, "FindCards")
+	defer func() { done(err) }()
+	return r.next.FindCards(ctx, filter)
+}
+
+func (r *instrumentedRepo) UpdateCardStatus(ctx context.Context, token string, status entity.CardStatus) (updated entity.Card, err error) {
+	ctx, done := observe(ctx, "UpdateCardStatus")
+	defer func() { done(err) }()
+	return r.next.UpdateCardStatus(ctx, token, status)
+}
+
+func (r *instrumentedRepo) CreateAPIKey(ctx context.Context, key entity.APIKey) (err error) {
+	ctx, done := observe(ctx, "CreateAPIKey")
+	defer func() { done(err) }()
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r *instrumentedRepo) FindAPIKeyByHash(ctx context.Context, hash string) (key *entity.APIKey, err error) {
+	ctx, done := observe(ctx, "FindAPIKeyByHash")
+	defer func() { done(err) }()
+	return r.next.FindAPIKeyByHash(ctx, hash)
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// relayLockKey is the advisory lock held while relaying, so a single
+// instance publishes at a time and events keep their order. It is a session
+// lock, so events are published with no database transaction open.
+const relayLockKey = 7_341_001
+
+// inTx runs fn in a database transaction, committing when it succeeds.
+func (r *repo) inTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	dbtx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbtx.Rollback(ctx) }()
+	if err := fn(dbtx); err != nil {
+		return err
+	}
+	return dbtx.Commit(ctx)
+}
+
+// insertEvents writes events to the outbox as part of dbtx.
+func insertEvents(ctx context.Context, dbtx pgx.Tx, events ...entity.Event) error {
+	types := make([]string, len(events))
+	accounts := make([]int, len(events))
+	payloads := make([]string, len(events))
+	for i, e := range events {
+		types[i], accounts[i], payloads[i] = string(e.Type), e.AccountID, string(e.Payload)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (type, account_id, payload)
+		SELECT type, account_id, payload::jsonb
+		FROM unnest($1::text[], $2::integer[], $3::text[]) WITH ORDINALITY AS e(type, account_id, payload, n)
+		ORDER BY n`,
+		outboxTable,
+	)
+	_, err := dbtx.Exec(ctx, query, types, accounts, payloads)
+	return err
+}
+
+// RelayEvents hands unpublished events, in id order, to publish until it
+// accepted limit of them, and marks those as published. Events it refused are
+// paged past, so events held back at the head of the outbox do not stall the
+// ones after them. It returns how many were published, or zero when another
+// instance holds the relay lock.
+//
+// Outbox ids are taken when a transaction inserts them but become visible
+// when it commits, so the events after a missing id are held back for up to
+// gapWait in case it commits late. Events that failed before are handed
+// again first.
+func (r *repo) RelayEvents(ctx context.Context, limit int, gapWait time.Duration, publish func(entity.Event) error) (int, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", relayLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer func() {
+		// A connection still holding the lock must not go back to the pool.
+		unlockCtx := context.WithoutCancel(ctx)
+		if _, err := conn.Exec(unlockCtx, "SELECT pg_advisory_unlock($1)", relayLockKey); err != nil {
+			_ = conn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	var lastID int64
+	var gapSince *time.Time
+	var now time.Time
+	position := fmt.Sprintf("SELECT last_id, gap_since, now() FROM %s", outboxRelayTable)
+	if err := conn.QueryRow(ctx, position).Scan(&lastID, &gapSince, &now); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+		SELECT id, type, account_id, payload, created_at
+		FROM %s
+		WHERE published_at IS NULL AND id > $2
+		ORDER BY id
+		LIMIT $1`,
+		outboxTable,
+	)
+	var ids []int64
+	var after int64
+pages:
+	for len(ids) < limit {
+		rows, err := conn.Query(ctx, query, limit, after)
+		if err != nil {
+			return 0, err
+		}
+		events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Event, error) {
+			var e entity.Event
+			err := row.Scan(&e.ID, &e.Type, &e.AccountID, &e.Payload, &e.CreatedAt)
+			return e, err
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range events {
+			if e.ID > lastID {
+				if e.ID != lastID+1 {
+					if gapSince == nil {
+						gapSince = &now
+					}
+					if now.Sub(*gapSince) < gapWait {
+						break pages
+					}
+				}
+				gapSince, lastID = nil, e.ID
+			}
+			after = e.ID
+			if publish(e) == nil {
+				if ids = append(ids, e.ID); len(ids) == limit {
+					break pages
+				}
+			}
+		}
+		if len(events) < limit {
+			break
+		}
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET published_at = now() WHERE id = ANY($1)", outboxTable)
+	advance := fmt.Sprintf("UPDATE %s SET last_id = $1, gap_since = $2", outboxRelayTable)
+	err = pgx.BeginFunc(ctx, conn, func(dbtx pgx.Tx) error {
+		if len(ids) > 0 {
+			if _, err := dbtx.Exec(ctx, update, ids); err != nil {
+				return err
+			}
+		}
+		_, err := dbtx.Exec(ctx, advance, lastID, gapSince)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// FindEvents returns the outbox events matching filter, published or not.
+// The events of an account include the updates that moved a transaction
+// away from it.
+func (r *repo) FindEvents(ctx context.Context, filter entity.EventFilter) ([]entity.Event, error) {
+	query := fmt.Sprintf(`
+		SELECT id, type, account_id, payload, created_at
+		FROM %s
+		WHERE id > $1
+		AND ($5 = 0 OR id <= $5)
+		AND (
+			$2 = 0
+			OR account_id = $2
+			OR (type = $3 AND (payload->'previous'->>'account_id')::integer = $2)
+		)
+		ORDER BY id
+		LIMIT $4`,
+		outboxTable,
+	)
+	rows, err := r.pool.Query(ctx, query, filter.AfterID, filter.AccountID, string(entity.EventTransactionUpdated), filter.Limit, filter.UntilID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Event, error) {
+		var e entity.Event
+		err := row.Scan(&e.ID, &e.Type, &e.AccountID, &e.Payload, &e.CreatedAt)
+		return e, err
+	})
+}
+
+// LastEventID returns the id of the newest outbox event, or zero when there
+// is none.
+func (r *repo) LastEventID(ctx context.Context) (int64, error) {
+	var id int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", outboxTable)
+	err := r.pool.QueryRow(ctx, query).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	scheduleTable    = "pismo.schedule"
+	scheduleRunTable = "pismo.schedule_run"
+)
+
+const scheduleColumns = `
+	id,
+	account_id,
+	operation_type_id,
+	amount,
+	recurrence,
+	day,
+	start_at,
+	end_at,
+	next_run_at,
+	status,
+	created_by,
+	created_at`
+
+const scheduleRunColumns = `
+	id,
+	schedule_id,
+	due_at,
+	status,
+	transaction_id,
+	COALESCE(error, ''),
+	started_at,
+	finished_at`
+
+func (r *repo) CreateSchedule(ctx context.Context, s entity.Schedule) (int, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			account_id,
+			operation_type_id,
+			amount,
+			recurrence,
+			day,
+			start_at,
+			end_at,
+			next_run_at,
+			status,
+			created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		scheduleTable,
+	)
+	var id int
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		s.AccountID, s.OperationTypeID, s.Amount, string(s.Recurrence), s.Day, s.StartAt, s.EndAt, s.NextRunAt, string(s.Status), s.CreatedBy,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *repo) FindSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error) {
+	var conds []string
+	var args []any
+	if filter.ID != nil {
+		args = append(args, *filter.ID)
+		conds = append(conds, fmt.Sprintf("id = $%d", len(args)))
+	}
+	if filter.AccountID != nil {
+		args = append(args, *filter.AccountID)
+		conds = append(conds, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", scheduleColumns, scheduleTable)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id"
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Schedule, error) {
+		var s entity.Schedule
+		err := row.Scan(scheduleFields(&s)...)
+		return s, err
+	})
+}
+
+// CancelSchedule stops an active schedule. A run already started is still
+// recorded.
+func (r *repo) CancelSchedule(ctx context.Context, id int) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, next_run_at = NULL
+		WHERE id = $1 AND status = $3`,
+		scheduleTable,
+	)
+	tag, err := r.pool.Exec(ctx, query, id, string(entity.ScheduleCanceled), string(entity.ScheduleActive))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrScheduleNotActive
+	}
+	return nil
+}
+
+// ClaimScheduleRuns starts a run of up to limit active schedules due at now
+// and moves each schedule to its next run, computed in the location of now,
+// all in one database transaction. Schedules locked by another instance are
+// skipped, so every run is claimed by a single instance.
+func (r *repo) ClaimScheduleRuns(ctx context.Context, now time.Time, limit int) ([]entity.ScheduleRun, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at, id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`,
+		scheduleColumns, scheduleTable,
+	)
+	insert := fmt.Sprintf(`
+		INSERT INTO %s (schedule_id, due_at, status, started_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (schedule_id, due_at) DO NOTHING
+		RETURNING id`,
+		scheduleRunTable,
+	)
+	update := fmt.Sprintf(`
+		UPDATE %s
+		SET next_run_at = $2, status = $3
+		WHERE id = $1`,
+		scheduleTable,
+	)
+	var runs []entity.ScheduleRun
+	err := r.inTx(ctx, func(dbtx pgx.Tx) error {
+		rows, err := dbtx.Query(ctx, query, string(entity.ScheduleActive), now, limit)
+		if err != nil {
+			return err
+		}
+		schedules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Schedule, error) {
+			var s entity.Schedule
+			err := row.Scan(scheduleFields(&s)...)
+			return s, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range schedules {
+			due := s.NextRunAt.In(now.Location())
+			run := entity.ScheduleRun{ScheduleID: s.ID, DueAt: due, Status: entity.RunRunning, StartedAt: now, Schedule: s}
+			err := dbtx.QueryRow(ctx, insert, s.ID, due, string(run.Status), now).Scan(&run.ID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			// A conflict means the run was already claimed; the schedule
+			// still moves on.
+			claimed := err == nil
+
+			next, status := s.NextRun(due), entity.ScheduleActive
+			if next == nil {
+				status = entity.ScheduleCompleted
+			}
+			if _, err := dbtx.Exec(ctx, update, s.ID, next, string(status)); err != nil {
+				return err
+			}
+			if claimed {
+				runs = append(runs, run)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ReclaimScheduleRuns returns up to limit runs still running since before,
+// left behind by an instance that stopped, along with their schedule, and
+// starts them again at now. Runs locked by another instance are skipped.
+// Posting a run again is safe, since its transaction is created along with
+// the outcome of the run.
+func (r *repo) ReclaimScheduleRuns(ctx context.Context, before, now time.Time, limit int) ([]entity.ScheduleRun, error) {
+	query := fmt.Sprintf(`
+		UPDATE %[1]s
+		SET started_at = $3
+		WHERE id IN (
+			SELECT id
+			FROM %[1]s
+			WHERE status = $1 AND started_at < $2
+			ORDER BY due_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, schedule_id, due_at, status, started_at`,
+		scheduleRunTable,
+	)
+	schedules := fmt.Sprintf("SELECT %s FROM %s WHERE id = ANY($1)", scheduleColumns, scheduleTable)
+	var runs []entity.ScheduleRun
+	err := r.inTx(ctx, func(dbtx pgx.Tx) error {
+		rows, err := dbtx.Query(ctx, query, string(entity.RunRunning), before, now, limit)
+		if err != nil {
+			return err
+		}
+		runs, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ScheduleRun, error) {
+			var run entity.ScheduleRun
+			err := row.Scan(&run.ID, &run.ScheduleID, &run.DueAt, &run.Status, &run.StartedAt)
+			run.DueAt = run.DueAt.In(now.Location())
+			return run, err
+		})
+		if err != nil || len(runs) == 0 {
+			return err
+		}
+
+		ids := make([]int, len(runs))
+		for i, run := range runs {
+			ids[i] = run.ScheduleID
+		}
+		rows, err = dbtx.Query(ctx, schedules, ids)
+		if err != nil {
+			return err
+		}
+		found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Schedule, error) {
+			var s entity.Schedule
+			err := row.Scan(scheduleFields(&s)...)
+			return s, err
+		})
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]entity.Schedule, len(found))
+		for _, s := range found {
+			byID[s.ID] = s
+		}
+		for i := range runs {
+			runs[i].Schedule = byID[runs[i].ScheduleID]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(runs, func(a, b entity.ScheduleRun) int { return a.DueAt.Compare(b.DueAt) })
+	return runs, nil
+}
+
+// StartScheduleRun records that the run is being posted at the given time,
+// or returns ErrRunNotRunning if it already finished.
+func (r *repo) StartScheduleRun(ctx context.Context, id int64, at time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET started_at = $2
+		WHERE id = $1 AND status = $3`,
+		scheduleRunTable,
+	)
+	tag, err := r.pool.Exec(ctx, query, id, at, string(entity.RunRunning))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrRunNotRunning
+	}
+	return nil
+}
+
+// FinishScheduleRun records the outcome of a run that did not post its
+// transaction, or returns ErrRunNotRunning if it already finished.
+// Succeeded runs are finished by CreateTransaction instead.
+func (r *repo) FinishScheduleRun(ctx context.Context, run entity.ScheduleRun) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, transaction_id = $3, error = NULLIF($4, ''), finished_at = $5
+		WHERE id = $1 AND status = $6`,
+		scheduleRunTable,
+	)
+	tag, err := r.pool.Exec(ctx, query, run.ID, string(run.Status), run.TransactionID, run.Error, run.FinishedAt, string(entity.RunRunning))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrRunNotRunning
+	}
+	return nil
+}
+
+// finishScheduleRun marks a running run as succeeded with the transaction
+// just created in dbtx. A run finished meanwhile, by another instance that
+// posted it again, fails with ErrRunNotRunning, rolling the transaction back.
+func finishScheduleRun(ctx context.Context, dbtx pgx.Tx, id int64, transactionID int, at time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, transaction_id = $3, finished_at = $4
+		WHERE id = $1 AND status = $5`,
+		scheduleRunTable,
+	)
+	tag, err := dbtx.Exec(ctx, query, id, string(entity.RunSucceeded), transactionID, at, string(entity.RunRunning))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrRunNotRunning
+	}
+	return nil
+}
+
+// FindScheduleRuns returns the runs of a schedule, newest first.
+func (r *repo) FindScheduleRuns(ctx context.Context, scheduleID int) ([]entity.ScheduleRun, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE schedule_id = $1
+		ORDER BY due_at DESC`,
+		scheduleRunColumns, scheduleRunTable,
+	)
+	rows, err := r.pool.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ScheduleRun, error) {
+		var run entity.ScheduleRun
+		err := row.Scan(&run.ID, &run.ScheduleID, &run.DueAt, &run.Status, &run.TransactionID, &run.Error, &run.StartedAt, &run.FinishedAt)
+		return run, err
+	})
+}
+
+func scheduleFields(s *entity.Schedule) []any {
+	return []any{
+		&s.ID, &s.AccountID, &s.OperationTypeID, &s.Amount, &s.Recurrence, &s.Day, &s.StartAt,
+		&s.EndAt, &s.NextRunAt, &s.Status, &s.CreatedBy, &s.CreatedAt,
+	}
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryStartKey struct{}
+
+// queryLogger logs every query issued through the pool. Arguments are never
+// logged since they may contain personal data such as document numbers.
+type queryLogger struct{}
+
+func (queryLogger) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, time.Now())
+}
+
+func (queryLogger) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	var elapsed time.Duration
+	if start, ok := ctx.Value(queryStartKey{}).(time.Time); ok {
+		elapsed = time.Since(start)
+	}
+	if data.Err != nil {
+		slog.ErrorContext(ctx, "database query failed", "duration", elapsed, "error", data.Err)
+		return
+	}
+	slog.DebugContext(ctx, "database query", "duration", elapsed, "command", data.CommandTag.String())
+}
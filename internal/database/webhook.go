@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	webhookSubscriptionTable = "pismo.webhook_subscription"
+	webhookDeliveryTable     = "pismo.webhook_delivery"
+)
+
+const deliveryColumns = `
+	d.id,
+	d.subscription_id,
+	d.event_id,
+	d.event_type,
+	d.status,
+	d.attempts,
+	d.next_attempt_at,
+	d.last_attempt_at,
+	COALESCE(d.last_status_code, 0),
+	COALESCE(d.last_error, ''),
+	d.delivered_at,
+	d.created_at`
+
+func (r *repo) CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			url,
+			secret,
+			event_types,
+			account_ids
+		) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		webhookSubscriptionTable,
+	)
+	if sub.EventTypes == nil {
+		sub.EventTypes = []entity.EventType{}
+	}
+	if sub.AccountIDs == nil {
+		sub.AccountIDs = []int{}
+	}
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		sub.URL, sub.Secret, eventTypeStrings(sub.EventTypes), sub.AccountIDs,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	return sub, err
+}
+
+func (r *repo) FindWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	query := fmt.Sprintf(`
+		SELECT id, url, event_types, account_ids, created_at
+		FROM %s
+		ORDER BY id`,
+		webhookSubscriptionTable,
+	)
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.WebhookSubscription, error) {
+		return scanWebhook(row)
+	})
+}
+
+func (r *repo) FindWebhook(ctx context.Context, id int) (*entity.WebhookSubscription, error) {
+	query := fmt.Sprintf(`
+		SELECT id, url, event_types, account_ids, created_at
+		FROM %s
+		WHERE id = $1`,
+		webhookSubscriptionTable,
+	)
+	sub, err := scanWebhook(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteWebhook removes a subscription along with its delivery log.
+func (r *repo) DeleteWebhook(ctx context.Context, id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", webhookSubscriptionTable)
+	tag, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueDeliveries creates a pending delivery of event for every matching
+// subscription and returns how many were created. Enqueuing an event again
+// creates no duplicates. A transaction moved between accounts matches the
+// subscriptions of either account.
+func (r *repo) EnqueueDeliveries(ctx context.Context, event entity.Event) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2::text, $3::jsonb
+		FROM %s
+		WHERE (cardinality(event_types) = 0 OR $2::text = ANY(event_types))
+		AND (cardinality(account_ids) = 0 OR account_ids && $4::integer[])
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		webhookDeliveryTable, webhookSubscriptionTable,
+	)
+	tag, err := r.pool.Exec(ctx, query, event.ID, string(event.Type), string(payload), event.Accounts())
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ClaimDeliveries returns up to limit pending deliveries that are due, with
+// the url and secret of their subscription. Claimed deliveries are not due
+// again until lease has passed, so instances sending at the same time do not
+// pick the same ones.
+func (r *repo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	query := fmt.Sprintf(`
+		UPDATE %[1]s d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM %[2]s s
+		WHERE s.id = d.subscription_id
+		AND d.id IN (
+			SELECT id
+			FROM %[1]s
+			WHERE status = $3 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[3]s, d.payload, s.url, s.secret`,
+		webhookDeliveryTable, webhookSubscriptionTable, deliveryColumns,
+	)
+	rows, err := r.pool.Query(ctx, query, limit, lease.Seconds(), string(entity.DeliveryPending))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.WebhookDelivery, error) {
+		var d entity.WebhookDelivery
+		err := row.Scan(append(deliveryFields(&d), &d.Payload, &d.URL, &d.Secret)...)
+		return d, err
+	})
+}
+
+// UpdateDelivery records the outcome of an attempt to send d.
+func (r *repo) UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET
+			status = $1,
+			attempts = $2,
+			next_attempt_at = $3,
+			last_attempt_at = $4,
+			last_status_code = NULLIF($5, 0),
+			last_error = NULLIF($6, ''),
+			delivered_at = $7
+		WHERE id = $8`,
+		webhookDeliveryTable,
+	)
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		string(d.Status), d.Attempts, d.NextAttemptAt, d.LastAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt, d.ID,
+	)
+	return err
+}
+
+// FindDeliveries returns the delivery log of a subscription, newest first.
+func (r *repo) FindDeliveries(ctx context.Context, filter entity.DeliveryFilter) ([]entity.WebhookDelivery, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s d
+		WHERE d.subscription_id = $1 AND ($2::text = '' OR d.status = $2::text)
+		ORDER BY d.id DESC
+		LIMIT $3`,
+		deliveryColumns, webhookDeliveryTable,
+	)
+	rows, err := r.pool.Query(ctx, query, filter.SubscriptionID, string(filter.Status), filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.WebhookDelivery, error) {
+		var d entity.WebhookDelivery
+		err := row.Scan(deliveryFields(&d)...)
+		return d, err
+	})
+}
+
+// ReplayDelivery makes a delivery of the subscription pending and due now,
+// whatever its status, with a fresh set of attempts.
+func (r *repo) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (entity.WebhookDelivery, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s d
+		SET status = $3, attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE d.subscription_id = $1 AND d.id = $2
+		RETURNING %s`,
+		webhookDeliveryTable, deliveryColumns,
+	)
+	var d entity.WebhookDelivery
+	err := r.pool.QueryRow(ctx, query, subscriptionID, id, string(entity.DeliveryPending)).Scan(deliveryFields(&d)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.WebhookDelivery{}, entity.ErrWebhookDeliveryNotFound
+	}
+	return d, err
+}
+
+func scanWebhook(row pgx.Row) (entity.WebhookSubscription, error) {
+	var sub entity.WebhookSubscription
+	var types []string
+	err := row.Scan(&sub.ID, &sub.URL, &types, &sub.AccountIDs, &sub.CreatedAt)
+	sub.EventTypes = make([]entity.EventType, len(types))
+	for i, t := range types {
+		sub.EventTypes[i] = entity.EventType(t)
+	}
+	return sub, err
+}
+
+func deliveryFields(d *entity.WebhookDelivery) []any {
+	return []any{
+		&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt,
+	}
+}
+
+func eventTypeStrings(types []entity.EventType) []string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return s
+}
@@ -1,19 +1,27 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"log/slog"
+	"transaction-routine/internal/logger"
+)
 
 var (
 	ErrMissingDocumentNumber = errors.New("missing document number")
 )
 
+// Account belongs to a customer, whose document number it carries.
 type Account struct {
-	ID             int    `json:"id"`
-	DocumentNumber string `json:"document_number"`
+	ID             int      `json:"id"`
+	DocumentNumber string   `json:"document_number"`
+	Currency       Currency `json:"currency,omitempty"`
+	CustomerID     int      `json:"customer_id,omitempty"`
 }
 
 type AccountFilter struct {
 	ID             *int    `json:"id"`
 	DocumentNumber *string `json:"document_number"`
+	CustomerID     *int    `json:"customer_id"`
 }
 
 func (a Account) ToFilter() AccountFilter {
@@ -24,5 +32,17 @@ func (a Account) ToFilter() AccountFilter {
 	if a.DocumentNumber != "" {
 		filter.DocumentNumber = &a.DocumentNumber
 	}
+	if a.CustomerID != 0 {
+		filter.CustomerID = &a.CustomerID
+	}
 	return filter
 }
+
+func (a Account) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", a.ID),
+		slog.String("document_number", logger.MaskDocument(a.DocumentNumber)),
+		slog.String("currency", string(a.Currency)),
+		slog.Int("customer_id", a.CustomerID),
+	)
+}
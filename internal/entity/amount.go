@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAmountPrecision  = errors.New("amount has more decimal places than allowed")
+	ErrAmountOutOfRange = errors.New("amount is above the maximum allowed")
+)
+
+// AmountScale and AmountDigits bound every stored amount, as the amount
+// columns are constrained to 4 decimal places and 15 integer digits.
+const (
+	AmountScale  = 4
+	AmountDigits = 15
+)
+
+// MaxAmount is the largest magnitude the amount columns hold.
+var MaxAmount = decimal.New(1, AmountDigits).Sub(decimal.New(1, -AmountScale))
+
+// AmountLimit bounds amounts to Scale decimal places and a magnitude of at
+// most Max. A nil member does not bound amounts.
+type AmountLimit struct {
+	Scale *int32
+	Max   *decimal.Decimal
+}
+
+// AmountLimits bounds the amounts of transactions by currency and by
+// operation type. An amount meets the Default limit, the one of its currency
+// and the one of its operation type, within the bounds of the amount columns.
+// The scale of a currency without one is its minor unit.
+type AmountLimits struct {
+	Default        AmountLimit
+	Currencies     map[Currency]AmountLimit
+	OperationTypes map[int]AmountLimit
+}
+
+// Scale returns the decimal places allowed in amounts in currency of the
+// operation type opTypeID.
+func (l AmountLimits) Scale(currency Currency, opTypeID int) int32 {
+	scale := currency.MinorUnits()
+	if s := l.Currencies[currency].Scale; s != nil {
+		scale = *s
+	}
+	for _, limit := range []AmountLimit{l.Default, l.OperationTypes[opTypeID]} {
+		if limit.Scale != nil {
+			scale = min(scale, *limit.Scale)
+		}
+	}
+	return min(scale, AmountScale)
+}
+
+// Max returns the largest magnitude allowed in amounts in currency of the
+// operation type opTypeID.
+func (l AmountLimits) Max(currency Currency, opTypeID int) decimal.Decimal {
+	bound := MaxAmount
+	for _, limit := range []AmountLimit{l.Default, l.Currencies[currency], l.OperationTypes[opTypeID]} {
+		if limit.Max != nil && limit.Max.LessThan(bound) {
+			bound = *limit.Max
+		}
+	}
+	return bound
+}
+
+// Check returns a FieldError when amount, in currency, breaks the limits of
+// the operation type opTypeID.
+func (l AmountLimits) Check(amount decimal.Decimal, currency Currency, opTypeID int) error {
+	if !fitsScale(amount, l.Scale(currency, opTypeID)) {
+		return &FieldError{Field: "amount", Err: ErrAmountPrecision}
+	}
+	if amount.Abs().GreaterThan(l.Max(currency, opTypeID)) {
+		return &FieldError{Field: "amount", Err: ErrAmountOutOfRange}
+	}
+	return nil
+}
+
+// checkAmount checks amount against the bounds of the amount columns.
+func checkAmount(amount decimal.Decimal) error {
+	if !fitsScale(amount, AmountScale) {
+		return ErrAmountPrecision
+	}
+	if amount.Abs().GreaterThan(MaxAmount) {
+		return ErrAmountOutOfRange
+	}
+	return nil
+}
+
+func fitsScale(amount decimal.Decimal, scale int32) bool {
+	return amount.Equal(amount.Truncate(scale))
+}
+
+// ParseAmountLimits reads limits written as
+//
+//	default=max:1000000;JPY=max:150000000;KWD=scale:2;3=scale:0,max:5000
+//
+// keyed by default, a currency or an operation type id.
+func ParseAmountLimits(s string) (AmountLimits, error) {
+	limits := AmountLimits{Currencies: map[Currency]AmountLimit{}, OperationTypes: map[int]AmountLimit{}}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, bounds, ok := strings.Cut(entry, "=")
+		if !ok {
+			return AmountLimits{}, fmt.Errorf("invalid amount limit %q: missing '='", entry)
+		}
+		limit, err := parseAmountLimit(bounds)
+		if err != nil {
+			return AmountLimits{}, err
+		}
+		key = strings.TrimSpace(key)
+		if key == "default" {
+			limits.Default = limit
+		} else if id, err := strconv.Atoi(key); err == nil && id > 0 {
+			limits.OperationTypes[id] = limit
+		} else if Currency(key).Valid() {
+			limits.Currencies[Currency(key)] = limit
+		} else {
+			return AmountLimits{}, fmt.Errorf("invalid amount limit key %q", key)
+		}
+	}
+	return limits, nil
+}
+
+func parseAmountLimit(s string) (AmountLimit, error) {
+	var limit AmountLimit
+	for _, bound := range strings.Split(s, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(bound), ":")
+		switch name {
+		case "scale":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > AmountScale {
+				return AmountLimit{}, fmt.Errorf("invalid amount scale %q: expected 0 to %d", value, AmountScale)
+			}
+			scale := int32(n)
+			limit.Scale = &scale
+		case "max":
+			amount, err := decimal.NewFromString(value)
+			if err != nil || !amount.IsPositive() || amount.GreaterThan(MaxAmount) {
+				return AmountLimit{}, fmt.Errorf("invalid amount max %q", value)
+			}
+			limit.Max = &amount
+		default:
+			return AmountLimit{}, fmt.Errorf("invalid amount limit bound %q", bound)
+		}
+	}
+	return limit, nil
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrMissingAPIKeyName   = errors.New("missing api key name")
+	ErrMissingAPIKeyScopes = errors.New("missing api key scopes")
+)
+
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// MarshalJSON renders the dates of the APIKey in TimeLayout.
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type alias APIKey
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime  `json:"created_at"`
+		RevokedAt *jsonTime `json:"revoked_at,omitempty"`
+	}{
+		alias:     alias(k),
+		CreatedAt: jsonTime(k.CreatedAt),
+		RevokedAt: (*jsonTime)(k.RevokedAt),
+	})
+}
+
+func (k APIKey) Validate() error {
+	var errs []error
+	if k.Name == "" {
+		errs = append(errs, &FieldError{Field: "name", Err: ErrMissingAPIKeyName})
+	}
+	if len(k.Scopes) == 0 {
+		errs = append(errs, &FieldError{Field: "scopes", Err: ErrMissingAPIKeyScopes})
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrInvalidBatchMode  = errors.New("invalid batch mode")
+	ErrBatchItemRejected = errors.New("not created because other items of the batch failed")
+)
+
+type BatchMode string
+
+const (
+	// BatchAllOrNothing creates the items only when every one of them is valid.
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort creates the valid items and reports the invalid ones.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+func ParseBatchMode(s string) (BatchMode, error) {
+	switch mode := BatchMode(s); mode {
+	case "":
+		return BatchAllOrNothing, nil
+	case BatchAllOrNothing, BatchBestEffort:
+		return mode, nil
+	}
+	return "", ErrInvalidBatchMode
+}
+
+type BatchItemStatus string
+
+const (
+	BatchItemCreated  BatchItemStatus = "created"
+	BatchItemFailed   BatchItemStatus = "failed"
+	BatchItemRejected BatchItemStatus = "rejected"
+)
+
+// BatchItem is a transaction of a batch along with its position in the
+// request. Err is set when the item could not even be decoded.
+type BatchItem struct {
+	Index       int
+	Transaction Transaction
+	Err         error
+}
+
+type BatchItemResult struct {
+	Index  int             `json:"index"`
+	Status BatchItemStatus `json:"status"`
+	ID     int             `json:"id,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Mode    BatchMode         `json:"mode"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Items   []BatchItemResult `json:"items"`
+}
+
+// Fail marks the item at i as not created because of err.
+func (r *BatchResult) Fail(i int, status BatchItemStatus, err error) {
+	r.Items[i].Status = status
+	r.Items[i].Error = errorMessage(err)
+	r.Failed++
+}
+
+// errorMessage renders joined errors on a single line.
+func errorMessage(err error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var msgs []string
+		for _, e := range joined.Unwrap() {
+			msgs = append(msgs, e.Error())
+		}
+		return strings.Join(msgs, "; ")
+	}
+	return err.Error()
+}
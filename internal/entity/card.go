@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrCardNotFound      = errors.New("card not found")
+	ErrCardBlocked       = errors.New("card is blocked")
+	ErrCardExpired       = errors.New("card is expired")
+	ErrCardExists        = errors.New("a card with this number already exists")
+	ErrCardsDisabled     = errors.New("card issuing is not configured")
+	ErrInvalidCardStatus = errors.New("invalid card status")
+	ErrCardAccount       = errors.New("card belongs to another account")
+)
+
+type CardStatus string
+
+const (
+	CardActive  CardStatus = "active"
+	CardBlocked CardStatus = "blocked"
+)
+
+func (s CardStatus) Valid() bool {
+	return s == CardActive || s == CardBlocked
+}
+
+// Card is a payment card issued against an account. Clients refer to it by
+// its token; the PAN is only stored encrypted, along with a keyed
+// fingerprint to tell duplicates apart, and is shown masked.
+type Card struct {
+	ID           int        `json:"id"`
+	Token        string     `json:"token"`
+	AccountID    int        `json:"account_id"`
+	MaskedPAN    string     `json:"masked_pan"`
+	ExpiryMonth  int        `json:"expiry_month"`
+	ExpiryYear   int        `json:"expiry_year"`
+	Status       CardStatus `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	EncryptedPAN []byte     `json:"-"`
+	Fingerprint  string     `json:"-"`
+}
+
+// MarshalJSON renders the dates of the Card in TimeLayout.
+func (c Card) MarshalJSON() ([]byte, error) {
+	type alias Card
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime `json:"created_at"`
+	}{
+		alias:     alias(c),
+		CreatedAt: jsonTime(c.CreatedAt),
+	})
+}
+
+type CardFilter struct {
+	Token     *string
+	AccountID *int
+}
+
+// Expired reports whether the card is expired at now. Cards are valid
+// through the last day of their expiry month.
+func (c Card) Expired(now time.Time) bool {
+	end := time.Date(c.ExpiryYear, time.Month(c.ExpiryMonth)+1, 1, 0, 0, 0, 0, now.Location())
+	return !now.Before(end)
+}
+
+// Usable returns why the card cannot be used for a transaction at now, if
+// it cannot.
+func (c Card) Usable(now time.Time) error {
+	if c.Status == CardBlocked {
+		return ErrCardBlocked
+	}
+	if c.Expired(now) {
+		return ErrCardExpired
+	}
+	return nil
+}
+
+// UseCard makes tx a transaction of card c, found by its CardToken, or nil
+// if no card has it. The card must be usable at the event date of tx and,
+// when tx also names an account, belong to it.
+func (tx *Transaction) UseCard(c *Card) error {
+	if c == nil {
+		return &FieldError{Field: "card_token", Err: ErrCardNotFound}
+	}
+	if tx.AccountID != 0 && tx.AccountID != c.AccountID {
+		return &FieldError{Field: "account_id", Err: ErrCardAccount}
+	}
+	if err := c.Usable(tx.EventDate); err != nil {
+		return err
+	}
+	tx.AccountID, tx.CardID = c.AccountID, &c.ID
+	return nil
+}
+
+// KeepCard carries the card of stored over to tx, an update of stored. A
+// transaction made with a card cannot be moved to another account, which
+// the card does not belong to.
+func (tx *Transaction) KeepCard(stored Transaction) error {
+	if stored.CardID != nil && tx.AccountID != stored.AccountID {
+		return &FieldError{Field: "account_id", Err: ErrCardAccount}
+	}
+	tx.CardID = stored.CardID
+	return nil
+}
+
+func (c Card) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", c.ID),
+		slog.Int("account_id", c.AccountID),
+		slog.String("masked_pan", c.MaskedPAN),
+		slog.String("status", string(c.Status)),
+	)
+}
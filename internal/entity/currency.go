@@ -0,0 +1,107 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidCurrency = errors.New("invalid currency")
+	ErrInvalidFXRate   = errors.New("invalid fx rate")
+	ErrFXRateNotFound  = errors.New("no fx rate in effect for the currency pair")
+	ErrFXRateExists    = errors.New("the currency pair already has a rate taking effect at that time")
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// DefaultCurrency is the currency of accounts opened without one, and of
+// every account opened before currencies were introduced.
+const DefaultCurrency Currency = "BRL"
+
+// minorUnits is the number of decimal places of each supported currency.
+var minorUnits = map[Currency]int32{
+	"BRL": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"ARS": 2,
+	"MXN": 2,
+	"CLP": 0,
+	"JPY": 0,
+	"KWD": 3,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places amounts in c may have.
+func (c Currency) MinorUnits() int32 {
+	return minorUnits[c]
+}
+
+// Fits reports whether amount has no more decimal places than c allows.
+func (c Currency) Fits(amount decimal.Decimal) bool {
+	return fitsScale(amount, c.MinorUnits())
+}
+
+// Round rounds amount to the minor unit of c, half to even, so that the
+// rounding of many conversions does not lean in either direction.
+func (c Currency) Round(amount decimal.Decimal) decimal.Decimal {
+	return amount.RoundBank(c.MinorUnits())
+}
+
+// FXRate converts amounts in Base into Quote from EffectiveAt on, until a
+// later rate of the same pair takes effect: one unit of Base is worth Rate
+// units of Quote.
+type FXRate struct {
+	ID          int             `json:"id"`
+	Base        Currency        `json:"base"`
+	Quote       Currency        `json:"quote"`
+	Rate        decimal.Decimal `json:"rate"`
+	EffectiveAt time.Time       `json:"effective_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the FXRate in TimeLayout.
+func (r FXRate) MarshalJSON() ([]byte, error) {
+	type alias FXRate
+	return json.Marshal(struct {
+		alias
+		EffectiveAt jsonTime `json:"effective_at"`
+		CreatedAt   jsonTime `json:"created_at"`
+	}{
+		alias:       alias(r),
+		EffectiveAt: jsonTime(r.EffectiveAt),
+		CreatedAt:   jsonTime(r.CreatedAt),
+	})
+}
+
+// Validate checks every field of the rate, returning all failures joined as
+// FieldErrors.
+func (r FXRate) Validate() error {
+	var errs []error
+	if !r.Base.Valid() {
+		errs = append(errs, &FieldError{Field: "base", Err: ErrInvalidCurrency})
+	}
+	if !r.Quote.Valid() || r.Quote == r.Base {
+		errs = append(errs, &FieldError{Field: "quote", Err: ErrInvalidCurrency})
+	}
+	if !r.Rate.IsPositive() {
+		errs = append(errs, &FieldError{Field: "rate", Err: ErrInvalidFXRate})
+	}
+	if r.EffectiveAt.IsZero() {
+		errs = append(errs, &FieldError{Field: "effective_at", Err: ErrInvalidFXRate})
+	}
+	return errors.Join(errs...)
+}
+
+type FXRateFilter struct {
+	Base  Currency
+	Quote Currency
+}
@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"regexp"
+	"time"
+	"transaction-routine/internal/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrCustomerNotFound    = errors.New("customer not found")
+	ErrCustomerExists      = errors.New("a customer with this document number already exists")
+	ErrMissingCustomerName = errors.New("missing name")
+	ErrInvalidKYCStatus    = errors.New("invalid kyc status")
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrInvalidPhone        = errors.New("invalid phone, expected E.164 such as +5511987654321")
+)
+
+type KYCStatus string
+
+const (
+	KYCPending  KYCStatus = "pending"
+	KYCVerified KYCStatus = "verified"
+	KYCRejected KYCStatus = "rejected"
+)
+
+func (s KYCStatus) Valid() bool {
+	switch s {
+	case KYCPending, KYCVerified, KYCRejected:
+		return true
+	}
+	return false
+}
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// Customer is the person owning accounts, identified by its document number.
+// Customers of accounts opened before customers were introduced, or opened
+// with a document number only, have no name and a pending KYC.
+type Customer struct {
+	ID             int       `json:"id"`
+	DocumentNumber string    `json:"document_number"`
+	Name           string    `json:"name"`
+	KYCStatus      KYCStatus `json:"kyc_status"`
+	Email          string    `json:"email,omitempty"`
+	Phone          string    `json:"phone,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the Customer in TimeLayout.
+func (c Customer) MarshalJSON() ([]byte, error) {
+	type alias Customer
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime `json:"created_at"`
+	}{
+		alias:     alias(c),
+		CreatedAt: jsonTime(c.CreatedAt),
+	})
+}
+
+// Validate checks every field of the customer, returning all failures joined
+// as FieldErrors, and defaults its KYC status to pending.
+func (c *Customer) Validate() error {
+	if c.KYCStatus == "" {
+		c.KYCStatus = KYCPending
+	}
+	var errs []error
+	if c.DocumentNumber == "" {
+		errs = append(errs, &FieldError{Field: "document_number", Err: ErrMissingDocumentNumber})
+	}
+	if c.Name == "" {
+		errs = append(errs, &FieldError{Field: "name", Err: ErrMissingCustomerName})
+	}
+	if !c.KYCStatus.Valid() {
+		errs = append(errs, &FieldError{Field: "kyc_status", Err: ErrInvalidKYCStatus})
+	}
+	if c.Email != "" {
+		if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+			errs = append(errs, &FieldError{Field: "email", Err: ErrInvalidEmail})
+		}
+	}
+	if c.Phone != "" && !phonePattern.MatchString(c.Phone) {
+		errs = append(errs, &FieldError{Field: "phone", Err: ErrInvalidPhone})
+	}
+	return errors.Join(errs...)
+}
+
+func (c Customer) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", c.ID),
+		slog.String("document_number", logger.MaskDocument(c.DocumentNumber)),
+		slog.String("kyc_status", string(c.KYCStatus)),
+	)
+}
+
+type CustomerFilter struct {
+	ID             *int
+	DocumentNumber *string
+}
+
+// CustomerAccountBalance is the balance of an account of a customer, in the
+// currency of the account.
+type CustomerAccountBalance struct {
+	AccountID int             `json:"account_id"`
+	Currency  Currency        `json:"currency"`
+	Balance   decimal.Decimal `json:"balance"`
+}
+
+// CurrencyTotal is the sum of the balances of the accounts of a customer
+// held in Currency.
+type CurrencyTotal struct {
+	Currency Currency        `json:"currency"`
+	Balance  decimal.Decimal `json:"balance"`
+	Accounts int             `json:"accounts"`
+}
+
+// CustomerBalances is the consolidated view of the accounts of a customer.
+// Balances in different currencies are not added up: Totals holds one entry
+// per currency, in the order their first account was opened.
+type CustomerBalances struct {
+	CustomerID int                      `json:"customer_id"`
+	Accounts   []CustomerAccountBalance `json:"accounts"`
+	Totals     []CurrencyTotal          `json:"totals"`
+}
+
+// NewCustomerBalances consolidates the balances of the accounts of a
+// customer, given in the order the accounts were opened.
+func NewCustomerBalances(customerID int, accounts []CustomerAccountBalance) CustomerBalances {
+	b := CustomerBalances{CustomerID: customerID, Accounts: accounts, Totals: []CurrencyTotal{}}
+	if b.Accounts == nil {
+		b.Accounts = []CustomerAccountBalance{}
+	}
+	index := map[Currency]int{}
+	for _, acc := range accounts {
+		i, ok := index[acc.Currency]
+		if !ok {
+			i = len(b.Totals)
+			index[acc.Currency] = i
+			b.Totals = append(b.Totals, CurrencyTotal{Currency: acc.Currency, Balance: decimal.Zero})
+		}
+		b.Totals[i].Balance = b.Totals[i].Balance.Add(acc.Balance)
+		b.Totals[i].Accounts++
+	}
+	return b
+}
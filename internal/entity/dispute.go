@@ -0,0 +1,177 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operation types of the transactions posted by disputes, created by the
+// disputes migration, and the only operation type that can be disputed.
+const (
+	DisputeCreditDescription   = "CREDITO PROVISORIO"
+	DisputeReversalDescription = "ESTORNO CREDITO PROVISORIO"
+	DisputableDescription      = "COMPRA A VISTA"
+)
+
+var (
+	ErrDisputeNotFound          = errors.New("dispute not found")
+	ErrNotDisputable            = errors.New("only COMPRA A VISTA transactions can be disputed")
+	ErrInvalidDisputeAmount     = errors.New("must be positive and at most the purchase amount")
+	ErrMissingDisputeReason     = errors.New("missing dispute reason")
+	ErrAlreadyDisputed          = errors.New("transaction already has a dispute under way or won")
+	ErrInvalidDisputeTransition = errors.New("dispute cannot move to that status")
+	ErrDisputeTypesMissing      = errors.New("dispute operation types are missing")
+)
+
+type DisputeStatus string
+
+const (
+	DisputeOpened            DisputeStatus = "opened"
+	DisputeProvisionalCredit DisputeStatus = "provisional_credit"
+	DisputeWon               DisputeStatus = "won"
+	DisputeLost              DisputeStatus = "lost"
+	DisputeWithdrawn         DisputeStatus = "withdrawn"
+)
+
+func (s DisputeStatus) Valid() bool {
+	switch s {
+	case DisputeOpened, DisputeProvisionalCredit, DisputeWon, DisputeLost, DisputeWithdrawn:
+		return true
+	}
+	return false
+}
+
+// Dispute is a cardholder claim against a purchase. The account is credited
+// the disputed Amount, provisionally while the dispute is under way or for
+// good once it is won, and the credit is reversed if the dispute is lost or
+// withdrawn. The credit is due by CreditDueAt and the outcome by ResolveBy.
+type Dispute struct {
+	ID                    int             `json:"id"`
+	TransactionID         int             `json:"transaction_id"`
+	AccountID             int             `json:"account_id"`
+	Amount                decimal.Decimal `json:"amount"`
+	Reason                string          `json:"reason"`
+	Status                DisputeStatus   `json:"status"`
+	CreditTransactionID   *int            `json:"credit_transaction_id,omitempty"`
+	ReversalTransactionID *int            `json:"reversal_transaction_id,omitempty"`
+	CreditDueAt           time.Time       `json:"credit_due_at"`
+	ResolveBy             time.Time       `json:"resolve_by"`
+	Overdue               bool            `json:"overdue"`
+	OpenedBy              string          `json:"opened_by"`
+	OpenedAt              time.Time       `json:"opened_at"`
+	ResolvedAt            *time.Time      `json:"resolved_at,omitempty"`
+}
+
+// MarshalJSON renders the dates of the Dispute in TimeLayout.
+func (d Dispute) MarshalJSON() ([]byte, error) {
+	type alias Dispute
+	return json.Marshal(struct {
+		alias
+		CreditDueAt jsonTime  `json:"credit_due_at"`
+		ResolveBy   jsonTime  `json:"resolve_by"`
+		OpenedAt    jsonTime  `json:"opened_at"`
+		ResolvedAt  *jsonTime `json:"resolved_at,omitempty"`
+	}{
+		alias:       alias(d),
+		CreditDueAt: jsonTime(d.CreditDueAt),
+		ResolveBy:   jsonTime(d.ResolveBy),
+		OpenedAt:    jsonTime(d.OpenedAt),
+		ResolvedAt:  (*jsonTime)(d.ResolvedAt),
+	})
+}
+
+// DisputeFilter selects disputes. Overdue selects the ones past a deadline
+// at AsOf.
+type DisputeFilter struct {
+	ID        *int
+	AccountID *int
+	Status    DisputeStatus
+	Overdue   bool
+	AsOf      time.Time
+}
+
+// Open checks the dispute against the disputed purchase and fills it in. A
+// zero Amount disputes the whole purchase.
+func (d *Dispute) Open(tx Transaction, opTypes OperationType) error {
+	if op, ok := opTypes[tx.OperationTypeID]; !ok || op.Description != DisputableDescription {
+		return &FieldError{Field: "transaction_id", Err: ErrNotDisputable}
+	}
+	var errs []error
+	purchase := tx.Amount.Abs()
+	if d.Amount.IsZero() {
+		d.Amount = purchase
+	}
+	if !d.Amount.IsPositive() || d.Amount.GreaterThan(purchase) {
+		errs = append(errs, &FieldError{Field: "amount", Err: ErrInvalidDisputeAmount})
+	} else if err := checkAmount(d.Amount); err != nil {
+		errs = append(errs, &FieldError{Field: "amount", Err: err})
+	}
+	if d.Reason == "" {
+		errs = append(errs, &FieldError{Field: "reason", Err: ErrMissingDisputeReason})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	d.AccountID = tx.AccountID
+	d.Status = DisputeOpened
+	return nil
+}
+
+// Resolved reports whether the dispute reached a final status.
+func (d Dispute) Resolved() bool {
+	return d.Status == DisputeWon || d.Status == DisputeLost || d.Status == DisputeWithdrawn
+}
+
+// PastDeadline reports whether the credit or the outcome of the dispute is
+// late at now.
+func (d Dispute) PastDeadline(now time.Time) bool {
+	if d.Resolved() {
+		return false
+	}
+	return (d.Status == DisputeOpened && now.After(d.CreditDueAt)) || now.After(d.ResolveBy)
+}
+
+// DeadlineStatus returns the status a dispute past a deadline at now moves
+// to: provisional_credit once its credit is due, or won once its outcome is,
+// the cardholder keeping the credit. ok is false when no deadline passed.
+func (d Dispute) DeadlineStatus(now time.Time) (status DisputeStatus, ok bool) {
+	switch {
+	case !d.PastDeadline(now):
+		return "", false
+	case now.After(d.ResolveBy):
+		return DisputeWon, true
+	default:
+		return DisputeProvisionalCredit, true
+	}
+}
+
+// Transition moves the dispute to status and returns the transaction to post
+// along, if any: the credit when the account was not credited yet and the
+// dispute is credited or won, or its reversal when a credited dispute is lost
+// or withdrawn. credit and reversal are the operation types to post with.
+func (d *Dispute) Transition(status DisputeStatus, credit, reversal int, now time.Time) (*Transaction, error) {
+	if d.Resolved() || status == d.Status {
+		return nil, ErrInvalidDisputeTransition
+	}
+	var posting *Transaction
+	switch status {
+	case DisputeProvisionalCredit, DisputeWon:
+		if d.Status == DisputeOpened {
+			posting = &Transaction{AccountID: d.AccountID, OperationTypeID: credit, Amount: d.Amount, EventDate: now}
+		}
+	case DisputeLost, DisputeWithdrawn:
+		if d.Status == DisputeProvisionalCredit {
+			posting = &Transaction{AccountID: d.AccountID, OperationTypeID: reversal, Amount: d.Amount.Neg(), EventDate: now}
+		}
+	default:
+		return nil, ErrInvalidDisputeTransition
+	}
+	d.Status = status
+	if d.Resolved() {
+		d.ResolvedAt = &now
+	}
+	return posting, nil
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventAccountCreated      EventType = "AccountCreated"
+	EventTransactionCreated  EventType = "TransactionCreated"
+	EventTransactionUpdated  EventType = "TransactionUpdated"
+	EventTransactionReversed EventType = "TransactionReversed"
+)
+
+// Event is a domain event written to the outbox along with the change it
+// describes. Events of the same account are delivered in ID order; the ID
+// also lets consumers drop the duplicates at-least-once delivery may cause.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      EventType       `json:"type"`
+	AccountID int             `json:"account_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the Event in TimeLayout.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type alias Event
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime `json:"created_at"`
+	}{
+		alias:     alias(e),
+		CreatedAt: jsonTime(e.CreatedAt),
+	})
+}
+
+// Accounts returns the accounts the event concerns: its own and, for an
+// update that moved a transaction, the account that lost it.
+func (e Event) Accounts() []int {
+	if e.Type == EventTransactionUpdated {
+		var change TransactionChange
+		if json.Unmarshal(e.Payload, &change) == nil && change.Previous.AccountID != 0 && change.Previous.AccountID != e.AccountID {
+			return []int{e.AccountID, change.Previous.AccountID}
+		}
+	}
+	return []int{e.AccountID}
+}
+
+// EventFilter selects outbox events after AfterID, in id order. A zero
+// AccountID matches every account and a zero UntilID leaves the range open.
+type EventFilter struct {
+	AccountID int
+	AfterID   int64
+	UntilID   int64
+	Limit     int
+}
+
+// TransactionChange is the payload of TransactionUpdated. Previous tells
+// consumers which account lost the amount when a transaction is moved.
+type TransactionChange struct {
+	Transaction Transaction `json:"transaction"`
+	Previous    Transaction `json:"previous"`
+}
+
+// TransactionReversal is the payload of TransactionReversed: the
+// transaction posted to reverse the one with ReversedTransactionID.
+type TransactionReversal struct {
+	Transaction           Transaction `json:"transaction"`
+	ReversedTransactionID int         `json:"reversed_transaction_id"`
+}
+
+func NewAccountCreated(acc Account) Event {
+	return newEvent(EventAccountCreated, acc.ID, acc)
+}
+
+func NewTransactionCreated(tx Transaction) Event {
+	return newEvent(EventTransactionCreated, tx.AccountID, tx)
+}
+
+func NewTransactionUpdated(tx, previous Transaction) Event {
+	return newEvent(EventTransactionUpdated, tx.AccountID, TransactionChange{Transaction: tx, Previous: previous})
+}
+
+func NewTransactionReversed(tx Transaction, reversedID int) Event {
+	return newEvent(EventTransactionReversed, tx.AccountID, TransactionReversal{Transaction: tx, ReversedTransactionID: reversedID})
+}
+
+func newEvent(t EventType, accountID int, payload any) Event {
+	// Accounts and transactions always marshal.
+	b, _ := json.Marshal(payload)
+	return Event{Type: t, AccountID: accountID, Payload: b}
+}
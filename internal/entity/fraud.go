@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTransactionDenied = errors.New("transaction denied")
+	ErrInvalidFraudRules = errors.New("invalid fraud rules")
+)
+
+// FraudAction is the outcome of screening a transaction. Reviewed
+// transactions are created, and flagged for an analyst to look at.
+type FraudAction string
+
+const (
+	FraudAllow  FraudAction = "allow"
+	FraudReview FraudAction = "review"
+	FraudDeny   FraudAction = "deny"
+)
+
+// Severity orders the actions from allow to deny.
+func (a FraudAction) Severity() int {
+	switch a {
+	case FraudReview:
+		return 1
+	case FraudDeny:
+		return 2
+	}
+	return 0
+}
+
+// FraudDecision records the screening of a transaction and the rule behind
+// it, empty when the transaction was allowed. Denied transactions have no
+// TransactionID, since they were not created.
+type FraudDecision struct {
+	ID              int64           `json:"id"`
+	TransactionID   *int            `json:"transaction_id,omitempty"`
+	AccountID       int             `json:"account_id"`
+	OperationTypeID int             `json:"operation_type_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Action          FraudAction     `json:"action"`
+	Rule            string          `json:"rule,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the FraudDecision in TimeLayout.
+func (d FraudDecision) MarshalJSON() ([]byte, error) {
+	type alias FraudDecision
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime `json:"created_at"`
+	}{
+		alias:     alias(d),
+		CreatedAt: jsonTime(d.CreatedAt),
+	})
+}
+
+const (
+	DefaultFraudDecisionLimit = 100
+	MaxFraudDecisionLimit     = 1000
+)
+
+// FraudDecisionFilter selects decisions, newest first.
+type FraudDecisionFilter struct {
+	AccountID *int
+	Action    FraudAction
+	Limit     int
+}
@@ -0,0 +1,28 @@
+package entity
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Status  string         `json:"status"`
+	Details map[string]any `json:"details,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type PoolStats struct {
+	Acquired int32
+	Idle     int32
+	Total    int32
+	Max      int32
+}
+
+func (h HealthReport) IsUp() bool {
+	return h.Status == HealthStatusUp
+}
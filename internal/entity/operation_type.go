@@ -1,8 +1,38 @@
 package entity
 
+import (
+	"errors"
+	"slices"
+)
+
+var ErrMissingOperationDescription = errors.New("missing operation type description")
+
+// systemDescriptions are the operation types only the service posts, along
+// with the records auditing them: adjustments and dispute credits. Clients
+// cannot post them.
+var systemDescriptions = []string{
+	AdjustmentCreditDescription,
+	AdjustmentDebitDescription,
+	DisputeCreditDescription,
+	DisputeReversalDescription,
+}
+
 type OperationType map[int]*Operation
 
 type Operation struct {
 	Description    string `json:"description"`
 	PositiveAmount bool   `json:"positive_amount"`
 }
+
+func (op Operation) Validate() error {
+	if op.Description == "" {
+		return &FieldError{Field: "description", Err: ErrMissingOperationDescription}
+	}
+	return nil
+}
+
+// System reports whether the operation type is reserved for the postings of
+// the service itself.
+func (op Operation) System() bool {
+	return slices.Contains(systemDescriptions, op.Description)
+}
@@ -0,0 +1,141 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operation types of the adjustments posted to fix sign violations, created
+// by the reconciliation migration.
+const (
+	AdjustmentCreditDescription = "AJUSTE CREDITO"
+	AdjustmentDebitDescription  = "AJUSTE DEBITO"
+)
+
+var (
+	ErrAdjustmentTypesMissing = errors.New("adjustment operation types are missing")
+	ErrAlreadyAdjusted        = errors.New("a sign violation was already adjusted by another reconciliation")
+)
+
+type AccountBalance struct {
+	AccountID int             `json:"account_id"`
+	Balance   decimal.Decimal `json:"balance"`
+}
+
+// ClosingBalances are the balances of a previous reconciliation. The ones
+// recomputed as of the same date must not have changed.
+type ClosingBalances struct {
+	AsOf     time.Time        `json:"as_of"`
+	Balances []AccountBalance `json:"balances"`
+}
+
+// MarshalJSON renders the dates of the ClosingBalances in TimeLayout.
+func (c ClosingBalances) MarshalJSON() ([]byte, error) {
+	type alias ClosingBalances
+	return json.Marshal(struct {
+		alias
+		AsOf jsonTime `json:"as_of"`
+	}{
+		alias: alias(c),
+		AsOf:  jsonTime(c.AsOf),
+	})
+}
+
+type ReconciliationRequest struct {
+	Closing *ClosingBalances
+	// Apply posts an adjustment for every sign violation found.
+	Apply bool
+}
+
+// BalanceDrift is an account whose balance as of the closing date no longer
+// matches the closing balance.
+type BalanceDrift struct {
+	AccountID int             `json:"account_id"`
+	Expected  decimal.Decimal `json:"expected"`
+	Actual    decimal.Decimal `json:"actual"`
+}
+
+// SignViolation is a transaction whose amount sign does not match the
+// PositiveAmount of its operation type.
+type SignViolation struct {
+	TransactionID   int             `json:"transaction_id"`
+	AccountID       int             `json:"account_id"`
+	OperationTypeID int             `json:"operation_type_id"`
+	Amount          decimal.Decimal `json:"amount"`
+}
+
+// Adjustment is a transaction posted by a reconciliation to correct another
+// one, recorded in the audit table with who applied it and why.
+type Adjustment struct {
+	TransactionID         int             `json:"transaction_id"`
+	AdjustedTransactionID int             `json:"adjusted_transaction_id"`
+	AccountID             int             `json:"account_id"`
+	OperationTypeID       int             `json:"operation_type_id"`
+	Amount                decimal.Decimal `json:"amount"`
+	EventDate             time.Time       `json:"event_date"`
+	Reason                string          `json:"reason"`
+	CreatedBy             string          `json:"created_by"`
+}
+
+// MarshalJSON renders the dates of the Adjustment in TimeLayout.
+func (a Adjustment) MarshalJSON() ([]byte, error) {
+	type alias Adjustment
+	return json.Marshal(struct {
+		alias
+		EventDate jsonTime `json:"event_date"`
+	}{
+		alias:     alias(a),
+		EventDate: jsonTime(a.EventDate),
+	})
+}
+
+// ReconciliationReport holds the balances recomputed from the transactions,
+// which can be given back as the closing balances of the next run, and the
+// problems found.
+type ReconciliationReport struct {
+	AsOf           time.Time        `json:"as_of"`
+	Balances       []AccountBalance `json:"balances"`
+	ClosingAsOf    *time.Time       `json:"closing_as_of,omitempty"`
+	Drifts         []BalanceDrift   `json:"drifts"`
+	SignViolations []SignViolation  `json:"sign_violations"`
+	Adjustments    []Adjustment     `json:"adjustments"`
+}
+
+// MarshalJSON renders the dates of the ReconciliationReport in TimeLayout.
+func (r ReconciliationReport) MarshalJSON() ([]byte, error) {
+	type alias ReconciliationReport
+	return json.Marshal(struct {
+		alias
+		AsOf        jsonTime  `json:"as_of"`
+		ClosingAsOf *jsonTime `json:"closing_as_of,omitempty"`
+	}{
+		alias:       alias(r),
+		AsOf:        jsonTime(r.AsOf),
+		ClosingAsOf: (*jsonTime)(r.ClosingAsOf),
+	})
+}
+
+// Clean reports whether nothing is left to fix: no drift and no sign
+// violation without an adjustment.
+func (r ReconciliationReport) Clean() bool {
+	return len(r.Drifts) == 0 && len(r.SignViolations) == len(r.Adjustments)
+}
+
+// Correction returns the adjustment that cancels a sign violation: twice the
+// opposite of its amount, so the account ends up as if the sign were right.
+func (v SignViolation) Correction(credit, debit int) Adjustment {
+	amount := v.Amount.Neg().Mul(decimal.NewFromInt(2))
+	op := debit
+	if amount.IsPositive() {
+		op = credit
+	}
+	return Adjustment{
+		AdjustedTransactionID: v.TransactionID,
+		AccountID:             v.AccountID,
+		OperationTypeID:       op,
+		Amount:                amount,
+	}
+}
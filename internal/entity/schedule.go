@@ -0,0 +1,220 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrScheduleNotFound  = errors.New("schedule not found")
+	ErrScheduleNotActive = errors.New("schedule is no longer active")
+	ErrRunNotRunning     = errors.New("schedule run is no longer running")
+	ErrInvalidRecurrence = errors.New("must be once, weekly or monthly")
+	ErrInvalidDay        = errors.New("must be a weekday from 0 (Sunday) to 6 for weekly schedules, or a day from 1 to 31 for monthly ones")
+	ErrStartNotInFuture  = errors.New("must be in the future")
+	ErrInvalidEnd        = errors.New("must be after start_at")
+)
+
+type Recurrence string
+
+const (
+	RecurrenceOnce    Recurrence = "once"
+	RecurrenceWeekly  Recurrence = "weekly"
+	RecurrenceMonthly Recurrence = "monthly"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	ScheduleCompleted ScheduleStatus = "completed"
+	ScheduleCanceled  ScheduleStatus = "canceled"
+)
+
+// Schedule posts a transaction at StartAt, once or repeatedly. Weekly
+// schedules run on the weekday Day and monthly ones on the day Day, or the
+// last day of shorter months, both at the time of day of StartAt.
+type Schedule struct {
+	ID              int             `json:"id"`
+	AccountID       int             `json:"account_id"`
+	OperationTypeID int             `json:"operation_type_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Recurrence      Recurrence      `json:"recurrence"`
+	Day             *int            `json:"day,omitempty"`
+	StartAt         time.Time       `json:"start_at"`
+	EndAt           *time.Time      `json:"end_at,omitempty"`
+	NextRunAt       *time.Time      `json:"next_run_at,omitempty"`
+	Status          ScheduleStatus  `json:"status"`
+	CreatedBy       string          `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the Schedule in TimeLayout.
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	type alias Schedule
+	return json.Marshal(struct {
+		alias
+		StartAt   jsonTime  `json:"start_at"`
+		EndAt     *jsonTime `json:"end_at,omitempty"`
+		NextRunAt *jsonTime `json:"next_run_at,omitempty"`
+		CreatedAt jsonTime  `json:"created_at"`
+	}{
+		alias:     alias(s),
+		StartAt:   jsonTime(s.StartAt),
+		EndAt:     (*jsonTime)(s.EndAt),
+		NextRunAt: (*jsonTime)(s.NextRunAt),
+		CreatedAt: jsonTime(s.CreatedAt),
+	})
+}
+
+type ScheduleFilter struct {
+	ID        *int
+	AccountID *int
+}
+
+// Validate checks the schedule fields other than the transaction ones, which
+// are validated as a transaction, given the current time.
+func (s Schedule) Validate(now time.Time) error {
+	var errs []error
+	switch s.Recurrence {
+	case RecurrenceOnce:
+		if s.Day != nil {
+			errs = append(errs, &FieldError{Field: "day", Err: ErrInvalidDay})
+		}
+	case RecurrenceWeekly:
+		if s.Day != nil && (*s.Day < 0 || *s.Day > 6) {
+			errs = append(errs, &FieldError{Field: "day", Err: ErrInvalidDay})
+		}
+	case RecurrenceMonthly:
+		if s.Day != nil && (*s.Day < 1 || *s.Day > 31) {
+			errs = append(errs, &FieldError{Field: "day", Err: ErrInvalidDay})
+		}
+	default:
+		errs = append(errs, &FieldError{Field: "recurrence", Err: ErrInvalidRecurrence})
+	}
+	if !s.StartAt.After(now) {
+		errs = append(errs, &FieldError{Field: "start_at", Err: ErrStartNotInFuture})
+	}
+	if s.EndAt != nil && !s.EndAt.After(s.StartAt) {
+		errs = append(errs, &FieldError{Field: "end_at", Err: ErrInvalidEnd})
+	}
+	return errors.Join(errs...)
+}
+
+// Start makes the schedule active from its first run, computed in loc. A
+// missing Day is taken from StartAt. A schedule whose first run falls after
+// EndAt is completed right away, without any run.
+func (s *Schedule) Start(loc *time.Location) {
+	s.StartAt = s.StartAt.In(loc)
+	if s.Day == nil {
+		switch s.Recurrence {
+		case RecurrenceWeekly:
+			day := int(s.StartAt.Weekday())
+			s.Day = &day
+		case RecurrenceMonthly:
+			day := s.StartAt.Day()
+			s.Day = &day
+		}
+	}
+	s.Status = ScheduleActive
+	first := s.StartAt
+	if s.Recurrence != RecurrenceOnce {
+		first = s.next(s.StartAt.Add(-time.Nanosecond))
+	}
+	if s.EndAt != nil && first.After(*s.EndAt) {
+		s.Status, s.NextRunAt = ScheduleCompleted, nil
+		return
+	}
+	s.NextRunAt = &first
+}
+
+// NextRun returns the run following the one due at due, computed in the
+// location of due, or nil when there is none.
+func (s Schedule) NextRun(due time.Time) *time.Time {
+	if s.Recurrence == RecurrenceOnce {
+		return nil
+	}
+	next := s.next(due)
+	if s.EndAt != nil && next.After(*s.EndAt) {
+		return nil
+	}
+	return &next
+}
+
+// next returns the first run strictly after t.
+func (s Schedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	h, m, sec := s.StartAt.In(loc).Clock()
+	y, mo, d := t.Date()
+	switch s.Recurrence {
+	case RecurrenceWeekly:
+		for i := 0; ; i++ {
+			c := time.Date(y, mo, d+i, h, m, sec, 0, loc)
+			if c.Weekday() == time.Weekday(*s.Day) && c.After(t) {
+				return c
+			}
+		}
+	default:
+		for i := 0; ; i++ {
+			first := time.Date(y, mo+time.Month(i), 1, h, m, sec, 0, loc)
+			last := first.AddDate(0, 1, -1).Day()
+			c := time.Date(first.Year(), first.Month(), min(*s.Day, last), h, m, sec, 0, loc)
+			if c.After(t) {
+				return c
+			}
+		}
+	}
+}
+
+// Transaction returns the transaction a run of the schedule posts.
+func (s Schedule) Transaction() Transaction {
+	return Transaction{AccountID: s.AccountID, OperationTypeID: s.OperationTypeID, Amount: s.Amount}
+}
+
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+// ScheduleRun records the posting of one run of a schedule.
+type ScheduleRun struct {
+	ID            int64      `json:"id"`
+	ScheduleID    int        `json:"schedule_id"`
+	DueAt         time.Time  `json:"due_at"`
+	Status        RunStatus  `json:"status"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Schedule      Schedule   `json:"-"`
+}
+
+// MarshalJSON renders the dates of the ScheduleRun in TimeLayout.
+func (r ScheduleRun) MarshalJSON() ([]byte, error) {
+	type alias ScheduleRun
+	return json.Marshal(struct {
+		alias
+		DueAt      jsonTime  `json:"due_at"`
+		StartedAt  jsonTime  `json:"started_at"`
+		FinishedAt *jsonTime `json:"finished_at,omitempty"`
+	}{
+		alias:      alias(r),
+		DueAt:      jsonTime(r.DueAt),
+		StartedAt:  jsonTime(r.StartedAt),
+		FinishedAt: (*jsonTime)(r.FinishedAt),
+	})
+}
+
+// Transaction returns the transaction posted by the run, which finishes the
+// run when it is created.
+func (r ScheduleRun) Transaction() Transaction {
+	tx := r.Schedule.Transaction()
+	tx.ScheduleRunID = &r.ID
+	return tx
+}
@@ -1,8 +1,11 @@
 package entity
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
+	"transaction-routine/internal/mergepatch"
 
 	"github.com/shopspring/decimal"
 )
@@ -10,17 +13,43 @@ import (
 var (
 	ErrInvalidAccountID       = errors.New("invalid account id")
 	ErrInvalidOperationTypeID = errors.New("invalid operation type id")
+	ErrSystemOperationType    = errors.New("operation type is reserved for system postings")
 	ErrInvalidAmount          = errors.New("invalid amount")
 	ErrInvalidEventDate       = errors.New("invalid event date")
 	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrInvalidPatch           = errors.New("invalid merge patch")
 )
 
+// TimeLayout is the RFC 3339 layout used for dates in API responses. Unlike
+// time.RFC3339 it always renders a numeric offset, even for UTC.
+const TimeLayout = "2006-01-02T15:04:05.999999-07:00"
+
+// jsonTime renders a time in TimeLayout. MarshalJSON methods convert their
+// time fields to it.
+type jsonTime time.Time
+
+func (t jsonTime) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(t).Format(TimeLayout) + `"`), nil
+}
+
+// Transaction amounts are in the currency of their account. The amount as
+// entered, in OriginalCurrency, is kept along with the FXRate that converted
+// it, which is 1 when no conversion took place. Transactions made with a card
+// keep its id; CardToken is the token entered in place of the account, until
+// the card is resolved. ScheduleRunID is the schedule run posting the
+// transaction, finished along with it.
 type Transaction struct {
-	ID              int             `json:"id"`
-	AccountID       int             `json:"account_id"`
-	OperationTypeID int             `json:"operation_type_id"`
-	Amount          decimal.Decimal `json:"amount"`
-	EventDate       time.Time       `json:"event_date"`
+	ID               int             `json:"id"`
+	AccountID        int             `json:"account_id"`
+	OperationTypeID  int             `json:"operation_type_id"`
+	Amount           decimal.Decimal `json:"amount"`
+	EventDate        time.Time       `json:"event_date"`
+	OriginalAmount   decimal.Decimal `json:"original_amount"`
+	OriginalCurrency Currency        `json:"original_currency"`
+	FXRate           decimal.Decimal `json:"fx_rate"`
+	CardID           *int            `json:"card_id,omitempty"`
+	CardToken        string          `json:"-"`
+	ScheduleRunID    *int64          `json:"-"`
 }
 
 type TransactionFilter struct {
@@ -31,19 +60,91 @@ type TransactionFilter struct {
 	EventDate       *time.Time       `json:"event_date"`
 }
 
+// MarshalJSON leaves out the original amount of transactions not converted
+// yet, such as the postings built by the repository.
+func (tx Transaction) MarshalJSON() ([]byte, error) {
+	type alias Transaction
+	v := struct {
+		alias
+		EventDate        jsonTime         `json:"event_date"`
+		OriginalAmount   *decimal.Decimal `json:"original_amount,omitempty"`
+		OriginalCurrency Currency         `json:"original_currency,omitempty"`
+		FXRate           *decimal.Decimal `json:"fx_rate,omitempty"`
+	}{
+		alias:     alias(tx),
+		EventDate: jsonTime(tx.EventDate),
+	}
+	if tx.OriginalCurrency != "" {
+		v.OriginalAmount, v.OriginalCurrency, v.FXRate = &tx.OriginalAmount, tx.OriginalCurrency, &tx.FXRate
+	}
+	return json.Marshal(v)
+}
+
+// Convert fixes the amount of the transaction, entered in OriginalCurrency,
+// or in the account currency when it is empty, into the account currency,
+// keeping the entered amount and the rate used. Both amounts are checked
+// against limits, and the converted one is rounded half to even to the scale
+// limits allow. rate is ignored, and may be nil, when no conversion is
+// needed.
+func (tx *Transaction) Convert(account Currency, rate *FXRate, limits AmountLimits) error {
+	if tx.OriginalCurrency == "" {
+		tx.OriginalCurrency = account
+	}
+	if !tx.OriginalCurrency.Valid() {
+		return &FieldError{Field: "currency", Err: ErrInvalidCurrency}
+	}
+	if err := limits.Check(tx.Amount, tx.OriginalCurrency, tx.OperationTypeID); err != nil {
+		return err
+	}
+	tx.OriginalAmount = tx.Amount
+	if tx.OriginalCurrency == account {
+		tx.FXRate = decimal.NewFromInt(1)
+		return nil
+	}
+	if rate == nil || rate.Base != tx.OriginalCurrency || rate.Quote != account {
+		return &FieldError{Field: "currency", Err: ErrFXRateNotFound}
+	}
+	tx.FXRate = rate.Rate
+	tx.Amount = tx.Amount.Mul(rate.Rate).RoundBank(limits.Scale(account, tx.OperationTypeID))
+	if tx.Amount.IsZero() {
+		return &FieldError{Field: "amount", Err: ErrInvalidAmount}
+	}
+	return limits.Check(tx.Amount, account, tx.OperationTypeID)
+}
+
+// Entered returns the transaction as entered, before its conversion.
+func (tx Transaction) Entered() Transaction {
+	if tx.OriginalCurrency != "" {
+		tx.Amount = tx.OriginalAmount
+	}
+	tx.OriginalAmount, tx.FXRate = decimal.Decimal{}, decimal.Decimal{}
+	return tx
+}
+
+// Validate checks every field of the transaction posted by a client,
+// returning all failures joined as FieldErrors, and fixes the amount sign for
+// its operation type. System operation types are refused.
 func (tx *Transaction) Validate(opTypes OperationType) error {
+	var errs []error
 	if tx.AccountID <= 0 {
-		return ErrInvalidAccountID
+		errs = append(errs, &FieldError{Field: "account_id", Err: ErrInvalidAccountID})
 	}
 	if tx.Amount.IsZero() {
-		return ErrInvalidAmount
+		errs = append(errs, &FieldError{Field: "amount", Err: ErrInvalidAmount})
+	} else if err := checkAmount(tx.Amount); err != nil {
+		errs = append(errs, &FieldError{Field: "amount", Err: err})
 	}
 	if tx.EventDate.IsZero() {
-		return ErrInvalidEventDate
+		errs = append(errs, &FieldError{Field: "event_date", Err: ErrInvalidEventDate})
 	}
 	op, ok := opTypes[tx.OperationTypeID]
 	if !ok {
-		return ErrInvalidOperationTypeID
+		errs = append(errs, &FieldError{Field: "operation_type_id", Err: ErrInvalidOperationTypeID})
+	} else if op.System() {
+		errs = append(errs, &FieldError{Field: "operation_type_id", Err: ErrSystemOperationType})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 	if (op.PositiveAmount && tx.Amount.LessThan(decimal.Zero)) || (!op.PositiveAmount && tx.Amount.GreaterThan(decimal.Zero)) {
 		tx.Amount = tx.Amount.Neg()
@@ -71,17 +172,22 @@ func (tx Transaction) ToFilter() TransactionFilter {
 	return filter
 }
 
-func (tx *Transaction) Update(newTx Transaction) {
-	if newTx.AccountID != 0 {
-		tx.AccountID = newTx.AccountID
+// Patch applies a JSON merge patch (RFC 7396) to the transaction. Fields
+// set to null are cleared and the id is never changed.
+func (tx *Transaction) Patch(patch []byte) error {
+	doc, err := json.Marshal(tx)
+	if err != nil {
+		return err
 	}
-	if newTx.OperationTypeID != 0 {
-		tx.OperationTypeID = newTx.OperationTypeID
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
 	}
-	if !newTx.Amount.IsZero() {
-		tx.Amount = newTx.Amount
-	}
-	if !newTx.EventDate.IsZero() {
-		tx.EventDate = newTx.EventDate
+	var patched Transaction
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
 	}
+	patched.ID = tx.ID
+	*tx = patched
+	return nil
 }
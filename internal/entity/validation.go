@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FieldError ties a validation error to the JSON field that caused it.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors returns every FieldError in err, which may be a single
+// FieldError or several joined with errors.Join.
+func FieldErrors(err error) []*FieldError {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var fields []*FieldError
+		for _, e := range joined.Unwrap() {
+			fields = append(fields, FieldErrors(e)...)
+		}
+		return fields
+	}
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		return []*FieldError{fe}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("must be an absolute http or https url")
+	ErrUnknownEventType        = errors.New("unknown event type")
+	ErrInvalidDeliveryStatus   = errors.New("invalid delivery status")
+)
+
+// EventTypes lists the event types subscriptions may filter on.
+var EventTypes = []EventType{
+	EventAccountCreated,
+	EventTransactionCreated,
+	EventTransactionUpdated,
+	EventTransactionReversed,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead is the dead-letter status of deliveries that failed every
+	// attempt. They are only sent again when replayed by hand.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookSubscription receives the events of the given types and accounts.
+// Empty filters match every event type or account. The secret signs the
+// deliveries and is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID         int         `json:"id"`
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	AccountIDs []int       `json:"account_ids"`
+	Secret     string      `json:"secret,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// MarshalJSON renders the dates of the WebhookSubscription in TimeLayout.
+func (s WebhookSubscription) MarshalJSON() ([]byte, error) {
+	type alias WebhookSubscription
+	return json.Marshal(struct {
+		alias
+		CreatedAt jsonTime `json:"created_at"`
+	}{
+		alias:     alias(s),
+		CreatedAt: jsonTime(s.CreatedAt),
+	})
+}
+
+func (s WebhookSubscription) Validate() error {
+	var errs []error
+	u, err := url.Parse(s.URL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, &FieldError{Field: "url", Err: ErrInvalidWebhookURL})
+	}
+	for _, t := range s.EventTypes {
+		if !slices.Contains(EventTypes, t) {
+			errs = append(errs, &FieldError{Field: "event_types", Err: ErrUnknownEventType})
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookDelivery is an event to be sent to a subscription, and the log of
+// its attempts so far.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      EventType       `json:"event_type"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"-"`
+	URL            string          `json:"-"`
+	Secret         string          `json:"-"`
+}
+
+// MarshalJSON renders the dates of the WebhookDelivery in TimeLayout.
+func (d WebhookDelivery) MarshalJSON() ([]byte, error) {
+	type alias WebhookDelivery
+	return json.Marshal(struct {
+		alias
+		NextAttemptAt jsonTime  `json:"next_attempt_at"`
+		LastAttemptAt *jsonTime `json:"last_attempt_at,omitempty"`
+		DeliveredAt   *jsonTime `json:"delivered_at,omitempty"`
+		CreatedAt     jsonTime  `json:"created_at"`
+	}{
+		alias:         alias(d),
+		NextAttemptAt: jsonTime(d.NextAttemptAt),
+		LastAttemptAt: (*jsonTime)(d.LastAttemptAt),
+		DeliveredAt:   (*jsonTime)(d.DeliveredAt),
+		CreatedAt:     jsonTime(d.CreatedAt),
+	})
+}
+
+const (
+	DefaultDeliveryLimit = 100
+	MaxDeliveryLimit     = 1000
+)
+
+// DeliveryFilter narrows the delivery log of a subscription. An empty status
+// lists deliveries in any status.
+type DeliveryFilter struct {
+	SubscriptionID int
+	Status         DeliveryStatus
+	Limit          int
+}
+
+func ParseDeliveryStatus(s string) (DeliveryStatus, error) {
+	switch st := DeliveryStatus(s); st {
+	case "", DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return st, nil
+	}
+	return "", ErrInvalidDeliveryStatus
+}
@@ -0,0 +1,182 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the layout of a rules file. It is read as YAML, so JSON files
+// work as well.
+type File struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+// ParseRules builds the rules of a rules file.
+func ParseRules(data []byte) ([]Rule, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %s", entity.ErrInvalidFraudRules, err)
+	}
+	rules := make([]Rule, 0, len(f.Rules))
+	names := map[string]bool{}
+	for _, cfg := range f.Rules {
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("%w: rule %s defined twice", entity.ErrInvalidFraudRules, cfg.Name)
+		}
+		names[cfg.Name] = true
+		rule, err := NewRule(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+type ruleSet struct {
+	rules   []Rule
+	modTime time.Time
+}
+
+// Engine screens transactions with the rules of a file, picking up changes
+// to it without a restart.
+type Engine struct {
+	repo  database.Repository
+	cl    clock.Clock
+	path  string
+	rules atomic.Pointer[ruleSet]
+}
+
+// NewEngine returns an engine with the rules in path, failing if they are
+// invalid.
+func NewEngine(repo database.Repository, cl clock.Clock, path string) (*Engine, error) {
+	e := &Engine{repo: repo, cl: cl, path: path}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload reads the rules file again. The current rules are kept if it is
+// invalid.
+func (e *Engine) Reload() error {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return err
+	}
+	e.rules.Store(&ruleSet{rules: rules, modTime: info.ModTime()})
+	return nil
+}
+
+// Watch reloads the rules whenever the file changes, checking every
+// interval until ctx is done.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(e.path)
+		if err != nil {
+			slog.ErrorContext(ctx, "error checking fraud rules", "path", e.path, "error", err)
+			continue
+		}
+		if info.ModTime().Equal(e.rules.Load().modTime) {
+			continue
+		}
+		if err := e.Reload(); err != nil {
+			slog.ErrorContext(ctx, "error reloading fraud rules, keeping the current ones", "path", e.path, "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "fraud rules reloaded", "path", e.path, "rules", len(e.Rules()))
+	}
+}
+
+// Rules returns the rules in use.
+func (e *Engine) Rules() []Rule {
+	return e.rules.Load().rules
+}
+
+// Screen checks tx against every rule and decides on the most severe action
+// of the rules it breaks. The decision is not stored.
+func (e *Engine) Screen(ctx context.Context, tx entity.Transaction) (entity.FraudDecision, error) {
+	decisions, err := e.ScreenBatch(ctx, []entity.Transaction{tx})
+	if err != nil {
+		return entity.FraudDecision{}, err
+	}
+	return decisions[0], nil
+}
+
+// ScreenBatch screens transactions created together, in order. Each one is
+// checked against the history of its account along with the transactions
+// before it in txs that were not denied. The decisions are not stored.
+func (e *Engine) ScreenBatch(ctx context.Context, txs []entity.Transaction) ([]entity.FraudDecision, error) {
+	now := e.cl.Now()
+	rules := e.Rules()
+	since := now
+	for _, rule := range rules {
+		if s := rule.Since(now); s.Before(since) {
+			since = s
+		}
+	}
+
+	histories := map[int][]entity.Transaction{}
+	decisions := make([]entity.FraudDecision, len(txs))
+	for i, tx := range txs {
+		history, ok := histories[tx.AccountID]
+		if !ok && since.Before(now) {
+			var err error
+			history, err = e.repo.RecentTransactions(ctx, tx.AccountID, since)
+			if err != nil {
+				return nil, err
+			}
+		}
+		decisions[i] = decide(rules, tx, history, now)
+		if decisions[i].Action != entity.FraudDeny {
+			history = append(history, tx)
+		}
+		histories[tx.AccountID] = history
+	}
+	return decisions, nil
+}
+
+func decide(rules []Rule, tx entity.Transaction, history []entity.Transaction, now time.Time) entity.FraudDecision {
+	decision := entity.FraudDecision{
+		AccountID:       tx.AccountID,
+		OperationTypeID: tx.OperationTypeID,
+		Amount:          tx.Amount,
+		Action:          entity.FraudAllow,
+		CreatedAt:       now,
+	}
+	for _, rule := range rules {
+		if rule.Action().Severity() <= decision.Action.Severity() {
+			continue
+		}
+		if reason := rule.Check(tx, history, now); reason != "" {
+			decision.Action = rule.Action()
+			decision.Rule = rule.Name()
+			decision.Reason = reason
+		}
+	}
+	return decision
+}
@@ -0,0 +1,205 @@
+// Package fraud screens incoming transactions with configurable rules
+// against the recent activity of their account.
+package fraud
+
+import (
+	"fmt"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rule checks a transaction against the account history. History holds the
+// transactions of the account since the time Since returns, and now is in
+// the configured time zone.
+type Rule interface {
+	Name() string
+	Action() entity.FraudAction
+	Since(now time.Time) time.Time
+	// Check returns why tx breaks the rule, or "" if it does not.
+	Check(tx entity.Transaction, history []entity.Transaction, now time.Time) string
+}
+
+// RuleConfig is a rule as written in the rules file. The fields a rule
+// takes depend on its type; OperationTypeID restricts any rule to one
+// operation type.
+type RuleConfig struct {
+	Name            string             `yaml:"name"`
+	Type            string             `yaml:"type"`
+	Action          entity.FraudAction `yaml:"action"`
+	OperationTypeID int                `yaml:"operation_type_id"`
+	MaxAmount       decimal.Decimal    `yaml:"max_amount"`
+	MaxCount        int                `yaml:"max_count"`
+	Window          time.Duration      `yaml:"window"`
+}
+
+// Factory builds a rule of a type from its configuration.
+type Factory func(cfg RuleConfig) (Rule, error)
+
+var factories = map[string]Factory{
+	"max_amount":  newMaxAmount,
+	"velocity":    newVelocity,
+	"daily_total": newDailyTotal,
+	"duplicate":   newDuplicate,
+}
+
+// Register makes a rule type available to rules files.
+func Register(ruleType string, f Factory) {
+	factories[ruleType] = f
+}
+
+// NewRule builds the rule described by cfg.
+func NewRule(cfg RuleConfig) (Rule, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("%w: rule without a name", entity.ErrInvalidFraudRules)
+	}
+	if cfg.Action != entity.FraudReview && cfg.Action != entity.FraudDeny {
+		return nil, fmt.Errorf("%w: rule %s: action must be review or deny", entity.ErrInvalidFraudRules, cfg.Name)
+	}
+	f, ok := factories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: rule %s: unknown type %q", entity.ErrInvalidFraudRules, cfg.Name, cfg.Type)
+	}
+	rule, err := f(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: rule %s: %s", entity.ErrInvalidFraudRules, cfg.Name, err)
+	}
+	return rule, nil
+}
+
+type base struct {
+	name   string
+	action entity.FraudAction
+	opType int
+}
+
+func (b base) Name() string               { return b.name }
+func (b base) Action() entity.FraudAction { return b.action }
+
+func (b base) applies(tx entity.Transaction) bool {
+	return b.opType == 0 || tx.OperationTypeID == b.opType
+}
+
+// matching returns the transactions of history the rule applies to, from
+// since on.
+func (b base) matching(history []entity.Transaction, since time.Time) []entity.Transaction {
+	var txs []entity.Transaction
+	for _, h := range history {
+		if b.applies(h) && !h.EventDate.Before(since) {
+			txs = append(txs, h)
+		}
+	}
+	return txs
+}
+
+func newBase(cfg RuleConfig) base {
+	return base{name: cfg.Name, action: cfg.Action, opType: cfg.OperationTypeID}
+}
+
+// maxAmount caps the amount of a single transaction.
+type maxAmount struct {
+	base
+	max decimal.Decimal
+}
+
+func newMaxAmount(cfg RuleConfig) (Rule, error) {
+	if !cfg.MaxAmount.IsPositive() {
+		return nil, fmt.Errorf("max_amount must be positive")
+	}
+	return maxAmount{base: newBase(cfg), max: cfg.MaxAmount}, nil
+}
+
+func (r maxAmount) Since(now time.Time) time.Time { return now }
+
+func (r maxAmount) Check(tx entity.Transaction, _ []entity.Transaction, _ time.Time) string {
+	if r.applies(tx) && tx.Amount.Abs().GreaterThan(r.max) {
+		return fmt.Sprintf("amount %s above %s", tx.Amount.Abs(), r.max)
+	}
+	return ""
+}
+
+// velocity caps how many transactions an account makes within a window.
+type velocity struct {
+	base
+	max    int
+	window time.Duration
+}
+
+func newVelocity(cfg RuleConfig) (Rule, error) {
+	if cfg.MaxCount <= 0 || cfg.Window <= 0 {
+		return nil, fmt.Errorf("max_count and window must be positive")
+	}
+	return velocity{base: newBase(cfg), max: cfg.MaxCount, window: cfg.Window}, nil
+}
+
+func (r velocity) Since(now time.Time) time.Time { return now.Add(-r.window) }
+
+func (r velocity) Check(tx entity.Transaction, history []entity.Transaction, now time.Time) string {
+	if !r.applies(tx) {
+		return ""
+	}
+	if n := len(r.matching(history, r.Since(now))) + 1; n > r.max {
+		return fmt.Sprintf("%d transactions within %s, above %d", n, r.window, r.max)
+	}
+	return ""
+}
+
+// dailyTotal caps the total amount of an account in a calendar day.
+type dailyTotal struct {
+	base
+	max decimal.Decimal
+}
+
+func newDailyTotal(cfg RuleConfig) (Rule, error) {
+	if !cfg.MaxAmount.IsPositive() {
+		return nil, fmt.Errorf("max_amount must be positive")
+	}
+	return dailyTotal{base: newBase(cfg), max: cfg.MaxAmount}, nil
+}
+
+func (r dailyTotal) Since(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+func (r dailyTotal) Check(tx entity.Transaction, history []entity.Transaction, now time.Time) string {
+	if !r.applies(tx) {
+		return ""
+	}
+	total := tx.Amount.Abs()
+	for _, h := range r.matching(history, r.Since(now)) {
+		total = total.Add(h.Amount.Abs())
+	}
+	if total.GreaterThan(r.max) {
+		return fmt.Sprintf("daily total %s above %s", total, r.max)
+	}
+	return ""
+}
+
+// duplicate catches the same amount posted again within a window.
+type duplicate struct {
+	base
+	window time.Duration
+}
+
+func newDuplicate(cfg RuleConfig) (Rule, error) {
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+	return duplicate{base: newBase(cfg), window: cfg.Window}, nil
+}
+
+func (r duplicate) Since(now time.Time) time.Time { return now.Add(-r.window) }
+
+func (r duplicate) Check(tx entity.Transaction, history []entity.Transaction, now time.Time) string {
+	if !r.applies(tx) {
+		return ""
+	}
+	for _, h := range r.matching(history, r.Since(now)) {
+		if h.OperationTypeID == tx.OperationTypeID && h.Amount.Equal(tx.Amount) {
+			return fmt.Sprintf("same amount as transaction %d within %s", h.ID, r.window)
+		}
+	}
+	return ""
+}
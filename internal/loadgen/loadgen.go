@@ -0,0 +1,427 @@
+// Package loadgen drives a configurable mix of API calls against a running
+// service, reports their latency and checks the resulting balances.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+	"transaction-routine/internal/client"
+	"transaction-routine/internal/entity"
+
+	"github.com/shopspring/decimal"
+)
+
+// Operations a mix can weigh.
+const (
+	OpTransaction = "transaction"
+	OpBalance     = "balance"
+	OpAccount     = "account"
+)
+
+var ErrInvalidMix = errors.New("invalid mix")
+
+// Mix weighs how often each operation runs.
+type Mix map[string]int
+
+// ParseMix parses weights such as "transaction=8,balance=1,account=1".
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not name=weight", ErrInvalidMix, part)
+		}
+		if name != OpTransaction && name != OpBalance && name != OpAccount {
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidMix, name)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("%w: invalid weight %q", ErrInvalidMix, weight)
+		}
+		mix[name] = w
+	}
+	if mix.total() == 0 {
+		return nil, fmt.Errorf("%w: every weight is zero", ErrInvalidMix)
+	}
+	return mix, nil
+}
+
+func (m Mix) total() int {
+	total := 0
+	for _, w := range m {
+		total += w
+	}
+	return total
+}
+
+func (m Mix) pick() string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	n := rand.IntN(m.total())
+	for _, name := range names {
+		if n < m[name] {
+			return name
+		}
+		n -= m[name]
+	}
+	return names[len(names)-1]
+}
+
+type Config struct {
+	Client client.Config
+	// Accounts is how many fixture accounts are created and used.
+	Accounts int
+	// Concurrency is how many requests may be in flight at once.
+	Concurrency int
+	// RPS is the target request rate. With 0 every worker sends its next
+	// request as soon as the previous one ends.
+	RPS float64
+	// The run stops after Duration or Requests, whichever comes first. Zero
+	// disables a limit.
+	Duration time.Duration
+	Requests int
+	Mix      Mix
+	// OperationTypes restricts the operation types of the transactions
+	// created. Empty means every type the API lists that clients may post.
+	OperationTypes []int
+}
+
+// Settings is the configuration of a run, without its credentials.
+type Settings struct {
+	URL            string  `json:"url"`
+	Accounts       int     `json:"accounts"`
+	Concurrency    int     `json:"concurrency"`
+	RPS            float64 `json:"rps"`
+	Duration       string  `json:"duration"`
+	Requests       int     `json:"requests"`
+	Mix            Mix     `json:"mix"`
+	OperationTypes []int   `json:"operation_types"`
+}
+
+// AccountCheck compares the balance the API reports for a fixture account
+// with the one expected from the transactions created.
+type AccountCheck struct {
+	AccountID int             `json:"account_id"`
+	Expected  decimal.Decimal `json:"expected"`
+	Actual    decimal.Decimal `json:"actual"`
+	// Skipped is set when some transaction may or may not have been
+	// created, e.g. after a timeout, so no balance can be expected.
+	Skipped bool `json:"skipped,omitempty"`
+	OK      bool `json:"ok"`
+}
+
+type BalanceCheck struct {
+	Passed   bool           `json:"passed"`
+	Accounts []AccountCheck `json:"accounts"`
+}
+
+// Result is written as JSON so runs can be compared.
+type Result struct {
+	StartedAt  time.Time          `json:"started_at"`
+	Elapsed    float64            `json:"elapsed_seconds"`
+	Settings   Settings           `json:"settings"`
+	Dropped    int                `json:"dropped"`
+	Total      Summary            `json:"total"`
+	Operations map[string]Summary `json:"operations"`
+	Balances   BalanceCheck       `json:"balance_check"`
+}
+
+// fixture is an account created for the run and the balance its
+// transactions should add up to.
+type fixture struct {
+	mu        sync.Mutex
+	id        int
+	expected  decimal.Decimal
+	uncertain bool
+}
+
+type generator struct {
+	cfg       Config
+	client    *client.Client
+	opTypes   entity.OperationType
+	opIDs     []int
+	fixtures  []*fixture
+	recorders map[string]*recorder
+	budget    atomic.Int64
+	dropped   atomic.Int64
+}
+
+// Run creates the fixture accounts, sends the configured load and checks the
+// balances of the fixtures. Cancelling ctx stops the load early; the balances
+// are still checked.
+func Run(ctx context.Context, cfg Config) (Result, error) {
+	if cfg.Accounts <= 0 || cfg.Concurrency <= 0 {
+		return Result{}, errors.New("accounts and concurrency must be positive")
+	}
+	if cfg.Duration <= 0 && cfg.Requests <= 0 {
+		return Result{}, errors.New("a duration or a number of requests is required")
+	}
+	g := &generator{
+		cfg:       cfg,
+		client:    client.New(cfg.Client),
+		recorders: map[string]*recorder{},
+	}
+	for name, weight := range cfg.Mix {
+		if weight > 0 {
+			g.recorders[name] = newRecorder()
+		}
+	}
+	if err := g.setup(ctx); err != nil {
+		return Result{}, err
+	}
+
+	started := time.Now()
+	g.load(ctx)
+	elapsed := time.Since(started)
+
+	result := Result{
+		StartedAt:  started,
+		Elapsed:    elapsed.Seconds(),
+		Settings:   g.settings(),
+		Dropped:    int(g.dropped.Load()),
+		Operations: map[string]Summary{},
+	}
+	var latencies []time.Duration
+	statuses, errs := map[string]int{}, 0
+	for name, r := range g.recorders {
+		result.Operations[name] = r.summary(elapsed)
+		latencies = append(latencies, r.latencies...)
+		for status, n := range r.statuses {
+			statuses[status] += n
+		}
+		errs += r.errors
+	}
+	result.Total = summarize(latencies, statuses, errs, elapsed)
+
+	// The load may have been interrupted, the check must still run.
+	checkCtx := context.WithoutCancel(ctx)
+	var err error
+	result.Balances, err = g.checkBalances(checkCtx)
+	return result, err
+}
+
+func (g *generator) settings() Settings {
+	duration := ""
+	if g.cfg.Duration > 0 {
+		duration = g.cfg.Duration.String()
+	}
+	return Settings{
+		URL:            g.cfg.Client.URL,
+		Accounts:       g.cfg.Accounts,
+		Concurrency:    g.cfg.Concurrency,
+		RPS:            g.cfg.RPS,
+		Duration:       duration,
+		Requests:       g.cfg.Requests,
+		Mix:            g.cfg.Mix,
+		OperationTypes: g.opIDs,
+	}
+}
+
+// setup loads the operation types and creates the fixture accounts, which
+// start with a zero balance so the tables need not be empty.
+func (g *generator) setup(ctx context.Context) error {
+	var err error
+	g.opTypes, err = g.client.ListOperationTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list operation types: %w", err)
+	}
+	g.opIDs = g.cfg.OperationTypes
+	if len(g.opIDs) == 0 {
+		for id, op := range g.opTypes {
+			if !op.System() {
+				g.opIDs = append(g.opIDs, id)
+			}
+		}
+		slices.Sort(g.opIDs)
+	}
+	for _, id := range g.opIDs {
+		if g.opTypes[id] == nil {
+			return fmt.Errorf("unknown operation type %d", id)
+		}
+		if g.opTypes[id].System() {
+			return fmt.Errorf("operation type %d is reserved for system postings", id)
+		}
+	}
+	if len(g.opIDs) == 0 {
+		return errors.New("no operation types to create transactions with")
+	}
+
+	for i := 0; i < g.cfg.Accounts; i++ {
+		acc, err := g.client.CreateAccount(ctx, fmt.Sprintf("%011d", rand.Int64N(1e11)))
+		if err != nil {
+			return fmt.Errorf("cannot create fixture account: %w", err)
+		}
+		g.fixtures = append(g.fixtures, &fixture{id: acc.ID})
+	}
+	return nil
+}
+
+// load sends requests until the duration or the request budget runs out.
+func (g *generator) load(ctx context.Context) {
+	runCtx := ctx
+	if g.cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, g.cfg.Duration)
+		defer cancel()
+	}
+	g.budget.Store(int64(g.cfg.Requests))
+
+	var wg sync.WaitGroup
+	if g.cfg.RPS <= 0 {
+		for i := 0; i < g.cfg.Concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for runCtx.Err() == nil && g.take() {
+					g.do(ctx)
+				}
+			}()
+		}
+		wg.Wait()
+		return
+	}
+
+	// Requests are scheduled at the target rate whether or not the previous
+	// ones are done; those finding every worker busy are dropped.
+	jobs := make(chan struct{}, g.cfg.Concurrency)
+	for i := 0; i < g.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				g.do(ctx)
+			}
+		}()
+	}
+	interval := time.Duration(float64(time.Second) / g.cfg.RPS)
+	start := time.Now()
+	for n := 0; g.take(); n++ {
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(n) * interval)))
+		select {
+		case <-runCtx.Done():
+			timer.Stop()
+			close(jobs)
+			wg.Wait()
+			return
+		case <-timer.C:
+		}
+		select {
+		case jobs <- struct{}{}:
+		default:
+			g.dropped.Add(1)
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// take reserves a request from the budget, which is unlimited when no number
+// of requests was configured.
+func (g *generator) take() bool {
+	if g.cfg.Requests <= 0 {
+		return true
+	}
+	return g.budget.Add(-1) >= 0
+}
+
+func (g *generator) do(ctx context.Context) {
+	op := g.cfg.Mix.pick()
+	f := g.fixtures[rand.IntN(len(g.fixtures))]
+	start := time.Now()
+	switch op {
+	case OpTransaction:
+		opTypeID := g.opIDs[rand.IntN(len(g.opIDs))]
+		amount := decimal.New(rand.Int64N(100000)+1, -2)
+		_, err := g.client.CreateTransaction(ctx, entity.Transaction{AccountID: f.id, OperationTypeID: opTypeID, Amount: amount})
+		g.recorders[op].record(time.Since(start), http.StatusCreated, err)
+		if !g.opTypes[opTypeID].PositiveAmount {
+			amount = amount.Neg()
+		}
+		f.mu.Lock()
+		switch {
+		case err == nil:
+			f.expected = f.expected.Add(amount)
+		case !certain(err):
+			f.uncertain = true
+		}
+		f.mu.Unlock()
+	case OpBalance:
+		_, err := g.client.GetBalance(ctx, f.id)
+		g.recorders[op].record(time.Since(start), http.StatusOK, err)
+	case OpAccount:
+		_, err := g.client.GetAccount(ctx, f.id)
+		g.recorders[op].record(time.Since(start), http.StatusOK, err)
+	}
+}
+
+func (g *generator) checkBalances(ctx context.Context) (BalanceCheck, error) {
+	check := BalanceCheck{Passed: true}
+	for _, f := range g.fixtures {
+		actual, err := g.client.GetBalance(ctx, f.id)
+		if err != nil {
+			return check, fmt.Errorf("cannot get balance of account %d: %w", f.id, err)
+		}
+		c := AccountCheck{AccountID: f.id, Expected: f.expected, Actual: actual, Skipped: f.uncertain}
+		c.OK = c.Skipped || actual.Equal(f.expected)
+		check.Passed = check.Passed && c.OK
+		check.Accounts = append(check.Accounts, c)
+	}
+	return check, nil
+}
+
+// WriteSummary prints the result as a table of latencies per operation
+// followed by the balance check.
+func (r Result) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tERRORS\tRPS\tP50 MS\tP90 MS\tP95 MS\tP99 MS\tMAX MS\t")
+	names := make([]string, 0, len(r.Operations))
+	for name := range r.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	row := func(name string, s Summary) {
+		l := s.Latency
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			name, s.Requests, s.Errors, s.RPS, l.P50, l.P90, l.P95, l.P99, l.Max)
+	}
+	for _, name := range names {
+		row(name, r.Operations[name])
+	}
+	row("total", r.Total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if r.Dropped > 0 {
+		fmt.Fprintf(w, "\n%d requests dropped: every worker was busy, raise -concurrency to reach the target rate\n", r.Dropped)
+	}
+	status := "passed"
+	if !r.Balances.Passed {
+		status = "FAILED"
+	}
+	fmt.Fprintf(w, "\nbalance check %s\n", status)
+	for _, c := range r.Balances.Accounts {
+		switch {
+		case c.Skipped:
+			fmt.Fprintf(w, "  account %d: skipped, some transactions have an unknown outcome\n", c.AccountID)
+		case !c.OK:
+			fmt.Fprintf(w, "  account %d: expected %s, got %s\n", c.AccountID, c.Expected, c.Actual)
+		}
+	}
+	return nil
+}
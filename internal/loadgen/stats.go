@@ -0,0 +1,116 @@
+package loadgen
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"transaction-routine/internal/client"
+)
+
+// Latency percentiles in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Summary is what was observed for one operation, or for all of them.
+type Summary struct {
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	RPS      float64        `json:"rps"`
+	Statuses map[string]int `json:"statuses"`
+	Latency  Latency        `json:"latency_ms"`
+}
+
+// recorder collects the latency and status of every request of an
+// operation.
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	statuses  map[string]int
+	errors    int
+}
+
+func newRecorder() *recorder {
+	return &recorder{statuses: map[string]int{}}
+}
+
+// record stores a request that took d and ended with err, where ok is the
+// status of a successful response.
+func (r *recorder) record(d time.Duration, ok int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, d)
+	if err != nil {
+		r.errors++
+	}
+	r.statuses[statusOf(ok, err)]++
+}
+
+func statusOf(ok int, err error) string {
+	var apiErr *client.APIError
+	switch {
+	case err == nil:
+		return strconv.Itoa(ok)
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.Status)
+	}
+	return "error"
+}
+
+func (r *recorder) summary(elapsed time.Duration) Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return summarize(r.latencies, r.statuses, r.errors, elapsed)
+}
+
+func summarize(latencies []time.Duration, statuses map[string]int, errs int, elapsed time.Duration) Summary {
+	s := Summary{Requests: len(latencies), Errors: errs, Statuses: statuses}
+	if elapsed > 0 {
+		s.RPS = float64(len(latencies)) / elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.Latency = Latency{
+		Min:  ms(sorted[0]),
+		Mean: ms(total / time.Duration(len(sorted))),
+		P50:  ms(percentile(sorted, 50)),
+		P90:  ms(percentile(sorted, 90)),
+		P95:  ms(percentile(sorted, 95)),
+		P99:  ms(percentile(sorted, 99)),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// certain reports whether a failed request surely did not change anything:
+// the API answered with a client error.
+func certain(err error) bool {
+	var apiErr *client.APIError
+	return errors.As(err, &apiErr) && apiErr.Status < http.StatusInternalServerError
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"transaction-routine/internal/auth"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const documentNumberKey = "document_number"
+
+// New builds a JSON logger writing to w. Records logged with a context carry
+// the request id, principal and trace id stored in it, and document numbers
+// are always masked.
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: maskAttr,
+	})
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+// MaskDocument hides all but the last four characters of a document number.
+func MaskDocument(doc string) string {
+	const visible = 4
+	if len(doc) <= visible {
+		return strings.Repeat("*", len(doc))
+	}
+	return strings.Repeat("*", len(doc)-visible) + doc[len(doc)-visible:]
+}
+
+func maskAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == documentNumberKey && a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, MaskDocument(a.Value.String()))
+	}
+	return a
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if p := auth.FromContext(ctx); p != nil {
+		r.AddAttrs(slog.String("principal", p.Subject))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
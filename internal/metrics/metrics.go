@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "transaction_routine"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	TransactionsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Number of transactions created by operation type.",
+	}, []string{"operation_type"})
+
+	ValidationRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_rejections_total",
+		Help:      "Number of requests rejected by validation, by error.",
+	}, []string{"error"})
+
+	ThrottledRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "throttled_requests_total",
+		Help:      "Number of requests rejected by rate limiting, by route and limit.",
+	}, []string{"route", "limit"})
+
+	OutboxEventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_published_total",
+		Help:      "Number of outbox events published, by event type.",
+	}, []string{"type"})
+
+	OutboxPublishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_failures_total",
+		Help:      "Number of failed attempts to publish an outbox event, by event type.",
+	}, []string{"type"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_attempts_total",
+		Help:      "Number of webhook delivery attempts, by event type and outcome (delivered, retry, dead).",
+	}, []string{"type", "outcome"})
+
+	ScheduleRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "schedule",
+		Name:      "runs_total",
+		Help:      "Number of scheduled transaction runs, by status (succeeded, failed).",
+	}, []string{"status"})
+
+	FraudDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fraud",
+		Name:      "decisions_total",
+		Help:      "Number of screened transactions, by action (allow, review, deny) and the rule behind it.",
+	}, []string{"action", "rule"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of repository calls by method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		TransactionsCreated,
+		ValidationRejections,
+		ThrottledRequests,
+		DBQueryDuration,
+		OutboxEventsPublished,
+		OutboxPublishFailures,
+		WebhookDeliveries,
+		ScheduleRuns,
+		FraudDecisions,
+	)
+}
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
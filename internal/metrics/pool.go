@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"transaction-routine/internal/entity"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredDesc = prometheus.NewDesc(namespace+"_db_pool_acquired_connections", "Number of connections currently in use.", nil, nil)
+	poolIdleDesc     = prometheus.NewDesc(namespace+"_db_pool_idle_connections", "Number of idle connections in the pool.", nil, nil)
+	poolTotalDesc    = prometheus.NewDesc(namespace+"_db_pool_total_connections", "Total number of connections in the pool.", nil, nil)
+	poolMaxDesc      = prometheus.NewDesc(namespace+"_db_pool_max_connections", "Maximum size of the pool.", nil, nil)
+)
+
+type poolCollector struct {
+	stat func() entity.PoolStats
+}
+
+// RegisterPool exposes the stats returned by stat as gauges, read on every scrape.
+func RegisterPool(stat func() entity.PoolStats) error {
+	return Registry.Register(&poolCollector{stat: stat})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(s.Acquired))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(s.Total))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(s.Max))
+}
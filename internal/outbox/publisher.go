@@ -0,0 +1,127 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_publisher.go -package=mocks -source=publisher.go
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+)
+
+const (
+	PublisherNone = "none"
+	PublisherFile = "file"
+	PublisherHTTP = "http"
+)
+
+// Publisher delivers events downstream. An error means the event was not
+// delivered and will be published again.
+type Publisher interface {
+	Publish(ctx context.Context, event entity.Event) error
+}
+
+// NewPublisher returns the publisher selected by OUTBOX_PUBLISHER, or nil
+// when events are only kept in the outbox. The closer, if any, must be
+// closed on shutdown.
+func NewPublisher(cfg *config.Config) (Publisher, io.Closer, error) {
+	switch cfg.OutboxPublisher {
+	case "", PublisherNone:
+		return nil, nil, nil
+	case PublisherFile:
+		f, err := os.OpenFile(cfg.OutboxFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewFilePublisher(f), f, nil
+	case PublisherHTTP:
+		if cfg.OutboxURL == "" {
+			return nil, nil, fmt.Errorf("OUTBOX_URL is required by the %s publisher", PublisherHTTP)
+		}
+		return NewHTTPPublisher(cfg.OutboxURL, cfg.OutboxTimeout), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unknown outbox publisher %q", cfg.OutboxPublisher)
+}
+
+// Fanout publishes every event to each of its publishers in turn. When one of
+// them fails the event is published again to all of them, so each one must
+// tolerate duplicates.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, event entity.Event) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilePublisher writes each event as a line of JSON.
+type FilePublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewFilePublisher(w io.Writer) *FilePublisher {
+	return &FilePublisher{w: w}
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event entity.Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	// The event only counts as delivered once it is on disk.
+	if f, ok := p.w.(*os.File); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// HTTPPublisher posts each event as JSON. Any status but 2xx is a failed
+// delivery.
+type HTTPPublisher struct {
+	url  string
+	http *http.Client
+}
+
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{url: url, http: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event entity.Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", string(event.Type))
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("publishing event %d: unexpected status %d", event.ID, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Package outbox relays the domain events written to the outbox table to a
+// Publisher.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+)
+
+var errAccountBlocked = errors.New("an earlier event of the account was not published")
+
+// Relay polls the outbox and publishes its events in order. Delivery is at
+// least once: an event is marked published only after the publisher accepts
+// it, so a crash in between publishes it again.
+type Relay struct {
+	repo      database.Repository
+	pub       Publisher
+	interval  time.Duration
+	gapWait   time.Duration
+	batchSize int
+}
+
+// NewRelay returns a relay polling every interval. A missing outbox id
+// holds the later events back for up to gapWait in case it commits late.
+func NewRelay(repo database.Repository, pub Publisher, interval, gapWait time.Duration, batchSize int) *Relay {
+	return &Relay{repo: repo, pub: pub, interval: interval, gapWait: gapWait, batchSize: batchSize}
+}
+
+// Run relays events until ctx is done. Full batches are followed right away
+// by the next one; otherwise it waits for the poll interval.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "error relaying outbox events", "error", err)
+		}
+		if err == nil && n == r.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// RelayOnce publishes a batch of events and returns how many were published.
+// Once an event of an account fails, the later events of that account are
+// held back so each account keeps its order; other accounts go on, however
+// many held events come before theirs. A transaction moved between accounts
+// holds back both of them.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	blocked := map[int]bool{}
+	return r.repo.RelayEvents(ctx, r.batchSize, r.gapWait, func(e entity.Event) error {
+		accounts := e.Accounts()
+		for _, account := range accounts {
+			if blocked[account] {
+				// The other accounts of the event must wait for it too.
+				for _, account := range accounts {
+					blocked[account] = true
+				}
+				return errAccountBlocked
+			}
+		}
+		if err := r.pub.Publish(ctx, e); err != nil {
+			for _, account := range accounts {
+				blocked[account] = true
+			}
+			metrics.OutboxPublishFailures.WithLabelValues(string(e.Type)).Inc()
+			slog.WarnContext(ctx, "error publishing event", "event_id", e.ID, "type", e.Type, "account_id", e.AccountID, "error", err)
+			return err
+		}
+		metrics.OutboxEventsPublished.WithLabelValues(string(e.Type)).Inc()
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+	"transaction-routine/internal/clock"
+)
+
+const sweepInterval = time.Minute
+
+// Result describes the state of a bucket after a request was checked.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed, when denied
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rule   Rule
+}
+
+// Limiter keeps one token bucket per key. Buckets idle long enough to be full
+// again are dropped periodically.
+type Limiter struct {
+	cl        clock.Clock
+	policies  Policies
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func New(cl clock.Clock, policies Policies) *Limiter {
+	return &Limiter{cl: cl, policies: policies, buckets: make(map[string]*bucket), lastSweep: cl.Now()}
+}
+
+// Policy returns the policy configured for route.
+func (l *Limiter) Policy(route string) Policy {
+	return l.policies.For(route)
+}
+
+// Allow takes a token from the bucket identified by key, created with rule
+// if it does not exist yet.
+func (l *Limiter) Allow(key string, rule Rule) Result {
+	return l.AllowEach(map[string]int{key: 1}, rule)
+}
+
+// Cost is the number of tokens to take from the bucket of Key, created with
+// Rule if it does not exist yet.
+type Cost struct {
+	Key    string
+	Rule   Rule
+	Tokens int
+}
+
+// AllowEach takes from the bucket of each key, created with rule if it does
+// not exist yet, the number of tokens the key maps to. Either every bucket
+// has enough tokens and they are all taken, or none is. The result is the one
+// of the bucket with the fewest tokens left when allowed, or of the one to
+// wait the longest for when denied.
+func (l *Limiter) AllowEach(costs map[string]int, rule Rule) Result {
+	all := make([]Cost, 0, len(costs))
+	for key, n := range costs {
+		all = append(all, Cost{Key: key, Rule: rule, Tokens: n})
+	}
+	results := l.Take(all)
+	if len(results) == 0 {
+		return Result{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit}
+	}
+	return results[Tightest(results)]
+}
+
+// Take takes every cost, whatever its rule, or none of them when any bucket
+// has too few tokens. It returns the result of each cost, in order.
+func (l *Limiter) Take(costs []Cost) []Result {
+	now := l.cl.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	allowed := true
+	buckets := make([]*bucket, len(costs))
+	for i, c := range costs {
+		b, ok := l.buckets[c.Key]
+		if !ok || b.rule != c.Rule {
+			b = &bucket{tokens: float64(c.Rule.Limit), last: now, rule: c.Rule}
+			l.buckets[c.Key] = b
+		}
+		b.refill(now)
+		buckets[i] = b
+		if b.tokens < float64(c.Tokens) {
+			allowed = false
+		}
+	}
+
+	results := make([]Result, len(costs))
+	for i, c := range costs {
+		b, n := buckets[i], float64(c.Tokens)
+		r := Result{Allowed: allowed, Limit: c.Rule.Limit}
+		if allowed {
+			b.tokens -= n
+		} else {
+			r.RetryAfter = b.timeFor(n - b.tokens)
+		}
+		r.Remaining = int(math.Floor(b.tokens))
+		r.Reset = b.timeFor(float64(c.Rule.Limit) - b.tokens)
+		results[i] = r
+	}
+	return results
+}
+
+// Tightest returns the index of the result with the fewest tokens left when
+// allowed, or of the one to wait the longest for when denied, or -1 when
+// there is none.
+func Tightest(results []Result) int {
+	best := -1
+	for i, r := range results {
+		if best < 0 || (r.Allowed && r.Remaining < results[best].Remaining) || (!r.Allowed && r.RetryAfter > results[best].RetryAfter) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.rule.Limit) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (b *bucket) rate() float64 {
+	return float64(b.rule.Limit) / b.rule.Period.Seconds()
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(float64(b.rule.Limit), b.tokens+elapsed*b.rate())
+	b.last = now
+}
+
+func (b *bucket) timeFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / b.rate() * float64(time.Second))
+}
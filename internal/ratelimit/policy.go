@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyClient  = "client"
+	KeyAccount = "account"
+
+	// DefaultRoute holds the policy applied to routes without their own entry.
+	DefaultRoute = "default"
+)
+
+// Rule allows Limit requests per Period, refilled continuously. It is also
+// the bucket capacity, so bursts of up to Limit requests are accepted.
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Policy holds the rules applied to a route, keyed by KeyClient or KeyAccount.
+type Policy map[string]Rule
+
+// Policies maps a route, written as "METHOD /pattern", to its policy.
+type Policies map[string]Policy
+
+// For returns the policy of route, falling back to the default policy.
+func (p Policies) For(route string) Policy {
+	if policy, ok := p[route]; ok {
+		return policy
+	}
+	return p[DefaultRoute]
+}
+
+// ParsePolicies reads policies written as
+//
+//	default=client:100/s;POST /transactions=client:20/s,account:5/s
+//
+// where the period is one of s, m or h.
+func ParsePolicies(s string) (Policies, error) {
+	policies := Policies{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, rules, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: missing '='", entry)
+		}
+		policy := Policy{}
+		for _, rule := range strings.Split(rules, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(rule), ":")
+			if !ok || (key != KeyClient && key != KeyAccount) {
+				return nil, fmt.Errorf("invalid rate limit rule %q", rule)
+			}
+			r, err := parseRule(value)
+			if err != nil {
+				return nil, err
+			}
+			policy[key] = r
+		}
+		policies[strings.TrimSpace(route)] = policy
+	}
+	return policies, nil
+}
+
+func parseRule(s string) (Rule, error) {
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate %q: expected limit/period", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+	var d time.Duration
+	switch period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		return Rule{}, fmt.Errorf("invalid rate period %q", period)
+	}
+	return Rule{Limit: n, Period: d}, nil
+}
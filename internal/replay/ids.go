@@ -0,0 +1,162 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const apiPrefix = "/v1"
+
+// fieldResources are the body fields and query parameters holding the id of
+// another resource.
+var fieldResources = map[string]string{
+	"account_id":        "accounts",
+	"card_token":        "cards",
+	"operation_type_id": "operation-types",
+	"transaction_id":    "transactions",
+}
+
+// segments splits the path of a request into its segments, without the API
+// version.
+func segments(path string) []string {
+	path = strings.TrimPrefix(path, apiPrefix)
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// learn maps the ids created by a recorded POST to the ones the target
+// created for the same request. The created resource is named by the last
+// segment of the path, as in /accounts/{id}/cards, and a card is also mapped
+// by its token, which later paths and card_token fields refer to.
+func (rp *Replayer) learn(path string, recorded, replayed []byte) {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segs := segments(path)
+	if len(segs) == 2 && segs[1] == "batch" {
+		var rec, rep struct {
+			Items []struct {
+				ID json.Number `json:"id"`
+			} `json:"items"`
+		}
+		if json.Unmarshal(recorded, &rec) != nil || json.Unmarshal(replayed, &rep) != nil {
+			return
+		}
+		for i := 0; i < len(rec.Items) && i < len(rep.Items); i++ {
+			rp.learnID(segs[0], rec.Items[i].ID.String(), rep.Items[i].ID.String())
+		}
+		return
+	}
+	resource := segs[len(segs)-1]
+	recID, recToken := idOf(recorded)
+	repID, repToken := idOf(replayed)
+	rp.learnID(resource, recID, repID)
+	rp.learnID(resource, recToken, repToken)
+}
+
+func (rp *Replayer) learnID(resource, from, to string) {
+	if from == "" || to == "" {
+		return
+	}
+	if rp.ids[resource] == nil {
+		rp.ids[resource] = map[string]string{}
+	}
+	rp.ids[resource][from] = to
+}
+
+func (rp *Replayer) lookup(resource, id string) (string, bool) {
+	to, ok := rp.ids[resource][id]
+	return to, ok
+}
+
+func idOf(body []byte) (id, token string) {
+	var v struct {
+		ID    json.Number `json:"id"`
+		Token string      `json:"token"`
+	}
+	if json.Unmarshal(body, &v) != nil {
+		return "", ""
+	}
+	return v.ID.String(), v.Token
+}
+
+// rewritePath replaces the recorded ids in the path segments and query
+// parameters with the ones learned so far.
+func (rp *Replayer) rewritePath(path string) string {
+	rawQuery := ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+	parts := strings.Split(path, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if to, ok := rp.lookup(parts[i], parts[i+1]); ok {
+			parts[i+1] = to
+		}
+	}
+	path = strings.Join(parts, "/")
+	if rawQuery == "" {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path + "?" + rawQuery
+	}
+	for field, resource := range fieldResources {
+		if to, ok := rp.lookup(resource, query.Get(field)); ok {
+			query.Set(field, to)
+		}
+	}
+	return path + "?" + query.Encode()
+}
+
+// rewriteBody replaces the recorded ids referenced by the body with the ones
+// learned so far. The body is returned untouched when nothing changes.
+func (rp *Replayer) rewriteBody(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if !rp.rewriteValue(v) {
+		return body, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (rp *Replayer) rewriteValue(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			switch f := field.(type) {
+			case json.Number:
+				if to, ok := rp.lookup(fieldResources[k], f.String()); ok {
+					v[k] = json.Number(to)
+					changed = true
+				}
+				continue
+			case string:
+				if to, ok := rp.lookup(fieldResources[k], f); ok {
+					v[k] = to
+					changed = true
+				}
+				continue
+			}
+			changed = rp.rewriteValue(field) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = rp.rewriteValue(item) || changed
+		}
+	}
+	return changed
+}
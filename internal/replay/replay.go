@@ -0,0 +1,185 @@
+// Package replay sends HTTP traffic recorded as JSON lines to another
+// deployment of the API and reports where its responses differ.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Record is one line of a capture: a request and the status it got.
+type Record struct {
+	Time     time.Time         `json:"time"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Header   map[string]string `json:"header,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	Status   int               `json:"status"`
+	Response json.RawMessage   `json:"response,omitempty"`
+}
+
+type Config struct {
+	URL     string
+	APIKey  string
+	Token   string
+	Timeout time.Duration
+	// Speed scales the delays between records: 1 keeps the original timing,
+	// 2 replays twice as fast and 0 sends every request right away.
+	Speed float64
+}
+
+// Result is the outcome of replaying one record.
+type Result struct {
+	Line     int    `json:"line"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Recorded int    `json:"recorded_status"`
+	Replayed int    `json:"replayed_status,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report summarises a replay. Diffs holds the records whose status differs
+// from the recording or which could not be sent.
+type Report struct {
+	Total   int      `json:"total"`
+	Matched int      `json:"matched"`
+	Diffs   []Result `json:"diffs"`
+}
+
+type Replayer struct {
+	cfg  Config
+	http *http.Client
+	// ids maps the ids seen in the recording to the ones the target returned,
+	// per resource.
+	ids map[string]map[string]string
+}
+
+func New(cfg Config) *Replayer {
+	return &Replayer{
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.Timeout},
+		ids:  map[string]map[string]string{},
+	}
+}
+
+// Run replays every record read from r in order. It stops early only when
+// the capture is malformed or ctx is done.
+func (rp *Replayer) Run(ctx context.Context, r io.Reader) (Report, error) {
+	report := Report{Diffs: []Result{}}
+	var first time.Time
+	start := time.Now()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Method == "" || rec.Path == "" {
+			return report, fmt.Errorf("line %d: method and path are required", line)
+		}
+
+		if !rec.Time.IsZero() {
+			if first.IsZero() {
+				first = rec.Time
+			}
+			if err := rp.wait(ctx, start, rec.Time.Sub(first)); err != nil {
+				return report, err
+			}
+		}
+
+		result := rp.send(ctx, line, rec)
+		report.Total++
+		if result.Error == "" && (rec.Status == 0 || rec.Status == result.Replayed) {
+			report.Matched++
+		} else {
+			report.Diffs = append(report.Diffs, result)
+		}
+	}
+	return report, scanner.Err()
+}
+
+// wait sleeps until offset, scaled by the speed, has passed since start.
+func (rp *Replayer) wait(ctx context.Context, start time.Time, offset time.Duration) error {
+	if rp.cfg.Speed <= 0 {
+		return ctx.Err()
+	}
+	d := time.Until(start.Add(time.Duration(float64(offset) / rp.cfg.Speed)))
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (rp *Replayer) send(ctx context.Context, line int, rec Record) Result {
+	path := rp.rewritePath(rec.Path)
+	result := Result{Line: line, Method: rec.Method, Path: path, Recorded: rec.Status}
+
+	var body io.Reader
+	if len(rec.Body) > 0 {
+		b, err := rp.rewriteBody(rec.Body)
+		if err != nil {
+			result.Error = fmt.Sprintf("invalid body: %s", err)
+			return result
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, rec.Method, strings.TrimRight(rp.cfg.URL, "/")+path, body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for k, v := range rec.Header {
+		req.Header.Set(k, v)
+	}
+	// Recorded credentials belong to the recorded environment.
+	req.Header.Del("Authorization")
+	req.Header.Del("X-API-Key")
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if rp.cfg.APIKey != "" {
+		req.Header.Set("X-API-Key", rp.cfg.APIKey)
+	}
+	if rp.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+rp.cfg.Token)
+	}
+
+	resp, err := rp.http.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.Replayed = resp.StatusCode
+
+	if rec.Method == http.MethodPost && isSuccess(rec.Status) && isSuccess(resp.StatusCode) {
+		replayed, err := io.ReadAll(resp.Body)
+		if err == nil {
+			rp.learn(rec.Path, rec.Response, replayed)
+		}
+	}
+	return result
+}
+
+func isSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"transaction-routine/internal/service"
+)
+
+// DisputeWorker moves on the disputes whose deadlines passed. Several
+// instances can run it: a dispute moved by one of them is skipped by the
+// others.
+type DisputeWorker struct {
+	svc      service.DisputeService
+	interval time.Duration
+}
+
+func NewDisputeWorker(svc service.DisputeService, interval time.Duration) *DisputeWorker {
+	return &DisputeWorker{svc: svc, interval: interval}
+}
+
+// Run enforces the dispute deadlines every interval until ctx is done.
+func (w *DisputeWorker) Run(ctx context.Context) {
+	for {
+		n, err := w.svc.EnforceDeadlines(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "error enforcing dispute deadlines", "error", err)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "disputes moved past their deadlines", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
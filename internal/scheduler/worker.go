@@ -0,0 +1,130 @@
+// Package scheduler posts the transactions of due schedules, and of
+// disputes past their deadlines.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/service"
+)
+
+type Config struct {
+	Interval   time.Duration
+	BatchSize  int
+	RunTimeout time.Duration
+}
+
+// Worker claims due schedule runs and posts their transactions. Each
+// transaction is created along with the outcome of its run, so a run is
+// posted exactly once: runs left running by an instance that stopped are
+// posted again, and only one of the attempts can succeed.
+type Worker struct {
+	repo  database.Repository
+	txsvc service.TransactionService
+	cl    clock.Clock
+	cfg   Config
+}
+
+func NewWorker(repo database.Repository, txsvc service.TransactionService, cl clock.Clock, cfg Config) *Worker {
+	return &Worker{repo: repo, txsvc: txsvc, cl: cl, cfg: cfg}
+}
+
+// Run posts due schedules until ctx is done, waiting for the poll interval
+// whenever a batch is not full.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		n, err := w.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "error running schedules", "error", err)
+		}
+		if err == nil && n == w.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.cfg.Interval):
+		}
+	}
+}
+
+// RunOnce posts a batch of runs, resuming interrupted ones before claiming
+// due ones, and returns how many it got. Schedules that fell behind catch up
+// one run per batch. Once ctx is done, the runs not posted yet are left for
+// a later batch.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	now := w.cl.Now()
+	// Twice the run timeout leaves a live instance time to record its
+	// outcome.
+	runs, err := w.repo.ReclaimScheduleRuns(ctx, now.Add(-2*w.cfg.RunTimeout), now, w.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(runs) > 0 {
+		slog.WarnContext(ctx, "resuming interrupted schedule runs", "count", len(runs))
+	}
+	if len(runs) < w.cfg.BatchSize {
+		claimed, err := w.repo.ClaimScheduleRuns(ctx, now, w.cfg.BatchSize-len(runs))
+		if err != nil {
+			return 0, err
+		}
+		runs = append(runs, claimed...)
+	}
+
+	for _, run := range runs {
+		if ctx.Err() != nil {
+			return len(runs), ctx.Err()
+		}
+		if err := w.post(ctx, run); err != nil {
+			return len(runs), err
+		}
+	}
+	return len(runs), nil
+}
+
+// post creates the transaction of run, or records why it failed. A run
+// finished meanwhile by another instance is skipped.
+func (w *Worker) post(ctx context.Context, run entity.ScheduleRun) error {
+	// A post under way is seen through on shutdown, within the run timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.cfg.RunTimeout)
+	defer cancel()
+
+	err := w.repo.StartScheduleRun(ctx, run.ID, w.cl.Now())
+	if errors.Is(err, entity.ErrRunNotRunning) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := w.txsvc.CreateTransaction(ctx, run.Transaction())
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "scheduled transaction posted", "schedule_id", run.ScheduleID, "due_at", run.DueAt, "transaction_id", tx.ID)
+		metrics.ScheduleRuns.WithLabelValues(string(entity.RunSucceeded)).Inc()
+		return nil
+	case errors.Is(err, entity.ErrRunNotRunning):
+		slog.InfoContext(ctx, "schedule run already finished", "schedule_id", run.ScheduleID, "due_at", run.DueAt)
+		return nil
+	}
+
+	finished := w.cl.Now()
+	run.Status = entity.RunFailed
+	run.Error = err.Error()
+	run.FinishedAt = &finished
+	if err := w.repo.FinishScheduleRun(ctx, run); err != nil {
+		if errors.Is(err, entity.ErrRunNotRunning) {
+			return nil
+		}
+		return err
+	}
+	slog.WarnContext(ctx, "scheduled transaction failed", "schedule_id", run.ScheduleID, "due_at", run.DueAt, "error", err)
+	metrics.ScheduleRuns.WithLabelValues(string(entity.RunFailed)).Inc()
+	return nil
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"transaction-routine/internal/entity"
+)
+
+const ndjsonMIME = "application/x-ndjson"
+
+var (
+	errEmptyBatch    = errors.New("batch is empty")
+	errBatchTooLarge = errors.New("batch has too many items")
+	errNotJSONArray  = errors.New("request body must be a JSON array")
+)
+
+type batchKey struct{}
+
+// readBatch decodes the items of a batch once, for the rate limits and the
+// handler, and answers the requests whose body is not a batch.
+func readBatch(maxItems int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			items, err := decodeBatch(r, maxItems)
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				switch {
+				case errors.As(err, &maxErr):
+					writeTooLarge(w, maxErr.Limit)
+				case errors.Is(err, errBatchTooLarge):
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					_, _ = w.Write(fmtResponse(fmt.Sprintf("%s, the limit is %d", err, maxItems)))
+				default:
+					writeFieldErrors(w, []fieldError{decodeError(err)})
+				}
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), batchKey{}, items)))
+		})
+	}
+}
+
+// batchItems returns the items decoded by readBatch.
+func batchItems(r *http.Request) []entity.BatchItem {
+	items, _ := r.Context().Value(batchKey{}).([]entity.BatchItem)
+	return items
+}
+
+func (s *Server) createTransactionBatchHandler(w http.ResponseWriter, r *http.Request) {
+	mode, err := entity.ParseBatchMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse(fmt.Sprintf("%s: must be %s or %s", err, entity.BatchAllOrNothing, entity.BatchBestEffort)))
+		return
+	}
+
+	result, err := s.txsvc.CreateTransactions(r.Context(), batchItems(r), mode)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create transactions"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case result.Failed == 0:
+		w.WriteHeader(http.StatusCreated)
+	case result.Created == 0:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	jsonResp, _ := json.Marshal(result)
+	_, _ = w.Write(jsonResp)
+}
+
+// decodeBatch reads the items of a batch, either a JSON array or one JSON
+// object per line. Items that cannot be decoded are kept with their error so
+// they are reported along with the others.
+func decodeBatch(r *http.Request, maxItems int) ([]entity.BatchItem, error) {
+	var raws []json.RawMessage
+	var err error
+	if mediaType(r) == ndjsonMIME {
+		raws, err = readNDJSON(r.Body, maxItems)
+	} else {
+		raws, err = readJSONArray(r.Body, maxItems)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(raws) == 0 {
+		return nil, errEmptyBatch
+	}
+
+	items := make([]entity.BatchItem, len(raws))
+	for i, raw := range raws {
+		items[i].Index = i
+		var req createTransactionRequest
+		if err := decodeStrict(bytes.NewReader(raw), &req); err != nil {
+			items[i].Err = itemError(err)
+			continue
+		}
+		items[i].Transaction = req.transaction()
+	}
+	return items, nil
+}
+
+func readJSONArray(body io.Reader, maxItems int) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errNotJSONArray
+	}
+	var raws []json.RawMessage
+	for dec.More() {
+		if len(raws) == maxItems {
+			return nil, errBatchTooLarge
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errTrailingData
+		}
+		return nil, err
+	}
+	return raws, nil
+}
+
+// readNDJSON splits the body in lines, skipping blank ones. Lines are not
+// parsed here, so a malformed line only fails its own item.
+func readNDJSON(body io.Reader, maxItems int) ([]json.RawMessage, error) {
+	reader := bufio.NewReader(body)
+	var raws []json.RawMessage
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if len(raws) == maxItems {
+				return nil, errBatchTooLarge
+			}
+			raws = append(raws, line)
+		}
+		if err == io.EOF {
+			return raws, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func itemError(err error) error {
+	fe := decodeError(err)
+	if fe.Field == "" {
+		return errors.New(fe.Message)
+	}
+	return &entity.FieldError{Field: fe.Field, Err: errors.New(fe.Message)}
+}
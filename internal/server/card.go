@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) issueCardHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := cardAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := s.cardsvc.IssueCard(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrAccountNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write(fmtResponse(err.Error()))
+		case errors.Is(err, entity.ErrCardsDisabled):
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write(fmtResponse(err.Error()))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write(fmtResponse("failed to issue card"))
+		}
+		return
+	}
+
+	jsonResp, _ := json.Marshal(c)
+	w.Header().Set("Location", fmt.Sprintf("%s/cards/%s", apiPrefix, c.Token))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listCardsHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := cardAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	cards, err := s.cardsvc.ListCards(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, entity.ErrAccountNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list cards"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(cards)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getCardHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := s.cardsvc.GetCard(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get card"))
+		return
+	}
+	if c == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrCardNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(c)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) setCardStatusHandler(status entity.CardStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.cardsvc.SetCardStatus(r.Context(), chi.URLParam(r, "token"), status)
+		if err != nil {
+			if errors.Is(err, entity.ErrCardNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write(fmtResponse(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write(fmtResponse("failed to update card"))
+			return
+		}
+
+		jsonResp, _ := json.Marshal(c)
+		_, _ = w.Write(jsonResp)
+	}
+}
+
+func cardAccountID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid account id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) createCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	var req createCustomerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := s.custsvc.CreateCustomer(r.Context(), req.customer())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		if errors.Is(err, entity.ErrCustomerExists) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create customer"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(c)
+	w.Header().Set("Location", fmt.Sprintf("%s/customers/%d", apiPrefix, c.ID))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := customerID(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := s.custsvc.GetCustomer(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get customer"))
+		return
+	}
+	if c == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrCustomerNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(c)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) openCustomerAccountHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := customerID(w, r)
+	if !ok {
+		return
+	}
+	var req openAccountRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	acc, err := s.accsvc.CreateAccount(r.Context(), req.account(id))
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		if errors.Is(err, entity.ErrCustomerNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create account"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(acc)
+	w.Header().Set("Location", fmt.Sprintf("%s/accounts/%d", apiPrefix, acc.ID))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getCustomerBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := customerID(w, r)
+	if !ok {
+		return
+	}
+
+	balances, err := s.custsvc.GetCustomerBalances(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get customer balances"))
+		return
+	}
+	if balances == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrCustomerNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(balances)
+	_, _ = w.Write(jsonResp)
+}
+
+func customerID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid customer id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"transaction-routine/internal/entity"
+)
+
+const (
+	maxBodyBytes   = 1 << 20
+	jsonMIME       = "application/json"
+	mergePatchMIME = "application/merge-patch+json"
+)
+
+// fieldError is a single invalid field reported back to the client.
+type fieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func fmtFieldErrors(msg string, errs []fieldError) []byte {
+	resp, _ := json.Marshal(struct {
+		Message string       `json:"message"`
+		Errors  []fieldError `json:"errors"`
+	}{Message: msg, Errors: errs})
+	return resp
+}
+
+func writeFieldErrors(w http.ResponseWriter, errs []fieldError) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write(fmtFieldErrors("invalid request body", errs))
+}
+
+// entityFieldErrors converts the field errors returned by entity validation,
+// reporting false when err holds none.
+func entityFieldErrors(err error) ([]fieldError, bool) {
+	var errs []fieldError
+	for _, fe := range entity.FieldErrors(err) {
+		errs = append(errs, fieldError{Field: fe.Field, Message: fe.Err.Error()})
+	}
+	return errs, len(errs) > 0
+}
+
+// limitBody caps the size of request bodies at maxBytes and requires writes
+// to use a media type the OpenAPI spec declares for the route, answering 413
+// and 415 before anything else reads the body.
+func limitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				writeTooLarge(w, maxBytes)
+				return
+			}
+			if accepted := acceptedMediaTypes(r); accepted != nil && !slices.Contains(accepted, mediaType(r)) {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				_, _ = w.Write(fmtResponse("content type must be one of: " + strings.Join(accepted, ", ")))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeTooLarge(w http.ResponseWriter, limit int64) {
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_, _ = w.Write(fmtResponse(fmt.Sprintf("request body exceeds %d bytes", limit)))
+}
+
+// acceptedMediaTypes lists the media types the spec declares for the body of
+// the matched operation, or nil when it takes no body.
+func acceptedMediaTypes(r *http.Request) []string {
+	_, op := specOperation(r)
+	if op == nil || op.RequestBody == nil {
+		return nil
+	}
+	accepted := make([]string, 0, len(op.RequestBody.Value.Content))
+	for mediaType := range op.RequestBody.Value.Content {
+		accepted = append(accepted, mediaType)
+	}
+	slices.Sort(accepted)
+	return accepted
+}
+
+func mediaType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// decodeJSON decodes a single JSON value from the request body into dst,
+// rejecting unknown fields and trailing data. It writes the error response
+// itself and reports whether the handler may continue.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := decodeStrict(r.Body, dst)
+	if err == nil {
+		return true
+	}
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeTooLarge(w, maxErr.Limit)
+		return false
+	}
+	writeFieldErrors(w, []fieldError{decodeError(err)})
+	return false
+}
+
+func decodeStrict(body io.Reader, dst any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errTrailingData
+		}
+		return err
+	}
+	return nil
+}
+
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+func decodeError(err error) fieldError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fieldError{Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.As(err, &typeErr):
+		return fieldError{Field: typeErr.Field, Message: fmt.Sprintf("must be of type %s", typeErr.Type)}
+	case errors.Is(err, io.EOF):
+		return fieldError{Message: "request body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fieldError{Message: "malformed JSON"}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fieldError{Field: field, Message: "unknown field"}
+	}
+	return fieldError{Message: err.Error()}
+}
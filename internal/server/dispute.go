@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) openDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	var req openDisputeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	d, err := s.dispsvc.OpenDispute(r.Context(), req.dispute())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		writeDisputeError(w, "failed to open dispute", err)
+		return
+	}
+
+	jsonResp, _ := json.Marshal(d)
+	w.Header().Set("Location", fmt.Sprintf("%s/disputes/%d", apiPrefix, d.ID))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listDisputesHandler(w http.ResponseWriter, r *http.Request) {
+	var filter entity.DisputeFilter
+	query := r.URL.Query()
+	if value := query.Get("account_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid account_id"))
+			return
+		}
+		filter.AccountID = &id
+	}
+	if value := query.Get("status"); value != "" {
+		filter.Status = entity.DisputeStatus(value)
+		if !filter.Status.Valid() {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid status"))
+			return
+		}
+	}
+	if value := query.Get("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid overdue"))
+			return
+		}
+		filter.Overdue = overdue
+	}
+
+	disputes, err := s.dispsvc.ListDisputes(r.Context(), filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list disputes"))
+		return
+	}
+	if disputes == nil {
+		disputes = []entity.Dispute{}
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.Dispute{"disputes": disputes})
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := disputeID(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := s.dispsvc.GetDispute(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get dispute"))
+		return
+	}
+	if d == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrDisputeNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(d)
+	_, _ = w.Write(jsonResp)
+}
+
+// transitionDisputeHandler moves a dispute to status.
+func (s *Server) transitionDisputeHandler(status entity.DisputeStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := disputeID(w, r)
+		if !ok {
+			return
+		}
+
+		d, err := s.dispsvc.TransitionDispute(r.Context(), id, status)
+		if err != nil {
+			writeDisputeError(w, "failed to move dispute", err)
+			return
+		}
+
+		jsonResp, _ := json.Marshal(d)
+		_, _ = w.Write(jsonResp)
+	}
+}
+
+func writeDisputeError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrDisputeNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(err.Error()))
+	case errors.Is(err, entity.ErrAlreadyDisputed), errors.Is(err, entity.ErrInvalidDisputeTransition):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write(fmtResponse(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse(msg))
+	}
+}
+
+func disputeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid dispute id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+)
+
+func (s *Server) listFraudDecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	filter := entity.FraudDecisionFilter{Limit: entity.DefaultFraudDecisionLimit}
+	query := r.URL.Query()
+	if value := query.Get("account_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid account_id"))
+			return
+		}
+		filter.AccountID = &id
+	}
+	if value := query.Get("action"); value != "" {
+		filter.Action = entity.FraudAction(value)
+		switch filter.Action {
+		case entity.FraudAllow, entity.FraudReview, entity.FraudDeny:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid action"))
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > entity.MaxFraudDecisionLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	decisions, err := s.txsvc.ListFraudDecisions(r.Context(), filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list fraud decisions"))
+		return
+	}
+	if decisions == nil {
+		decisions = []entity.FraudDecision{}
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.FraudDecision{"decisions": decisions})
+	_, _ = w.Write(jsonResp)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"transaction-routine/internal/entity"
+)
+
+func (s *Server) createFXRateHandler(w http.ResponseWriter, r *http.Request) {
+	var req createFXRateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	rate, err := s.fxsvc.CreateRate(r.Context(), req.rate())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		if errors.Is(err, entity.ErrFXRateExists) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create fx rate"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(rate)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listFXRatesHandler(w http.ResponseWriter, r *http.Request) {
+	var filter entity.FXRateFilter
+	query := r.URL.Query()
+	for param, currency := range map[string]*entity.Currency{"base": &filter.Base, "quote": &filter.Quote} {
+		if value := query.Get(param); value != "" {
+			*currency = entity.Currency(value)
+			if !currency.Valid() {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write(fmtResponse("invalid " + param))
+				return
+			}
+		}
+	}
+
+	rates, err := s.fxsvc.ListRates(r.Context(), filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list fx rates"))
+		return
+	}
+	if rates == nil {
+		rates = []entity.FXRate{}
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.FXRate{"rates": rates})
+	_, _ = w.Write(jsonResp)
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/logger"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/ratelimit"
+	"transaction-routine/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	apiKeyHeader    = "X-API-Key"
+
+	maxPeekBody = 1 << 20
+)
+
+var errMissingCredentials = errors.New("missing credentials")
+
+// anonymous is the principal of every request when authentication is disabled.
+var anonymous = &auth.Principal{Subject: "anonymous", Scopes: []string{auth.ScopeAdmin}}
+
+// requestID propagates the caller's X-Request-ID, or generates a new one, and
+// stores it in the request context so every log line can be correlated.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		slog.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", ww.Status(),
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// instrument records request latency labeled by the matched route pattern,
+// so paths such as /accounts/1 and /accounts/2 share the same series.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(r.Method, route, strconv.Itoa(ww.Status())).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// traceRequest starts the server span for the request, continuing the trace
+// received in the W3C traceparent header when present.
+func traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if route := chi.RouteContext(r.Context()).RoutePattern(); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(ww.Status()))
+		if ww.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(ww.Status()))
+		}
+	})
+}
+
+// authenticate resolves the principal of the request from a bearer JWT or an
+// X-API-Key header and stores it in the request context.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.AuthDisabled {
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), anonymous)))
+			return
+		}
+
+		var p *auth.Principal
+		var err error
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			p, err = s.authsvc.AuthenticateToken(r.Context(), token)
+		} else if key := r.Header.Get(apiKeyHeader); key != "" {
+			p, err = s.authsvc.AuthenticateAPIKey(r.Context(), key)
+		} else {
+			err = errMissingCredentials
+		}
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrInvalidAPIKey) || errors.Is(err, errMissingCredentials) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="transaction-routine"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write(fmtResponse(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write(fmtResponse("failed to authenticate request"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// requireScope rejects requests whose principal was not granted scope.
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := auth.FromContext(r.Context())
+			if p == nil || !p.HasScope(scope) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write(fmtResponse(fmt.Sprintf("missing required scope %s", scope)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// accountExtractor returns the account targeted by a request, if any.
+type accountExtractor func(r *http.Request) (string, bool)
+
+func accountFromURL(r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	return id, id != ""
+}
+
+func accountFromQuery(r *http.Request) (string, bool) {
+	id := r.URL.Query().Get("account_id")
+	return id, id != ""
+}
+
+// accountFromBody peeks at the account_id of a JSON body, leaving the body
+// intact for the handler.
+func accountFromBody(r *http.Request) (string, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return "", false
+	}
+	var req struct {
+		AccountID json.Number `json:"account_id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.AccountID == "" {
+		return "", false
+	}
+	return req.AccountID.String(), true
+}
+
+// accountsFromBatch counts the items of each account in a batch decoded by
+// readBatch. Items naming a card instead of an account, or that cannot be
+// decoded, are not counted; the handler reports the latter.
+func accountsFromBatch(r *http.Request) map[string]int {
+	counts := map[string]int{}
+	for _, item := range batchItems(r) {
+		if item.Err == nil && item.Transaction.AccountID > 0 {
+			counts[strconv.Itoa(item.Transaction.AccountID)]++
+		}
+	}
+	return counts
+}
+
+// rateLimit applies the client and account rules configured for the route,
+// answering 429 once any of their buckets is empty.
+func (s *Server) rateLimit(account accountExtractor) func(http.Handler) http.Handler {
+	return s.limitRequests(func(r *http.Request) map[string]int {
+		if account == nil {
+			return nil
+		}
+		if id, ok := account(r); ok {
+			return map[string]int{id: 1}
+		}
+		return nil
+	})
+}
+
+// rateLimitBatch applies the rules of the route to a batch read by
+// readBatch, taking from the bucket of each account one token per item of the
+// account. The batch is throttled as a whole when any account has fewer
+// tokens left, and refused when any account has more items than its rule
+// allows in a period, since waiting would not help.
+func (s *Server) rateLimitBatch() func(http.Handler) http.Handler {
+	return s.limitRequests(accountsFromBatch)
+}
+
+// limitRequests takes a token from the client bucket of the route and, from
+// the bucket of each account returned by accounts, the tokens it maps to.
+// Either every bucket has enough tokens and they are all taken, or the
+// request is throttled and none is.
+func (s *Server) limitRequests(accounts func(r *http.Request) map[string]int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			route := r.Method + " " + routePattern(r)
+			policy := s.limiter.Policy(route)
+
+			var costs []ratelimit.Cost
+			var kinds []string
+			if rule, ok := policy[ratelimit.KeyClient]; ok {
+				costs = append(costs, ratelimit.Cost{Key: ratelimit.KeyClient + "|" + route + "|" + clientID(r), Rule: rule, Tokens: 1})
+				kinds = append(kinds, ratelimit.KeyClient)
+			}
+			if rule, ok := policy[ratelimit.KeyAccount]; ok {
+				for id, n := range accounts(r) {
+					if n > rule.Limit {
+						w.WriteHeader(http.StatusRequestEntityTooLarge)
+						_, _ = w.Write(fmtResponse(fmt.Sprintf("batch has %d items of account %s, the limit is %d per %s", n, id, rule.Limit, rule.Period)))
+						return
+					}
+					costs = append(costs, ratelimit.Cost{Key: ratelimit.KeyAccount + "|" + route + "|" + id, Rule: rule, Tokens: n})
+					kinds = append(kinds, ratelimit.KeyAccount)
+				}
+			}
+
+			// Tokens are only taken when every bucket allows the request.
+			results := s.limiter.Take(costs)
+			if i := ratelimit.Tightest(results); i >= 0 {
+				res := results[i]
+				setRateLimitHeaders(w, res)
+				if !res.Allowed {
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+					metrics.ThrottledRequests.WithLabelValues(route, kinds[i]).Inc()
+					slog.WarnContext(r.Context(), "request throttled", "route", route, "limit", kinds[i])
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write(fmtResponse("rate limit exceeded"))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientID identifies the caller, falling back to its address when the
+// request is anonymous.
+func clientID(r *http.Request) string {
+	if p := auth.FromContext(r.Context()); p != nil && p != anonymous {
+		return p.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/chi/v5"
+)
+
+const apiPrefix = "/v1"
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var openAPISpec = mustLoadSpec(openAPIDocument)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(mergePatchMIME, openapi3filter.JSONBodyDecoder)
+}
+
+func mustLoadSpec(data []byte) *openapi3.T {
+	spec, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		panic(fmt.Sprintf("cannot load openapi spec: %s", err))
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		panic(fmt.Sprintf("invalid openapi spec: %s", err))
+	}
+	return spec
+}
+
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+// routePattern returns the matched route pattern without the API version
+// prefix, so versioned routes and their deprecated aliases share it.
+func routePattern(r *http.Request) string {
+	return strings.TrimPrefix(chi.RouteContext(r.Context()).RoutePattern(), apiPrefix)
+}
+
+// specOperation returns the spec path and operation matching the request, if
+// the spec documents it.
+func specOperation(r *http.Request) (string, *openapi3.Operation) {
+	path := routePattern(r)
+	pathItem := openAPISpec.Paths.Find(path)
+	if pathItem == nil {
+		return path, nil
+	}
+	return path, pathItem.GetOperation(r.Method)
+}
+
+// validateRequest rejects request bodies that do not match the schema the
+// OpenAPI spec declares for the route, before they reach the handlers.
+func validateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, op := specOperation(r)
+		if op == nil || op.RequestBody == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request: r,
+			Route: &routers.Route{
+				Spec:      openAPISpec,
+				Path:      path,
+				PathItem:  openAPISpec.Paths.Find(path),
+				Method:    r.Method,
+				Operation: op,
+			},
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				MultiError:         true,
+			},
+		}
+		if err := openapi3filter.ValidateRequestBody(r.Context(), input, op.RequestBody.Value); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeTooLarge(w, maxErr.Limit)
+				return
+			}
+			writeFieldErrors(w, schemaFieldErrors(err))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// propertyName extracts the property named by the reason of required and
+// additionalProperties schema errors, which may be reported on the parent.
+var propertyName = regexp.MustCompile(`^property "(.+)" is`)
+
+// schemaFieldErrors flattens the errors of a request body validation into
+// one entry per invalid field.
+func schemaFieldErrors(err error) []fieldError {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		// Keep the first error of each field, as alternatives such as oneOf
+		// report one per branch.
+		var errs []fieldError
+		seen := map[string]bool{}
+		for _, e := range multi {
+			for _, fe := range schemaFieldErrors(e) {
+				if !seen[fe.Field] {
+					seen[fe.Field] = true
+					errs = append(errs, fe)
+				}
+			}
+		}
+		return errs
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		pointer := schemaErr.JSONPointer()
+		if m := propertyName.FindStringSubmatch(schemaErr.Reason); m != nil && (len(pointer) == 0 || pointer[len(pointer)-1] != m[1]) {
+			pointer = append(pointer, m[1])
+		}
+		return []fieldError{{Field: strings.Join(pointer, "."), Message: schemaErr.Reason}}
+	}
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Err == nil {
+			return []fieldError{{Message: reqErr.Reason}}
+		}
+		if errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired) {
+			return []fieldError{{Message: "request body is empty"}}
+		}
+		return []fieldError{{Message: reqErr.Err.Error()}}
+	}
+	return []fieldError{{Message: err.Error()}}
+}
+
+// deprecated marks responses of the unversioned aliases, pointing clients to
+// the versioned route.
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, apiPrefix, r.URL.Path))
+		next.ServeHTTP(w, r)
+	})
+}
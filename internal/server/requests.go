@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/shopspring/decimal"
+)
+
+// Request bodies accepted by the API. They only hold the fields clients may
+// set, so decodeJSON rejects anything else, such as ids or server dates.
+
+type createAccountRequest struct {
+	DocumentNumber string          `json:"document_number"`
+	Currency       entity.Currency `json:"currency"`
+}
+
+func (req createAccountRequest) account() entity.Account {
+	return entity.Account{DocumentNumber: req.DocumentNumber, Currency: req.Currency}
+}
+
+type createCustomerRequest struct {
+	DocumentNumber string           `json:"document_number"`
+	Name           string           `json:"name"`
+	KYCStatus      entity.KYCStatus `json:"kyc_status"`
+	Email          string           `json:"email"`
+	Phone          string           `json:"phone"`
+}
+
+func (req createCustomerRequest) customer() entity.Customer {
+	return entity.Customer{
+		DocumentNumber: req.DocumentNumber,
+		Name:           req.Name,
+		KYCStatus:      req.KYCStatus,
+		Email:          req.Email,
+		Phone:          req.Phone,
+	}
+}
+
+// openAccountRequest opens an account for the customer in the URL, who
+// provides the document number.
+type openAccountRequest struct {
+	Currency entity.Currency `json:"currency"`
+}
+
+func (req openAccountRequest) account(customerID int) entity.Account {
+	return entity.Account{CustomerID: customerID, Currency: req.Currency}
+}
+
+// createTransactionRequest takes the amount in currency, which defaults to
+// the currency of the account. The account may be given by the token of one
+// of its cards instead.
+type createTransactionRequest struct {
+	AccountID       int             `json:"account_id"`
+	CardToken       string          `json:"card_token"`
+	OperationTypeID int             `json:"operation_type_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        entity.Currency `json:"currency"`
+}
+
+func (req createTransactionRequest) transaction() entity.Transaction {
+	return entity.Transaction{
+		AccountID:        req.AccountID,
+		CardToken:        req.CardToken,
+		OperationTypeID:  req.OperationTypeID,
+		Amount:           req.Amount,
+		OriginalCurrency: req.Currency,
+	}
+}
+
+type updateTransactionRequest struct {
+	AccountID       int             `json:"account_id"`
+	OperationTypeID int             `json:"operation_type_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        entity.Currency `json:"currency"`
+	EventDate       time.Time       `json:"event_date"`
+}
+
+func (req updateTransactionRequest) transaction(id int) entity.Transaction {
+	return entity.Transaction{
+		ID:               id,
+		AccountID:        req.AccountID,
+		OperationTypeID:  req.OperationTypeID,
+		Amount:           req.Amount,
+		OriginalCurrency: req.Currency,
+		EventDate:        req.EventDate,
+	}
+}
+
+// patchTransactionRequest keeps each member as raw JSON so an explicit null,
+// which clears the field, can be told apart from an omitted member. A
+// cleared currency is the account currency, as when PUT omits it.
+type patchTransactionRequest struct {
+	AccountID       json.RawMessage `json:"account_id,omitempty"`
+	OperationTypeID json.RawMessage `json:"operation_type_id,omitempty"`
+	Amount          json.RawMessage `json:"amount,omitempty"`
+	Currency        json.RawMessage `json:"currency,omitempty"`
+	EventDate       json.RawMessage `json:"event_date,omitempty"`
+}
+
+// patch returns the merge patch of the transaction as entered, which keeps
+// the currency in original_currency.
+func (req patchTransactionRequest) patch() []byte {
+	patch, _ := json.Marshal(struct {
+		AccountID        json.RawMessage `json:"account_id,omitempty"`
+		OperationTypeID  json.RawMessage `json:"operation_type_id,omitempty"`
+		Amount           json.RawMessage `json:"amount,omitempty"`
+		OriginalCurrency json.RawMessage `json:"original_currency,omitempty"`
+		EventDate        json.RawMessage `json:"event_date,omitempty"`
+	}{req.AccountID, req.OperationTypeID, req.Amount, req.Currency, req.EventDate})
+	return patch
+}
+
+type createOperationTypeRequest struct {
+	Description    string `json:"description"`
+	PositiveAmount bool   `json:"positive_amount"`
+}
+
+func (req createOperationTypeRequest) operation() entity.Operation {
+	return entity.Operation{Description: req.Description, PositiveAmount: req.PositiveAmount}
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (req createAPIKeyRequest) apiKey() entity.APIKey {
+	return entity.APIKey{Name: req.Name, Scopes: req.Scopes}
+}
+
+// reconcileRequest takes the balances of a previous report as the closing
+// balances to check.
+type reconcileRequest struct {
+	Closing *entity.ClosingBalances `json:"closing"`
+	Apply   bool                    `json:"apply"`
+}
+
+func (req reconcileRequest) reconciliation() entity.ReconciliationRequest {
+	return entity.ReconciliationRequest{Closing: req.Closing, Apply: req.Apply}
+}
+
+type createWebhookRequest struct {
+	URL        string             `json:"url"`
+	EventTypes []entity.EventType `json:"event_types"`
+	AccountIDs []int              `json:"account_ids"`
+}
+
+func (req createWebhookRequest) subscription() entity.WebhookSubscription {
+	return entity.WebhookSubscription{URL: req.URL, EventTypes: req.EventTypes, AccountIDs: req.AccountIDs}
+}
+
+type createScheduleRequest struct {
+	AccountID       int               `json:"account_id"`
+	OperationTypeID int               `json:"operation_type_id"`
+	Amount          decimal.Decimal   `json:"amount"`
+	Recurrence      entity.Recurrence `json:"recurrence"`
+	Day             *int              `json:"day"`
+	StartAt         time.Time         `json:"start_at"`
+	EndAt           *time.Time        `json:"end_at"`
+}
+
+func (req createScheduleRequest) schedule() entity.Schedule {
+	return entity.Schedule{
+		AccountID:       req.AccountID,
+		OperationTypeID: req.OperationTypeID,
+		Amount:          req.Amount,
+		Recurrence:      req.Recurrence,
+		Day:             req.Day,
+		StartAt:         req.StartAt,
+		EndAt:           req.EndAt,
+	}
+}
+
+type openDisputeRequest struct {
+	TransactionID int             `json:"transaction_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Reason        string          `json:"reason"`
+}
+
+func (req openDisputeRequest) dispute() entity.Dispute {
+	return entity.Dispute{TransactionID: req.TransactionID, Amount: req.Amount, Reason: req.Reason}
+}
+
+type createFXRateRequest struct {
+	Base        entity.Currency `json:"base"`
+	Quote       entity.Currency `json:"quote"`
+	Rate        decimal.Decimal `json:"rate"`
+	EffectiveAt time.Time       `json:"effective_at"`
+}
+
+func (req createFXRateRequest) rate() entity.FXRate {
+	return entity.FXRate{Base: req.Base, Quote: req.Quote, Rate: req.Rate, EffectiveAt: req.EffectiveAt}
+}
@@ -6,53 +6,161 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"transaction-routine/internal/auth"
 	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/shopspring/decimal"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(requestID)
+	r.Use(traceRequest)
+	r.Use(accessLog)
+	r.Use(instrument)
 
-	r.Get("/health", s.healthHandler)
+	r.Get("/livez", s.livenessHandler)
+	r.Get("/readyz", s.readinessHandler)
+	r.Get("/health", s.readinessHandler)
+	r.With(s.authenticate, requireScope(auth.ScopeMetrics)).Handle("/metrics", metrics.Handler())
 
-	r.Route("/accounts", func(r chi.Router) {
-		r.Get("/{id}", s.getAccountHandler)
-		r.Post("/", s.createAccountHandler)
-		r.Get("/{id}/balance", s.getAccountBalanceHandler)
+	r.Route(apiPrefix, func(r chi.Router) {
+		r.Get("/openapi.json", s.openAPIHandler)
+		s.registerAPIRoutes(r)
 	})
-
-	r.Route("/transactions", func(r chi.Router) {
-		r.Post("/", s.createTransactionHandler)
-		r.Put("/{id}", s.updateTransactionHandler)
+	// Unversioned aliases kept for clients that predate /v1.
+	r.Group(func(r chi.Router) {
+		r.Use(deprecated)
+		s.registerAPIRoutes(r)
 	})
 	return r
 }
 
-func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	if isHealthy := s.healthsvc.HealthCheck(r.Context()); isHealthy {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(fmtResponse("service is healthy"))
+func (s *Server) registerAPIRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+
+		r.Route("/accounts", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeAccountsRead, accountFromURL)...).Get("/{id}", s.getAccountHandler)
+			r.With(s.endpoint(auth.ScopeAccountsWrite, nil)...).Post("/", s.createAccountHandler)
+			r.With(s.endpoint(auth.ScopeAccountsRead, accountFromURL)...).Get("/{id}/balance", s.getAccountBalanceHandler)
+			r.With(s.endpoint(auth.ScopeAccountsRead, accountFromURL)...).Get("/{id}/events", s.accountEventsHandler)
+			r.With(s.endpoint(auth.ScopeAccountsWrite, accountFromURL)...).Post("/{id}/cards", s.issueCardHandler)
+			r.With(s.endpoint(auth.ScopeAccountsRead, accountFromURL)...).Get("/{id}/cards", s.listCardsHandler)
+		})
+
+		r.Route("/cards", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeAccountsRead, nil)...).Get("/{token}", s.getCardHandler)
+			r.With(s.endpoint(auth.ScopeAccountsWrite, nil)...).Post("/{token}/block", s.setCardStatusHandler(entity.CardBlocked))
+			r.With(s.endpoint(auth.ScopeAccountsWrite, nil)...).Post("/{token}/unblock", s.setCardStatusHandler(entity.CardActive))
+		})
+
+		r.Route("/customers", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeAccountsWrite, nil)...).Post("/", s.createCustomerHandler)
+			r.With(s.endpoint(auth.ScopeAccountsRead, nil)...).Get("/{id}", s.getCustomerHandler)
+			r.With(s.endpoint(auth.ScopeAccountsWrite, nil)...).Post("/{id}/accounts", s.openCustomerAccountHandler)
+			r.With(s.endpoint(auth.ScopeAccountsRead, nil)...).Get("/{id}/balances", s.getCustomerBalancesHandler)
+		})
+
+		r.Route("/transactions", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeTransactionsRead, accountFromQuery)...).Get("/", s.listTransactionsHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, accountFromBody)...).Post("/", s.createTransactionHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, accountFromBody)...).Put("/{id}", s.updateTransactionHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, accountFromBody)...).Patch("/{id}", s.patchTransactionHandler)
+			// Batch items are validated one by one by the handler, so the
+			// body is not checked against the spec as a whole.
+			r.With(requireScope(auth.ScopeTransactionsWrite), limitBody(s.cfg.BatchMaxBytes), readBatch(s.cfg.BatchMaxItems), s.rateLimitBatch()).
+				Post("/batch", s.createTransactionBatchHandler)
+		})
+
+		r.Route("/schedules", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, accountFromBody)...).Post("/", s.createScheduleHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsRead, accountFromQuery)...).Get("/", s.listSchedulesHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsRead, nil)...).Get("/{id}", s.getScheduleHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Delete("/{id}", s.cancelScheduleHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsRead, nil)...).Get("/{id}/runs", s.listScheduleRunsHandler)
+		})
+
+		r.Route("/disputes", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Post("/", s.openDisputeHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsRead, accountFromQuery)...).Get("/", s.listDisputesHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsRead, nil)...).Get("/{id}", s.getDisputeHandler)
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Post("/{id}/provisional-credit", s.transitionDisputeHandler(entity.DisputeProvisionalCredit))
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Post("/{id}/win", s.transitionDisputeHandler(entity.DisputeWon))
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Post("/{id}/lose", s.transitionDisputeHandler(entity.DisputeLost))
+			r.With(s.endpoint(auth.ScopeTransactionsWrite, nil)...).Post("/{id}/withdraw", s.transitionDisputeHandler(entity.DisputeWithdrawn))
+		})
+
+		r.With(s.endpoint(auth.ScopeAdmin, accountFromQuery)...).Get("/fraud-decisions", s.listFraudDecisionsHandler)
+
+		r.Route("/fx-rates", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeTransactionsRead, nil)...).Get("/", s.listFXRatesHandler)
+			r.With(s.endpoint(auth.ScopeAdmin, nil)...).Post("/", s.createFXRateHandler)
+		})
+
+		r.Route("/operation-types", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeTransactionsRead, nil)...).Get("/", s.listOperationTypesHandler)
+			r.With(s.endpoint(auth.ScopeAdmin, nil)...).Post("/", s.createOperationTypeHandler)
+		})
+
+		r.Route("/reconciliations", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeAdmin, nil)...).Post("/", s.reconcileHandler)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Post("/", s.createWebhookHandler)
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Get("/", s.listWebhooksHandler)
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Get("/{id}", s.getWebhookHandler)
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Delete("/{id}", s.deleteWebhookHandler)
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Get("/{id}/deliveries", s.listDeliveriesHandler)
+			r.With(s.endpoint(auth.ScopeWebhooks, nil)...).Post("/{id}/deliveries/{deliveryID}/replay", s.replayDeliveryHandler)
+		})
+
+		r.Route("/api-keys", func(r chi.Router) {
+			r.With(s.endpoint(auth.ScopeAdmin, nil)...).Post("/", s.createAPIKeyHandler)
+		})
+	})
+}
+
+// endpoint returns the middlewares every API route runs once matched: the
+// scope it requires, the body size and type limits, its rate limits and the
+// validation of its body.
+func (s *Server) endpoint(scope string, account accountExtractor) chi.Middlewares {
+	return chi.Middlewares{requireScope(scope), limitBody(maxBodyBytes), s.rateLimit(account), validateRequest}
+}
+
+func (s *Server) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jsonResp, _ := json.Marshal(entity.HealthReport{Status: entity.HealthStatusUp})
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.healthsvc.Readiness(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if !report.IsUp() {
+		w.WriteHeader(http.StatusServiceUnavailable)
 	}
-	w.WriteHeader(http.StatusInternalServerError)
-	_, _ = w.Write(fmtResponse("service is unhealthy"))
+	jsonResp, _ := json.Marshal(report)
+	_, _ = w.Write(jsonResp)
 }
 
 func (s *Server) createAccountHandler(w http.ResponseWriter, r *http.Request) {
-	var req entity.Account
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(fmtResponse(err.Error()))
+	var req createAccountRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if err := s.accsvc.CreateAccount(r.Context(), req); err != nil {
+	acc, err := s.accsvc.CreateAccount(r.Context(), req.account())
+	if err != nil {
 		if errors.Is(err, entity.ErrMissingDocumentNumber) {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write(fmtResponse(err.Error()))
+			writeFieldErrors(w, []fieldError{{Field: "document_number", Message: err.Error()}})
+			return
+		}
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
 			return
 		}
 		w.WriteHeader(http.StatusInternalServerError)
@@ -60,7 +168,10 @@ func (s *Server) createAccountHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	jsonResp, _ := json.Marshal(acc)
+	w.Header().Set("Location", fmt.Sprintf("%s/accounts/%d", apiPrefix, acc.ID))
 	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
 }
 
 func (s *Server) getAccountHandler(w http.ResponseWriter, r *http.Request) {
@@ -107,6 +218,17 @@ func (s *Server) getAccountBalanceHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	acc, err := s.accsvc.GetAccountByID(r.Context(), numid)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get account"))
+		return
+	}
+	if acc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse("account not found"))
+		return
+	}
 	balance, err := s.accsvc.GetAccountBalance(r.Context(), numid)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -114,26 +236,70 @@ func (s *Server) getAccountBalanceHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	jsonResp, _ := json.Marshal(map[string]decimal.Decimal{"balance": balance})
+	jsonResp, _ := json.Marshal(struct {
+		Balance  decimal.Decimal `json:"balance"`
+		Currency entity.Currency `json:"currency"`
+	}{balance, acc.Currency})
 	_, _ = w.Write(jsonResp)
 }
 
 func (s *Server) createTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	var req entity.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(fmtResponse(err.Error()))
+	var req createTransactionRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if err := s.txsvc.CreateTransaction(r.Context(), req); err != nil {
+	tx, err := s.txsvc.CreateTransaction(r.Context(), req.transaction())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		if errors.Is(err, entity.ErrTransactionDenied) || errors.Is(err, entity.ErrCardBlocked) || errors.Is(err, entity.ErrCardExpired) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		msg := fmt.Sprintf("failed to create transaction: %s", err.Error())
 		_, _ = w.Write(fmtResponse(msg))
 		return
 	}
 
+	jsonResp, _ := json.Marshal(tx)
+	w.Header().Set("Location", fmt.Sprintf("%s/transactions/%d", apiPrefix, tx.ID))
 	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	var filter entity.TransactionFilter
+	for param, dst := range map[string]**int{
+		"account_id":        &filter.AccountID,
+		"operation_type_id": &filter.OperationTypeID,
+	} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid " + param))
+			return
+		}
+		*dst = &id
+	}
+
+	txs, err := s.txsvc.ListTransactions(r.Context(), filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list transactions"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.Transaction{"transactions": txs})
+	_, _ = w.Write(jsonResp)
 }
 
 func (s *Server) updateTransactionHandler(w http.ResponseWriter, r *http.Request) {
@@ -150,20 +316,140 @@ func (s *Server) updateTransactionHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var req entity.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var req updateTransactionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := s.txsvc.UpdateTransaction(r.Context(), req.transaction(numid)); err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		s.writeTransactionError(w, "failed to update transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) patchTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("missing transaction id"))
+		return
+	}
+	numid, err := strconv.Atoi(id)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid transaction id"))
+		return
+	}
+
+	var req patchTransactionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := s.txsvc.PatchTransaction(r.Context(), numid, req.patch()); err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		s.writeTransactionError(w, "failed to patch transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeTransactionError answers the errors shared by the transaction write
+// handlers that are not field validation errors.
+func (s *Server) writeTransactionError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrTransactionNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(err.Error()))
+	case errors.Is(err, entity.ErrInvalidPatch):
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = w.Write(fmtResponse(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse(fmt.Sprintf("%s: %s", msg, err.Error())))
+	}
+}
+
+func (s *Server) listOperationTypesHandler(w http.ResponseWriter, r *http.Request) {
+	opTypes, err := s.opsvc.GetAllOperationTypes(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list operation types"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(opTypes)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) createOperationTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var req createOperationTypeRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	req.ID = numid
-	if err := s.txsvc.UpdateTransaction(r.Context(), req); err != nil {
+	if err := s.opsvc.CreateOperationType(r.Context(), req.operation()); err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
-		msg := fmt.Sprintf("failed to update transaction: %s", err.Error())
-		_, _ = w.Write(fmtResponse(msg))
+		_, _ = w.Write(fmtResponse("failed to create operation type"))
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) reconcileHandler(w http.ResponseWriter, r *http.Request) {
+	var req reconcileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	report, err := s.txsvc.ReconcileBalances(r.Context(), req.reconciliation())
+	if err != nil {
+		if errors.Is(err, entity.ErrAdjustmentTypesMissing) || errors.Is(err, entity.ErrAlreadyAdjusted) {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write(fmtResponse(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to reconcile balances"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(report)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	key, err := s.authsvc.CreateAPIKey(r.Context(), req.apiKey())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create api key"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(map[string]string{"name": req.Name, "key": key})
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
 }
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) createScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var req createScheduleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sch, err := s.schedsvc.CreateSchedule(r.Context(), req.schedule())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create schedule"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(sch)
+	w.Header().Set("Location", fmt.Sprintf("%s/schedules/%d", apiPrefix, sch.ID))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	var filter entity.ScheduleFilter
+	if value := r.URL.Query().Get("account_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid account_id"))
+			return
+		}
+		filter.AccountID = &id
+	}
+
+	schedules, err := s.schedsvc.ListSchedules(r.Context(), filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list schedules"))
+		return
+	}
+	if schedules == nil {
+		schedules = []entity.Schedule{}
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.Schedule{"schedules": schedules})
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	sch, err := s.schedsvc.GetSchedule(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get schedule"))
+		return
+	}
+	if sch == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrScheduleNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(sch)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) cancelScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.schedsvc.CancelSchedule(r.Context(), id); err != nil {
+		writeScheduleError(w, "failed to cancel schedule", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listScheduleRunsHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	runs, err := s.schedsvc.ListRuns(r.Context(), id)
+	if err != nil {
+		writeScheduleError(w, "failed to list schedule runs", err)
+		return
+	}
+	if runs == nil {
+		runs = []entity.ScheduleRun{}
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.ScheduleRun{"runs": runs})
+	_, _ = w.Write(jsonResp)
+}
+
+func writeScheduleError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, entity.ErrScheduleNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(err.Error()))
+	case errors.Is(err, entity.ErrScheduleNotActive):
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write(fmtResponse(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse(msg))
+	}
+}
+
+func scheduleID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid schedule id"))
+		return 0, false
+	}
+	return id, true
+}
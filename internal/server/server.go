@@ -10,33 +10,64 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 
 	"transaction-routine/internal/config"
+	"transaction-routine/internal/ratelimit"
 	"transaction-routine/internal/service"
+	"transaction-routine/internal/stream"
 )
 
 type Server struct {
 	port      int
 	cfg       *config.Config
+	limiter   *ratelimit.Limiter
+	authsvc   service.AuthService
 	healthsvc service.HealthService
 	accsvc    service.AccountService
 	opsvc     service.OpTypeService
 	txsvc     service.TransactionService
+	whsvc     service.WebhookService
+	schedsvc  service.ScheduleService
+	dispsvc   service.DisputeService
+	fxsvc     service.FXService
+	custsvc   service.CustomerService
+	cardsvc   service.CardService
+	hub       *stream.Hub
 }
 
-func NewServer(
-	ctx context.Context,
-	cfg *config.Config,
-	healthSvc service.HealthService,
-	accSvc service.AccountService,
-	opSvc service.OpTypeService,
-	tSvc service.TransactionService,
-) *http.Server {
+// Deps are the services the server hands requests to. Those left nil are
+// not used, which suits tests exercising a few routes.
+type Deps struct {
+	Limiter      *ratelimit.Limiter
+	Auth         service.AuthService
+	Health       service.HealthService
+	Accounts     service.AccountService
+	OpTypes      service.OpTypeService
+	Transactions service.TransactionService
+	Webhooks     service.WebhookService
+	Schedules    service.ScheduleService
+	Disputes     service.DisputeService
+	FX           service.FXService
+	Customers    service.CustomerService
+	Cards        service.CardService
+	Hub          *stream.Hub
+}
+
+func NewServer(ctx context.Context, cfg *config.Config, deps Deps) *http.Server {
 	NewServer := &Server{
 		port:      cfg.Port,
 		cfg:       cfg,
-		healthsvc: healthSvc,
-		accsvc:    accSvc,
-		opsvc:     opSvc,
-		txsvc:     tSvc,
+		limiter:   deps.Limiter,
+		authsvc:   deps.Auth,
+		healthsvc: deps.Health,
+		accsvc:    deps.Accounts,
+		opsvc:     deps.OpTypes,
+		txsvc:     deps.Transactions,
+		whsvc:     deps.Webhooks,
+		schedsvc:  deps.Schedules,
+		dispsvc:   deps.Disputes,
+		fxsvc:     deps.FX,
+		custsvc:   deps.Customers,
+		cardsvc:   deps.Cards,
+		hub:       deps.Hub,
 	}
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
@@ -45,6 +76,11 @@ func NewServer(
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
+	// Event streams never go idle, so they are ended for Shutdown to
+	// complete.
+	if deps.Hub != nil {
+		server.RegisterOnShutdown(deps.Hub.Close)
+	}
 	return server
 }
 
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/stream"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	// balanceEvent follows every live transaction event with the balance of
+	// the account. It carries no id, so resuming clients go on from the
+	// transaction event.
+	balanceEvent = "balance"
+	// streamRetry is how long clients wait before reconnecting.
+	streamRetry = 3 * time.Second
+)
+
+// accountEventsHandler streams the events of an account as server-sent
+// events, resuming after the Last-Event-ID header (or the last_event_id
+// query parameter, for clients unable to set headers) when given.
+func (s *Server) accountEventsHandler(w http.ResponseWriter, r *http.Request) {
+	numid, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid account id"))
+		return
+	}
+	lastID := r.Header.Get(lastEventIDHeader)
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastID != "" {
+		after, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil || after < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid last event id"))
+			return
+		}
+	}
+
+	acc, err := s.accsvc.GetAccountByID(r.Context(), numid)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get account"))
+		return
+	}
+	if acc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse("account not found"))
+		return
+	}
+
+	if s.hub == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write(fmtResponse("event stream unavailable"))
+		return
+	}
+	sub, err := s.hub.Subscribe(numid, after)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(streamRetry.Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write(fmtResponse(err.Error()))
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// The server write timeout would cut the stream.
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if rc.Flush() != nil {
+		return
+	}
+
+	for {
+		ctx, cancel := context.WithTimeout(r.Context(), s.cfg.StreamHeartbeat)
+		e, err := sub.Next(ctx)
+		cancel()
+		switch {
+		case err == nil:
+			if !s.writeAccountEvent(w, r, numid, e, !sub.Replayed(e)) {
+				return
+			}
+		case errors.Is(err, context.DeadlineExceeded) && r.Context().Err() == nil:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		default:
+			if r.Context().Err() == nil && !errors.Is(err, stream.ErrClosed) {
+				slog.WarnContext(r.Context(), "account event stream ended", "account_id", numid, "error", err)
+			}
+			return
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeAccountEvent writes e and, for live transaction events, the balance
+// of the account after it. Replayed events get no balance, since the current
+// one would include the events after them. It reports false when the stream
+// must end.
+func (s *Server) writeAccountEvent(w http.ResponseWriter, r *http.Request, accountID int, e entity.Event, live bool) bool {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	if !live || e.Type == entity.EventAccountCreated {
+		return true
+	}
+	balance, err := s.accsvc.GetAccountBalance(r.Context(), accountID)
+	if err != nil {
+		return false
+	}
+	data, _ = json.Marshal(struct {
+		AccountID int             `json:"account_id"`
+		Balance   decimal.Decimal `json:"balance"`
+	}{accountID, balance})
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", balanceEvent, data)
+	return true
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-routine/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sub, err := s.whsvc.CreateWebhook(r.Context(), req.subscription())
+	if err != nil {
+		if errs, ok := entityFieldErrors(err); ok {
+			writeFieldErrors(w, errs)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to create webhook subscription"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(sub)
+	w.Header().Set("Location", fmt.Sprintf("%s/webhooks/%d", apiPrefix, sub.ID))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.whsvc.ListWebhooks(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to list webhook subscriptions"))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.WebhookSubscription{"webhooks": subs})
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := s.whsvc.GetWebhook(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write(fmtResponse("failed to get webhook subscription"))
+		return
+	}
+	if sub == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(entity.ErrWebhookNotFound.Error()))
+		return
+	}
+
+	jsonResp, _ := json.Marshal(sub)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.whsvc.DeleteWebhook(r.Context(), id); err != nil {
+		s.writeWebhookError(w, "failed to delete webhook subscription", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	filter := entity.DeliveryFilter{SubscriptionID: id}
+	var err error
+	filter.Status, err = entity.ParseDeliveryStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse(fmt.Sprintf("%s: must be %s, %s or %s", err, entity.DeliveryPending, entity.DeliveryDelivered, entity.DeliveryDead)))
+		return
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(fmtResponse("invalid limit"))
+			return
+		}
+	}
+
+	deliveries, err := s.whsvc.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		s.writeWebhookError(w, "failed to list webhook deliveries", err)
+		return
+	}
+
+	jsonResp, _ := json.Marshal(map[string][]entity.WebhookDelivery{"deliveries": deliveries})
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) replayDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid delivery id"))
+		return
+	}
+
+	d, err := s.whsvc.ReplayDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		s.writeWebhookError(w, "failed to replay webhook delivery", err)
+		return
+	}
+
+	jsonResp, _ := json.Marshal(d)
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(jsonResp)
+}
+
+func (s *Server) writeWebhookError(w http.ResponseWriter, msg string, err error) {
+	if errors.Is(err, entity.ErrWebhookNotFound) || errors.Is(err, entity.ErrWebhookDeliveryNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(fmtResponse(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write(fmtResponse(msg))
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(fmtResponse("invalid webhook id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -3,16 +3,21 @@ package service
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"transaction-routine/internal/database"
 	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/tracing"
 
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type AccountService interface {
 	GetAccountByID(ctx context.Context, id int) (*entity.Account, error)
-	CreateAccount(ctx context.Context, acc entity.Account) error
+	CreateAccount(ctx context.Context, acc entity.Account) (entity.Account, error)
 	GetAccountBalance(ctx context.Context, id int) (decimal.Decimal, error)
 }
 
@@ -24,10 +29,13 @@ func NewAccountService(repo database.Repository) AccountService {
 	return &accountService{repo: repo}
 }
 
-func (s *accountService) GetAccountByID(ctx context.Context, id int) (*entity.Account, error) {
+func (s *accountService) GetAccountByID(ctx context.Context, id int) (_ *entity.Account, err error) {
+	ctx, span := tracing.Start(ctx, "AccountService.GetAccountByID", trace.WithAttributes(attribute.Int("account.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	accs, err := s.repo.FindAccounts(ctx, entity.AccountFilter{ID: &id})
 	if err != nil {
-		log.Printf("error getting account %d: %s", err, err)
+		slog.ErrorContext(ctx, "error getting account", "account_id", id, "error", err)
 		return nil, err
 	}
 	if len(accs) == 0 {
@@ -36,21 +44,40 @@ func (s *accountService) GetAccountByID(ctx context.Context, id int) (*entity.Ac
 	return &accs[0], nil
 }
 
-func (s *accountService) CreateAccount(ctx context.Context, acc entity.Account) error {
-	if acc.DocumentNumber == "" {
-		return entity.ErrMissingDocumentNumber
+// CreateAccount opens acc for the customer CustomerID or, when it is zero,
+// for the customer holding its document number.
+func (s *accountService) CreateAccount(ctx context.Context, acc entity.Account) (_ entity.Account, err error) {
+	ctx, span := tracing.Start(ctx, "AccountService.CreateAccount")
+	defer func() { tracing.End(span, err) }()
+
+	if acc.CustomerID == 0 && acc.DocumentNumber == "" {
+		metrics.ValidationRejections.WithLabelValues(entity.ErrMissingDocumentNumber.Error()).Inc()
+		return entity.Account{}, entity.ErrMissingDocumentNumber
+	}
+	if acc.Currency == "" {
+		acc.Currency = entity.DefaultCurrency
 	}
-	if err := s.repo.CreateAccount(ctx, acc); err != nil {
-		log.Printf("error creating account: %s", err)
-		return err
+	if !acc.Currency.Valid() {
+		metrics.ValidationRejections.WithLabelValues(entity.ErrInvalidCurrency.Error()).Inc()
+		return entity.Account{}, &entity.FieldError{Field: "currency", Err: entity.ErrInvalidCurrency}
 	}
-	return nil
+	created, err := s.repo.CreateAccount(ctx, acc)
+	if err != nil {
+		if !errors.Is(err, entity.ErrCustomerNotFound) {
+			slog.ErrorContext(ctx, "error creating account", "account", acc, "error", err)
+		}
+		return entity.Account{}, err
+	}
+	return created, nil
 }
 
-func (s *accountService) GetAccountBalance(ctx context.Context, id int) (decimal.Decimal, error) {
+func (s *accountService) GetAccountBalance(ctx context.Context, id int) (_ decimal.Decimal, err error) {
+	ctx, span := tracing.Start(ctx, "AccountService.GetAccountBalance", trace.WithAttributes(attribute.Int("account.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	txs, err := s.repo.FindTransactions(ctx, entity.TransactionFilter{AccountID: &id})
 	if err != nil {
-		log.Printf("error getting transactions to calculate balance: %s", err)
+		slog.ErrorContext(ctx, "error getting transactions to calculate balance", "account_id", id, "error", err)
 		return decimal.Zero, err
 	}
 	balance := decimal.Zero
@@ -0,0 +1,69 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_auth.go -package=mocks -source=auth.go
+package service
+
+import (
+	"context"
+	"log/slog"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+)
+
+type AuthService interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error)
+	AuthenticateToken(ctx context.Context, token string) (*auth.Principal, error)
+	CreateAPIKey(ctx context.Context, key entity.APIKey) (string, error)
+}
+
+type authService struct {
+	repo database.Repository
+	jwt  *auth.JWTVerifier
+}
+
+func NewAuthService(repo database.Repository, jwt *auth.JWTVerifier) AuthService {
+	return &authService{repo: repo, jwt: jwt}
+}
+
+func (s *authService) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	apiKey, err := s.repo.FindAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting api key", "error", err)
+		return nil, err
+	}
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	return &auth.Principal{
+		Subject: apiKey.Name,
+		Method:  auth.MethodAPIKey,
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
+
+func (s *authService) AuthenticateToken(ctx context.Context, token string) (*auth.Principal, error) {
+	p, err := s.jwt.Verify(token)
+	if err != nil {
+		slog.WarnContext(ctx, "error verifying token", "error", err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// CreateAPIKey stores a new key for the given name and scopes and returns
+// it. The plain key is never persisted, so it cannot be retrieved again.
+func (s *authService) CreateAPIKey(ctx context.Context, apiKey entity.APIKey) (string, error) {
+	if err := apiKey.Validate(); err != nil {
+		return "", err
+	}
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		return "", err
+	}
+	apiKey.KeyHash = auth.HashAPIKey(key)
+	if err := s.repo.CreateAPIKey(ctx, apiKey); err != nil {
+		slog.ErrorContext(ctx, "error creating api key", "name", apiKey.Name, "error", err)
+		return "", err
+	}
+	slog.InfoContext(ctx, "api key created", "name", apiKey.Name, "scopes", apiKey.Scopes)
+	return key, nil
+}
@@ -0,0 +1,145 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_card.go -package=mocks -source=card.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// issueAttempts bounds the cards generated for one request, in the unlikely
+// case their number or token is taken.
+const issueAttempts = 3
+
+type CardService interface {
+	IssueCard(ctx context.Context, accountID int) (entity.Card, error)
+	GetCard(ctx context.Context, token string) (*entity.Card, error)
+	ListCards(ctx context.Context, accountID int) ([]entity.Card, error)
+	SetCardStatus(ctx context.Context, token string, status entity.CardStatus) (entity.Card, error)
+}
+
+// CardIssuer generates new cards, with their PAN already encrypted.
+type CardIssuer interface {
+	Issue(accountID int, now time.Time) (entity.Card, error)
+}
+
+type cardService struct {
+	cl     clock.Clock
+	repo   database.Repository
+	issuer CardIssuer
+}
+
+// NewCardService returns a service issuing cards with issuer. A nil issuer
+// disables issuing, while existing cards can still be used and managed.
+func NewCardService(cl clock.Clock, repo database.Repository, issuer CardIssuer) CardService {
+	return &cardService{cl: cl, repo: repo, issuer: issuer}
+}
+
+func (s *cardService) IssueCard(ctx context.Context, accountID int) (_ entity.Card, err error) {
+	ctx, span := tracing.Start(ctx, "CardService.IssueCard", trace.WithAttributes(attribute.Int("account.id", accountID)))
+	defer func() { tracing.End(span, err) }()
+
+	if s.issuer == nil {
+		return entity.Card{}, entity.ErrCardsDisabled
+	}
+	if err := s.findAccount(ctx, accountID); err != nil {
+		return entity.Card{}, err
+	}
+	for attempt := 1; ; attempt++ {
+		c, err := s.issuer.Issue(accountID, s.cl.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "error generating card", "account_id", accountID, "error", err)
+			return entity.Card{}, err
+		}
+		c, err = s.repo.CreateCard(ctx, c)
+		if errors.Is(err, entity.ErrCardExists) && attempt < issueAttempts {
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "error creating card", "account_id", accountID, "error", err)
+			return entity.Card{}, err
+		}
+		slog.InfoContext(ctx, "card issued", "card", c)
+		c.CreatedAt = c.CreatedAt.In(s.cl.Location())
+		return c, nil
+	}
+}
+
+// GetCard returns the card with token, or nil if it does not exist.
+func (s *cardService) GetCard(ctx context.Context, token string) (_ *entity.Card, err error) {
+	ctx, span := tracing.Start(ctx, "CardService.GetCard")
+	defer func() { tracing.End(span, err) }()
+
+	cards, err := s.repo.FindCards(ctx, entity.CardFilter{Token: &token})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting card", "error", err)
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	c := cards[0]
+	c.CreatedAt = c.CreatedAt.In(s.cl.Location())
+	return &c, nil
+}
+
+// ListCards returns the cards of the account, in the order they were issued,
+// or ErrAccountNotFound.
+func (s *cardService) ListCards(ctx context.Context, accountID int) (_ []entity.Card, err error) {
+	ctx, span := tracing.Start(ctx, "CardService.ListCards", trace.WithAttributes(attribute.Int("account.id", accountID)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.findAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	cards, err := s.repo.FindCards(ctx, entity.CardFilter{AccountID: &accountID})
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing cards", "account_id", accountID, "error", err)
+		return nil, err
+	}
+	for i := range cards {
+		cards[i].CreatedAt = cards[i].CreatedAt.In(s.cl.Location())
+	}
+	return cards, nil
+}
+
+// SetCardStatus blocks or unblocks the card with token. Setting the status
+// it already has is allowed.
+func (s *cardService) SetCardStatus(ctx context.Context, token string, status entity.CardStatus) (_ entity.Card, err error) {
+	ctx, span := tracing.Start(ctx, "CardService.SetCardStatus", trace.WithAttributes(attribute.String("card.status", string(status))))
+	defer func() { tracing.End(span, err) }()
+
+	if !status.Valid() {
+		return entity.Card{}, entity.ErrInvalidCardStatus
+	}
+	c, err := s.repo.UpdateCardStatus(ctx, token, status)
+	if err != nil {
+		if !errors.Is(err, entity.ErrCardNotFound) {
+			slog.ErrorContext(ctx, "error updating card status", "status", status, "error", err)
+		}
+		return entity.Card{}, err
+	}
+	slog.InfoContext(ctx, "card status set", "card", c)
+	c.CreatedAt = c.CreatedAt.In(s.cl.Location())
+	return c, nil
+}
+
+func (s *cardService) findAccount(ctx context.Context, id int) error {
+	accs, err := s.repo.FindAccounts(ctx, entity.AccountFilter{ID: &id})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting card account", "account_id", id, "error", err)
+		return err
+	}
+	if len(accs) == 0 {
+		return entity.ErrAccountNotFound
+	}
+	return nil
+}
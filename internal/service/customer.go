@@ -0,0 +1,87 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_customer.go -package=mocks -source=customer.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, c entity.Customer) (entity.Customer, error)
+	GetCustomer(ctx context.Context, id int) (*entity.Customer, error)
+	GetCustomerBalances(ctx context.Context, id int) (*entity.CustomerBalances, error)
+}
+
+type customerService struct {
+	cl   clock.Clock
+	repo database.Repository
+}
+
+func NewCustomerService(cl clock.Clock, repo database.Repository) CustomerService {
+	return &customerService{cl: cl, repo: repo}
+}
+
+func (s *customerService) CreateCustomer(ctx context.Context, c entity.Customer) (_ entity.Customer, err error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.CreateCustomer")
+	defer func() { tracing.End(span, err) }()
+
+	if err := c.Validate(); err != nil {
+		countRejections(err)
+		return entity.Customer{}, err
+	}
+	c, err = s.repo.CreateCustomer(ctx, c)
+	if err != nil {
+		if !errors.Is(err, entity.ErrCustomerExists) {
+			slog.ErrorContext(ctx, "error creating customer", "customer", c, "error", err)
+		}
+		return entity.Customer{}, err
+	}
+	slog.InfoContext(ctx, "customer created", "customer", c)
+	c.CreatedAt = c.CreatedAt.In(s.cl.Location())
+	return c, nil
+}
+
+// GetCustomer returns the customer, or nil if it does not exist.
+func (s *customerService) GetCustomer(ctx context.Context, id int) (_ *entity.Customer, err error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetCustomer", trace.WithAttributes(attribute.Int("customer.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	customers, err := s.repo.FindCustomers(ctx, entity.CustomerFilter{ID: &id})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting customer", "customer_id", id, "error", err)
+		return nil, err
+	}
+	if len(customers) == 0 {
+		return nil, nil
+	}
+	c := customers[0]
+	c.CreatedAt = c.CreatedAt.In(s.cl.Location())
+	return &c, nil
+}
+
+// GetCustomerBalances returns the balances of the accounts of the customer
+// with their totals per currency, or nil if the customer does not exist.
+func (s *customerService) GetCustomerBalances(ctx context.Context, id int) (_ *entity.CustomerBalances, err error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetCustomerBalances", trace.WithAttributes(attribute.Int("customer.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	c, err := s.GetCustomer(ctx, id)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	accounts, err := s.repo.FindCustomerBalances(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting customer balances", "customer_id", id, "error", err)
+		return nil, err
+	}
+	balances := entity.NewCustomerBalances(id, accounts)
+	return &balances, nil
+}
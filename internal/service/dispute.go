@@ -0,0 +1,199 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_dispute.go -package=mocks -source=dispute.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type DisputeService interface {
+	OpenDispute(ctx context.Context, d entity.Dispute) (entity.Dispute, error)
+	ListDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error)
+	GetDispute(ctx context.Context, id int) (*entity.Dispute, error)
+	TransitionDispute(ctx context.Context, id int, status entity.DisputeStatus) (entity.Dispute, error)
+	EnforceDeadlines(ctx context.Context) (int, error)
+}
+
+type disputeService struct {
+	cl            clock.Clock
+	repo          database.Repository
+	opTypes       entity.OperationType
+	creditWithin  time.Duration
+	resolveWithin time.Duration
+}
+
+// NewDisputeService returns a service giving disputes creditWithin to be
+// credited and resolveWithin to be resolved from the time they are opened.
+func NewDisputeService(cl clock.Clock, repo database.Repository, opTypes entity.OperationType, creditWithin, resolveWithin time.Duration) DisputeService {
+	return &disputeService{cl: cl, repo: repo, opTypes: opTypes, creditWithin: creditWithin, resolveWithin: resolveWithin}
+}
+
+// OpenDispute opens a dispute against a COMPRA A VISTA transaction.
+func (s *disputeService) OpenDispute(ctx context.Context, d entity.Dispute) (_ entity.Dispute, err error) {
+	ctx, span := tracing.Start(ctx, "DisputeService.OpenDispute", trace.WithAttributes(attribute.Int("transaction.id", d.TransactionID)))
+	defer func() { tracing.End(span, err) }()
+
+	txs, err := s.repo.FindTransactions(ctx, entity.TransactionFilter{ID: &d.TransactionID})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting disputed transaction", "transaction_id", d.TransactionID, "error", err)
+		return entity.Dispute{}, err
+	}
+	if len(txs) == 0 {
+		return entity.Dispute{}, &entity.FieldError{Field: "transaction_id", Err: entity.ErrTransactionNotFound}
+	}
+	if err := d.Open(txs[0], s.opTypes); err != nil {
+		slog.WarnContext(ctx, "error validating dispute", "transaction_id", d.TransactionID, "error", err)
+		return entity.Dispute{}, err
+	}
+
+	d.OpenedAt = s.cl.Now()
+	d.CreditDueAt = d.OpenedAt.Add(s.creditWithin)
+	d.ResolveBy = d.OpenedAt.Add(s.resolveWithin)
+	if p := auth.FromContext(ctx); p != nil {
+		d.OpenedBy = p.Subject
+	}
+	d.ID, err = s.repo.CreateDispute(ctx, d)
+	if err != nil {
+		if !errors.Is(err, entity.ErrAlreadyDisputed) {
+			slog.ErrorContext(ctx, "error opening dispute", "transaction_id", d.TransactionID, "error", err)
+		}
+		return entity.Dispute{}, err
+	}
+	slog.InfoContext(ctx, "dispute opened", "id", d.ID, "transaction_id", d.TransactionID, "amount", d.Amount)
+	return d, nil
+}
+
+// ListDisputes returns the disputes matching filter, flagging the ones past a
+// deadline, with their dates in the configured time zone.
+func (s *disputeService) ListDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error) {
+	now := s.cl.Now()
+	filter.AsOf = now
+	disputes, err := s.repo.FindDisputes(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing disputes", "error", err)
+		return nil, err
+	}
+	for i := range disputes {
+		s.localize(&disputes[i], now)
+	}
+	return disputes, nil
+}
+
+func (s *disputeService) GetDispute(ctx context.Context, id int) (*entity.Dispute, error) {
+	disputes, err := s.ListDisputes(ctx, entity.DisputeFilter{ID: &id})
+	if err != nil || len(disputes) == 0 {
+		return nil, err
+	}
+	return &disputes[0], nil
+}
+
+// TransitionDispute moves a dispute to status, posting the credit of the
+// disputed amount or its reversal when the move calls for it.
+func (s *disputeService) TransitionDispute(ctx context.Context, id int, status entity.DisputeStatus) (_ entity.Dispute, err error) {
+	ctx, span := tracing.Start(ctx, "DisputeService.TransitionDispute", trace.WithAttributes(
+		attribute.Int("dispute.id", id),
+		attribute.String("dispute.status", string(status)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	d, err := s.GetDispute(ctx, id)
+	if err != nil {
+		return entity.Dispute{}, err
+	}
+	if d == nil {
+		return entity.Dispute{}, entity.ErrDisputeNotFound
+	}
+	return s.move(ctx, *d, status)
+}
+
+// EnforceDeadlines moves on the disputes past a deadline: opened disputes
+// not credited by credit_due_at get their provisional credit, and disputes
+// not resolved by resolve_by are won. It returns how many were moved.
+// Disputes moved meanwhile by someone else are skipped.
+func (s *disputeService) EnforceDeadlines(ctx context.Context) (moved int, err error) {
+	ctx, span := tracing.Start(ctx, "DisputeService.EnforceDeadlines")
+	defer func() { tracing.End(span, err) }()
+
+	now := s.cl.Now()
+	disputes, err := s.repo.FindDisputes(ctx, entity.DisputeFilter{Overdue: true, AsOf: now})
+	if err != nil {
+		slog.ErrorContext(ctx, "error finding overdue disputes", "error", err)
+		return 0, err
+	}
+	for _, d := range disputes {
+		status, ok := d.DeadlineStatus(now)
+		if !ok {
+			continue
+		}
+		if _, err := s.move(ctx, d, status); err != nil {
+			if errors.Is(err, entity.ErrInvalidDisputeTransition) {
+				continue
+			}
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// move moves d to status, posting the credit of the disputed amount or its
+// reversal when the move calls for it.
+func (s *disputeService) move(ctx context.Context, d entity.Dispute, status entity.DisputeStatus) (entity.Dispute, error) {
+	credit, reversal := 0, 0
+	for opID, op := range s.opTypes {
+		switch op.Description {
+		case entity.DisputeCreditDescription:
+			credit = opID
+		case entity.DisputeReversalDescription:
+			reversal = opID
+		}
+	}
+	if credit == 0 || reversal == 0 {
+		slog.ErrorContext(ctx, "cannot move dispute", "id", d.ID, "error", entity.ErrDisputeTypesMissing)
+		return entity.Dispute{}, entity.ErrDisputeTypesMissing
+	}
+
+	from := d.Status
+	now := s.cl.Now()
+	posting, err := d.Transition(status, credit, reversal, now)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid dispute transition", "id", d.ID, "from", from, "to", status)
+		return entity.Dispute{}, err
+	}
+	moved, err := s.repo.TransitionDispute(ctx, d, from, posting)
+	if err != nil {
+		if !errors.Is(err, entity.ErrInvalidDisputeTransition) {
+			slog.ErrorContext(ctx, "error moving dispute", "id", d.ID, "error", err)
+		}
+		return entity.Dispute{}, err
+	}
+	if posting != nil {
+		metrics.TransactionsCreated.WithLabelValues(s.opTypes[posting.OperationTypeID].Description).Inc()
+	}
+	s.localize(&moved, now)
+	slog.InfoContext(ctx, "dispute moved", "id", d.ID, "from", from, "to", status)
+	return moved, nil
+}
+
+func (s *disputeService) localize(d *entity.Dispute, now time.Time) {
+	loc := s.cl.Location()
+	d.Overdue = d.PastDeadline(now)
+	d.OpenedAt = d.OpenedAt.In(loc)
+	d.CreditDueAt = d.CreditDueAt.In(loc)
+	d.ResolveBy = d.ResolveBy.In(loc)
+	if d.ResolvedAt != nil {
+		resolved := d.ResolvedAt.In(loc)
+		d.ResolvedAt = &resolved
+	}
+}
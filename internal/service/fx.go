@@ -0,0 +1,71 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_fx.go -package=mocks -source=fx.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type FXService interface {
+	CreateRate(ctx context.Context, rate entity.FXRate) (entity.FXRate, error)
+	ListRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error)
+}
+
+type fxService struct {
+	cl   clock.Clock
+	repo database.Repository
+}
+
+func NewFXService(cl clock.Clock, repo database.Repository) FXService {
+	return &fxService{cl: cl, repo: repo}
+}
+
+// CreateRate publishes a rate of a currency pair. Transactions dated from its
+// effective date on are converted with it, until a later one takes effect.
+func (s *fxService) CreateRate(ctx context.Context, rate entity.FXRate) (_ entity.FXRate, err error) {
+	ctx, span := tracing.Start(ctx, "FXService.CreateRate", trace.WithAttributes(
+		attribute.String("fx.base", string(rate.Base)),
+		attribute.String("fx.quote", string(rate.Quote)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := rate.Validate(); err != nil {
+		return entity.FXRate{}, err
+	}
+	rate, err = s.repo.CreateFXRate(ctx, rate)
+	if err != nil {
+		if !errors.Is(err, entity.ErrFXRateExists) {
+			slog.ErrorContext(ctx, "error creating fx rate", "base", rate.Base, "quote", rate.Quote, "error", err)
+		}
+		return entity.FXRate{}, err
+	}
+	slog.InfoContext(ctx, "fx rate created", "id", rate.ID, "base", rate.Base, "quote", rate.Quote, "rate", rate.Rate, "effective_at", rate.EffectiveAt)
+	s.localize(&rate)
+	return rate, nil
+}
+
+// ListRates returns the rates matching filter, the latest first.
+func (s *fxService) ListRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error) {
+	rates, err := s.repo.FindFXRates(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing fx rates", "error", err)
+		return nil, err
+	}
+	for i := range rates {
+		s.localize(&rates[i])
+	}
+	return rates, nil
+}
+
+func (s *fxService) localize(rate *entity.FXRate) {
+	rate.EffectiveAt = rate.EffectiveAt.In(s.cl.Location())
+	rate.CreatedAt = rate.CreatedAt.In(s.cl.Location())
+}
@@ -1,27 +1,118 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_health.go -package=mocks -source=health.go
 package service
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"sync/atomic"
+	"time"
 	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+)
+
+const (
+	checkDatabase       = "database"
+	checkPool           = "pool"
+	checkOperationTypes = "operation_types"
+	checkMigrations     = "migrations"
+	checkShutdown       = "shutdown"
 )
 
 type HealthService interface {
-	HealthCheck(ctx context.Context) bool
+	Readiness(ctx context.Context) entity.HealthReport
+	Drain()
 }
 
 type healthService struct {
-	repo database.Repository
+	repo     database.Repository
+	opTypes  entity.OperationType
+	draining atomic.Bool
+}
+
+func NewHealthService(repo database.Repository, opTypes entity.OperationType) HealthService {
+	return &healthService{repo: repo, opTypes: opTypes}
+}
+
+// Drain marks the service as shutting down, making readiness fail so load
+// balancers stop routing new requests to this instance.
+func (s *healthService) Drain() {
+	s.draining.Store(true)
+}
+
+func (s *healthService) Readiness(ctx context.Context) entity.HealthReport {
+	report := entity.HealthReport{
+		Status: entity.HealthStatusUp,
+		Checks: map[string]entity.HealthCheck{
+			checkShutdown:       s.checkShutdown(),
+			checkDatabase:       s.checkDatabase(ctx),
+			checkPool:           s.checkPool(),
+			checkOperationTypes: s.checkOperationTypes(),
+			checkMigrations:     s.checkMigrations(ctx),
+		},
+	}
+	for name, check := range report.Checks {
+		if check.Status != entity.HealthStatusUp {
+			slog.WarnContext(ctx, "readiness check failed", "check", name, "error", check.Error)
+			report.Status = entity.HealthStatusDown
+		}
+	}
+	return report
+}
+
+func (s *healthService) checkShutdown() entity.HealthCheck {
+	if s.draining.Load() {
+		return entity.HealthCheck{Status: entity.HealthStatusDown, Error: "shutting down"}
+	}
+	return entity.HealthCheck{Status: entity.HealthStatusUp}
 }
 
-func NewHealthService(repo database.Repository) HealthService {
-	return &healthService{repo: repo}
+func (s *healthService) checkDatabase(ctx context.Context) entity.HealthCheck {
+	start := time.Now()
+	err := s.repo.Health(ctx)
+	details := map[string]any{"latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		return entity.HealthCheck{Status: entity.HealthStatusDown, Details: details, Error: err.Error()}
+	}
+	return entity.HealthCheck{Status: entity.HealthStatusUp, Details: details}
 }
 
-func (s *healthService) HealthCheck(ctx context.Context) bool {
-	if err := s.repo.Health(ctx); err != nil {
-		log.Panicf("error checking database health: %s", err)
-		return false
+// checkPool reports the use of the connection pool. A saturated pool only
+// means the instance is busy: requests wait for a connection, so readiness is
+// left to the database check.
+func (s *healthService) checkPool() entity.HealthCheck {
+	stat := s.repo.Stat()
+	saturation := 0.0
+	if stat.Max > 0 {
+		saturation = float64(stat.Acquired) / float64(stat.Max)
+	}
+	return entity.HealthCheck{
+		Status: entity.HealthStatusUp,
+		Details: map[string]any{
+			"acquired":   stat.Acquired,
+			"idle":       stat.Idle,
+			"total":      stat.Total,
+			"max":        stat.Max,
+			"saturation": saturation,
+		},
+	}
+}
+
+func (s *healthService) checkOperationTypes() entity.HealthCheck {
+	details := map[string]any{"loaded": len(s.opTypes)}
+	if len(s.opTypes) == 0 {
+		return entity.HealthCheck{Status: entity.HealthStatusDown, Details: details, Error: "operation types not loaded"}
+	}
+	return entity.HealthCheck{Status: entity.HealthStatusUp, Details: details}
+}
+
+func (s *healthService) checkMigrations(ctx context.Context) entity.HealthCheck {
+	version, dirty, err := s.repo.MigrationVersion(ctx)
+	if err != nil {
+		return entity.HealthCheck{Status: entity.HealthStatusDown, Error: err.Error()}
+	}
+	details := map[string]any{"version": version, "dirty": dirty}
+	if dirty {
+		return entity.HealthCheck{Status: entity.HealthStatusDown, Details: details, Error: "migration is dirty"}
 	}
-	return true
+	return entity.HealthCheck{Status: entity.HealthStatusUp, Details: details}
 }
@@ -3,7 +3,7 @@ package service
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"transaction-routine/internal/database"
 	"transaction-routine/internal/entity"
 )
@@ -27,8 +27,11 @@ func (s *opTypeService) GetAllOperationTypes(ctx context.Context) (entity.Operat
 }
 
 func (s *opTypeService) CreateOperationType(ctx context.Context, op entity.Operation) error {
+	if err := op.Validate(); err != nil {
+		return err
+	}
 	if err := s.repo.CreateOperationType(ctx, op); err != nil {
-		log.Printf("error creating operation type: %s", err)
+		slog.ErrorContext(ctx, "error creating operation type", "error", err)
 		return err
 	}
 	return nil
@@ -37,7 +40,7 @@ func (s *opTypeService) CreateOperationType(ctx context.Context, op entity.Opera
 func (s *opTypeService) RefreshOperationTypes(ctx context.Context) error {
 	opTypes, err := s.repo.FindOperationType(ctx)
 	if err != nil {
-		log.Printf("error getting operation types: %s", err)
+		slog.ErrorContext(ctx, "error getting operation types", "error", err)
 		return err
 	}
 	s.opTypes = opTypes
@@ -0,0 +1,155 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_schedule.go -package=mocks -source=schedule.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, s entity.Schedule) (entity.Schedule, error)
+	ListSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error)
+	GetSchedule(ctx context.Context, id int) (*entity.Schedule, error)
+	CancelSchedule(ctx context.Context, id int) error
+	ListRuns(ctx context.Context, id int) ([]entity.ScheduleRun, error)
+}
+
+type scheduleService struct {
+	cl      clock.Clock
+	repo    database.Repository
+	opTypes entity.OperationType
+}
+
+func NewScheduleService(cl clock.Clock, repo database.Repository, opTypes entity.OperationType) ScheduleService {
+	return &scheduleService{cl: cl, repo: repo, opTypes: opTypes}
+}
+
+// CreateSchedule validates the schedule and the transaction it posts, fixing
+// the amount sign for the operation type, and stores it with its first run.
+func (s *scheduleService) CreateSchedule(ctx context.Context, sch entity.Schedule) (_ entity.Schedule, err error) {
+	ctx, span := tracing.Start(ctx, "ScheduleService.CreateSchedule", trace.WithAttributes(
+		attribute.Int("account.id", sch.AccountID),
+		attribute.String("schedule.recurrence", string(sch.Recurrence)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	now := s.cl.Now()
+	tx := sch.Transaction()
+	tx.EventDate = now
+	if err := errors.Join(tx.Validate(s.opTypes), sch.Validate(now)); err != nil {
+		slog.WarnContext(ctx, "error validating schedule", "account_id", sch.AccountID, "error", err)
+		return entity.Schedule{}, err
+	}
+	sch.Amount = tx.Amount
+	found, err := s.repo.FindAccountIDs(ctx, []int{sch.AccountID})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting schedule account", "account_id", sch.AccountID, "error", err)
+		return entity.Schedule{}, err
+	}
+	if len(found) == 0 {
+		return entity.Schedule{}, &entity.FieldError{Field: "account_id", Err: entity.ErrAccountNotFound}
+	}
+
+	if p := auth.FromContext(ctx); p != nil {
+		sch.CreatedBy = p.Subject
+	}
+	sch.Start(s.cl.Location())
+	sch.ID, err = s.repo.CreateSchedule(ctx, sch)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating schedule", "account_id", sch.AccountID, "error", err)
+		return entity.Schedule{}, err
+	}
+	sch.CreatedAt = now
+	slog.InfoContext(ctx, "schedule created", "id", sch.ID, "account_id", sch.AccountID, "next_run_at", sch.NextRunAt)
+	return sch, nil
+}
+
+// ListSchedules returns the schedules matching filter with their dates in the
+// configured time zone.
+func (s *scheduleService) ListSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error) {
+	schedules, err := s.repo.FindSchedules(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing schedules", "error", err)
+		return nil, err
+	}
+	for i := range schedules {
+		s.localize(&schedules[i])
+	}
+	return schedules, nil
+}
+
+func (s *scheduleService) GetSchedule(ctx context.Context, id int) (*entity.Schedule, error) {
+	schedules, err := s.ListSchedules(ctx, entity.ScheduleFilter{ID: &id})
+	if err != nil || len(schedules) == 0 {
+		return nil, err
+	}
+	return &schedules[0], nil
+}
+
+// CancelSchedule stops an active schedule from running again.
+func (s *scheduleService) CancelSchedule(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "ScheduleService.CancelSchedule", trace.WithAttributes(attribute.Int("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.find(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.CancelSchedule(ctx, id); err != nil {
+		if !errors.Is(err, entity.ErrScheduleNotActive) {
+			slog.ErrorContext(ctx, "error canceling schedule", "id", id, "error", err)
+		}
+		return err
+	}
+	slog.InfoContext(ctx, "schedule canceled", "id", id)
+	return nil
+}
+
+// ListRuns returns the runs of a schedule, newest first.
+func (s *scheduleService) ListRuns(ctx context.Context, id int) ([]entity.ScheduleRun, error) {
+	if _, err := s.find(ctx, id); err != nil {
+		return nil, err
+	}
+	runs, err := s.repo.FindScheduleRuns(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing schedule runs", "id", id, "error", err)
+		return nil, err
+	}
+	loc := s.cl.Location()
+	for i := range runs {
+		runs[i].DueAt = runs[i].DueAt.In(loc)
+	}
+	return runs, nil
+}
+
+func (s *scheduleService) find(ctx context.Context, id int) (*entity.Schedule, error) {
+	sch, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sch == nil {
+		return nil, entity.ErrScheduleNotFound
+	}
+	return sch, nil
+}
+
+func (s *scheduleService) localize(sch *entity.Schedule) {
+	loc := s.cl.Location()
+	sch.StartAt = sch.StartAt.In(loc)
+	if sch.NextRunAt != nil {
+		next := sch.NextRunAt.In(loc)
+		sch.NextRunAt = &next
+	}
+	if sch.EndAt != nil {
+		end := sch.EndAt.In(loc)
+		sch.EndAt = &end
+	}
+}
@@ -3,61 +3,569 @@ package service
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"transaction-routine/internal/auth"
 	"transaction-routine/internal/clock"
 	"transaction-routine/internal/database"
 	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/tracing"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TransactionService interface {
-	CreateTransaction(ctx context.Context, t entity.Transaction) error
+	CreateTransaction(ctx context.Context, t entity.Transaction) (entity.Transaction, error)
 	UpdateTransaction(ctx context.Context, t entity.Transaction) error
+	PatchTransaction(ctx context.Context, id int, patch []byte) error
+	CreateTransactions(ctx context.Context, items []entity.BatchItem, mode entity.BatchMode) (entity.BatchResult, error)
+	ListTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error)
+	ReconcileBalances(ctx context.Context, req entity.ReconciliationRequest) (entity.ReconciliationReport, error)
+	ListFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error)
+}
+
+// Screener decides whether valid transactions may be created.
+type Screener interface {
+	Screen(ctx context.Context, tx entity.Transaction) (entity.FraudDecision, error)
+	ScreenBatch(ctx context.Context, txs []entity.Transaction) ([]entity.FraudDecision, error)
 }
 
 type transactionService struct {
-	cl      clock.Clock
-	repo    database.Repository
-	opTypes entity.OperationType
+	cl       clock.Clock
+	repo     database.Repository
+	opTypes  entity.OperationType
+	limits   entity.AmountLimits
+	screener Screener
 }
 
-func NewTransactionService(cl clock.Clock, repo database.Repository, opTypes entity.OperationType) TransactionService {
-	return &transactionService{cl: cl, repo: repo, opTypes: opTypes}
+// NewTransactionService returns a service bounding amounts by limits and
+// screening transactions, single or in batches, with screener before
+// creating them. A nil screener allows all of them.
+func NewTransactionService(cl clock.Clock, repo database.Repository, opTypes entity.OperationType, limits entity.AmountLimits, screener Screener) TransactionService {
+	return &transactionService{cl: cl, repo: repo, opTypes: opTypes, limits: limits, screener: screener}
 }
 
-func (s *transactionService) CreateTransaction(ctx context.Context, t entity.Transaction) error {
+func (s *transactionService) CreateTransaction(ctx context.Context, t entity.Transaction) (_ entity.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.CreateTransaction", trace.WithAttributes(
+		attribute.Int("account.id", t.AccountID),
+		attribute.Int("operation_type.id", t.OperationTypeID),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	t.EventDate = s.cl.Now()
-	if err := t.Validate(s.opTypes); err != nil {
-		log.Printf("error validating transaction: %s", err)
-		return err
+	if t.CardToken != "" {
+		card, err := s.findCard(ctx, t.CardToken)
+		if err != nil {
+			return entity.Transaction{}, err
+		}
+		if err := t.UseCard(card); err != nil {
+			slog.WarnContext(ctx, "error using card", "account_id", t.AccountID, "error", err)
+			countRejections(err)
+			return entity.Transaction{}, err
+		}
 	}
-	if err := s.repo.CreateTransaction(ctx, t); err != nil {
-		log.Printf("error creating transaction: %s", err)
-		return err
+	if err := s.validate(ctx, &t); err != nil {
+		slog.WarnContext(ctx, "error validating transaction", "account_id", t.AccountID, "error", err)
+		countRejections(err)
+		return entity.Transaction{}, err
 	}
-	return nil
+	if err := s.convert(ctx, &t); err != nil {
+		return entity.Transaction{}, err
+	}
+	decision, err := s.screen(ctx, t)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	t.ID, err = s.repo.CreateTransaction(ctx, t)
+	if err != nil {
+		// A schedule run finished by another instance is not an error.
+		if !errors.Is(err, entity.ErrRunNotRunning) {
+			slog.ErrorContext(ctx, "error creating transaction", "account_id", t.AccountID, "error", err)
+		}
+		return entity.Transaction{}, err
+	}
+	metrics.TransactionsCreated.WithLabelValues(s.opTypes[t.OperationTypeID].Description).Inc()
+	if decision != nil {
+		if decision.Action == entity.FraudReview {
+			slog.WarnContext(ctx, "transaction flagged for review", "transaction_id", t.ID, "rule", decision.Rule, "reason", decision.Reason)
+		}
+		decision.TransactionID = &t.ID
+		s.recordDecision(ctx, *decision)
+	}
+	return t, nil
+}
+
+// findCard returns the card with token, or nil if there is none.
+func (s *transactionService) findCard(ctx context.Context, token string) (*entity.Card, error) {
+	cards, err := s.repo.FindCards(ctx, entity.CardFilter{Token: &token})
+	if err != nil {
+		slog.ErrorContext(ctx, "error finding transaction card", "error", err)
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	return &cards[0], nil
 }
 
-func (s *transactionService) UpdateTransaction(ctx context.Context, tx entity.Transaction) error {
-	if err := tx.Validate(s.opTypes); err != nil {
-		log.Printf("error validating transaction to update: %s", err)
+// convert converts the amount of t into the currency of its account, at the
+// rate in effect at its event date.
+func (s *transactionService) convert(ctx context.Context, t *entity.Transaction) error {
+	currencies, err := s.repo.FindAccountCurrencies(ctx, []int{t.AccountID})
+	if err != nil {
+		slog.ErrorContext(ctx, "error finding transaction account", "account_id", t.AccountID, "error", err)
 		return err
 	}
+	currency, ok := currencies[t.AccountID]
+	if !ok {
+		err = &entity.FieldError{Field: "account_id", Err: entity.ErrAccountNotFound}
+	} else {
+		var rate *entity.FXRate
+		if rate, err = s.rate(ctx, t.OriginalCurrency, currency, t.EventDate); err != nil {
+			return err
+		}
+		err = t.Convert(currency, rate, s.limits)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "error converting transaction", "account_id", t.AccountID, "error", err)
+		countRejections(err)
+	}
+	return err
+}
+
+// rate returns the rate converting from into to at, or nil when there is
+// nothing to convert or no rate is in effect.
+func (s *transactionService) rate(ctx context.Context, from, to entity.Currency, at time.Time) (*entity.FXRate, error) {
+	if from == "" || from == to || !from.Valid() {
+		return nil, nil
+	}
+	rate, err := s.repo.FindFXRate(ctx, from, to, at)
+	if err != nil {
+		slog.ErrorContext(ctx, "error finding fx rate", "base", from, "quote", to, "error", err)
+	}
+	return rate, err
+}
+
+// screen runs the screener on t, returning its decision, or
+// ErrTransactionDenied once the denial is recorded.
+func (s *transactionService) screen(ctx context.Context, t entity.Transaction) (*entity.FraudDecision, error) {
+	if s.screener == nil {
+		return nil, nil
+	}
+	decision, err := s.screener.Screen(ctx, t)
+	if err != nil {
+		slog.ErrorContext(ctx, "error screening transaction", "account_id", t.AccountID, "error", err)
+		return nil, err
+	}
+	if decision.Action != entity.FraudDeny {
+		return &decision, nil
+	}
+	slog.WarnContext(ctx, "transaction denied", "account_id", t.AccountID, "rule", decision.Rule, "reason", decision.Reason)
+	s.recordDecision(ctx, decision)
+	return nil, entity.ErrTransactionDenied
+}
+
+// screenBatch screens the valid items of a batch and fails the denied ones
+// once their denial is recorded. It returns the items still valid, with the
+// decisions on them by index, to be recorded once they are created.
+func (s *transactionService) screenBatch(ctx context.Context, items []entity.BatchItem, valid []int, result *entity.BatchResult) ([]int, map[int]entity.FraudDecision, error) {
+	if s.screener == nil || len(valid) == 0 {
+		return valid, nil, nil
+	}
+	txs := make([]entity.Transaction, len(valid))
+	for j, i := range valid {
+		txs[j] = items[i].Transaction
+	}
+	decisions, err := s.screener.ScreenBatch(ctx, txs)
+	if err != nil {
+		slog.ErrorContext(ctx, "error screening batch transactions", "size", len(txs), "error", err)
+		return nil, nil, err
+	}
+	screened := make(map[int]entity.FraudDecision, len(decisions))
+	var allowed []int
+	for j, i := range valid {
+		d := decisions[j]
+		if d.Action == entity.FraudDeny {
+			slog.WarnContext(ctx, "transaction denied", "account_id", d.AccountID, "rule", d.Rule, "reason", d.Reason)
+			s.recordDecision(ctx, d)
+			result.Fail(i, entity.BatchItemFailed, entity.ErrTransactionDenied)
+			continue
+		}
+		screened[i] = d
+		allowed = append(allowed, i)
+	}
+	return allowed, screened, nil
+}
+
+// recordDecision stores a screening decision. The transaction does not
+// depend on it, so failures are only logged.
+func (s *transactionService) recordDecision(ctx context.Context, d entity.FraudDecision) {
+	metrics.FraudDecisions.WithLabelValues(string(d.Action), d.Rule).Inc()
+	if _, err := s.repo.CreateFraudDecision(ctx, d); err != nil {
+		slog.ErrorContext(ctx, "error recording fraud decision", "account_id", d.AccountID, "action", d.Action, "error", err)
+	}
+}
+
+// ListFraudDecisions returns the screening decisions matching filter, newest
+// first, with their dates in the configured time zone.
+func (s *transactionService) ListFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error) {
+	decisions, err := s.repo.FindFraudDecisions(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing fraud decisions", "error", err)
+		return nil, err
+	}
+	for i := range decisions {
+		decisions[i].CreatedAt = decisions[i].CreatedAt.In(s.cl.Location())
+	}
+	return decisions, nil
+}
+
+// CreateTransactions validates every item of a batch and inserts the valid
+// ones at once. In all-or-nothing mode nothing is inserted if any item fails.
+func (s *transactionService) CreateTransactions(ctx context.Context, items []entity.BatchItem, mode entity.BatchMode) (result entity.BatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.CreateTransactions", trace.WithAttributes(
+		attribute.Int("batch.size", len(items)),
+		attribute.String("batch.mode", string(mode)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	result = entity.BatchResult{Mode: mode, Items: make([]entity.BatchItemResult, len(items))}
+	now := s.cl.Now()
+	accountIDs := map[int]entity.Currency{}
+	cards := map[string]*entity.Card{}
+	for i := range items {
+		item := &items[i]
+		result.Items[i].Index = item.Index
+		if item.Err != nil {
+			result.Fail(i, entity.BatchItemFailed, item.Err)
+			continue
+		}
+		item.Transaction.EventDate = now
+		if token := item.Transaction.CardToken; token != "" {
+			card, ok := cards[token]
+			if !ok {
+				if card, err = s.findCard(ctx, token); err != nil {
+					return entity.BatchResult{}, err
+				}
+				cards[token] = card
+			}
+			if err := item.Transaction.UseCard(card); err != nil {
+				countRejections(err)
+				result.Fail(i, entity.BatchItemFailed, err)
+				continue
+			}
+		}
+		if err := item.Transaction.Validate(s.opTypes); err != nil {
+			countRejections(err)
+			result.Fail(i, entity.BatchItemFailed, err)
+			continue
+		}
+		accountIDs[item.Transaction.AccountID] = ""
+	}
+
+	if len(accountIDs) > 0 {
+		ids := make([]int, 0, len(accountIDs))
+		for id := range accountIDs {
+			ids = append(ids, id)
+		}
+		accountIDs, err = s.repo.FindAccountCurrencies(ctx, ids)
+		if err != nil {
+			slog.ErrorContext(ctx, "error finding batch accounts", "error", err)
+			return entity.BatchResult{}, err
+		}
+	}
+
+	var valid []int
+	rates := map[[2]entity.Currency]*entity.FXRate{}
+	for i := range items {
+		if result.Items[i].Status != "" {
+			continue
+		}
+		tx := &items[i].Transaction
+		currency, ok := accountIDs[tx.AccountID]
+		if !ok {
+			result.Fail(i, entity.BatchItemFailed, &entity.FieldError{Field: "account_id", Err: entity.ErrAccountNotFound})
+			continue
+		}
+		pair := [2]entity.Currency{tx.OriginalCurrency, currency}
+		rate, ok := rates[pair]
+		if !ok {
+			if rate, err = s.rate(ctx, tx.OriginalCurrency, currency, now); err != nil {
+				return entity.BatchResult{}, err
+			}
+			rates[pair] = rate
+		}
+		if err := tx.Convert(currency, rate, s.limits); err != nil {
+			countRejections(err)
+			result.Fail(i, entity.BatchItemFailed, err)
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	valid, decisions, err := s.screenBatch(ctx, items, valid, &result)
+	if err != nil {
+		return entity.BatchResult{}, err
+	}
+
+	if mode == entity.BatchAllOrNothing && result.Failed > 0 {
+		for _, i := range valid {
+			result.Fail(i, entity.BatchItemRejected, entity.ErrBatchItemRejected)
+		}
+		slog.WarnContext(ctx, "batch rejected", "size", len(items), "invalid", result.Failed-len(valid))
+		return result, nil
+	}
+	if len(valid) == 0 {
+		return result, nil
+	}
+
+	txs := make([]entity.Transaction, len(valid))
+	for j, i := range valid {
+		txs[j] = items[i].Transaction
+	}
+	ids, err := s.repo.CreateTransactions(ctx, txs)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating batch transactions", "size", len(txs), "error", err)
+		return entity.BatchResult{}, err
+	}
+	for j, i := range valid {
+		result.Items[i].Status = entity.BatchItemCreated
+		result.Items[i].ID = ids[j]
+		metrics.TransactionsCreated.WithLabelValues(s.opTypes[txs[j].OperationTypeID].Description).Inc()
+		if d, ok := decisions[i]; ok {
+			if d.Action == entity.FraudReview {
+				slog.WarnContext(ctx, "transaction flagged for review", "transaction_id", ids[j], "rule", d.Rule, "reason", d.Reason)
+			}
+			d.TransactionID = &ids[j]
+			s.recordDecision(ctx, d)
+		}
+	}
+	result.Created = len(valid)
+	return result, nil
+}
+
+// ListTransactions returns the transactions matching filter with their event
+// dates in the configured time zone.
+func (s *transactionService) ListTransactions(ctx context.Context, filter entity.TransactionFilter) (_ []entity.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.ListTransactions")
+	defer func() { tracing.End(span, err) }()
 
-	currTx, err := s.repo.FindTransactions(ctx, entity.TransactionFilter{ID: &tx.ID})
+	txs, err := s.repo.FindTransactions(ctx, filter)
 	if err != nil {
-		log.Printf("error getting transaction '%d' to update: %s", tx.ID, err)
+		slog.ErrorContext(ctx, "error listing transactions", "error", err)
+		return nil, err
+	}
+	for i := range txs {
+		txs[i].EventDate = txs[i].EventDate.In(s.cl.Location())
+	}
+	return txs, nil
+}
+
+// UpdateTransaction replaces every field of an existing transaction.
+func (s *transactionService) UpdateTransaction(ctx context.Context, tx entity.Transaction) (err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.UpdateTransaction", trace.WithAttributes(attribute.Int("transaction.id", tx.ID)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.validate(ctx, &tx); err != nil {
+		slog.WarnContext(ctx, "error validating transaction to update", "transaction_id", tx.ID, "error", err)
+		countRejections(err)
 		return err
 	}
-	if len(currTx) == 0 {
-		log.Printf("transaction '%d' not found", tx.ID)
-		return entity.ErrTransactionNotFound
+	stored, err := s.find(ctx, tx.ID)
+	if err != nil {
+		return err
 	}
+	if err := tx.KeepCard(stored); err != nil {
+		slog.WarnContext(ctx, "error moving card transaction", "transaction_id", tx.ID, "account_id", tx.AccountID, "error", err)
+		countRejections(err)
+		return err
+	}
+	if err := s.convert(ctx, &tx); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateTransaction(ctx, tx); err != nil {
+		slog.ErrorContext(ctx, "error updating transaction", "transaction_id", tx.ID, "error", err)
+		return err
+	}
+	return nil
+}
 
-	newTx := currTx[0]
-	newTx.Update(tx)
-	if err := s.repo.UpdateTransaction(ctx, newTx); err != nil {
-		log.Printf("error updating transaction: %s", err)
+// PatchTransaction applies a JSON merge patch to an existing transaction and
+// validates the merged result before storing it.
+func (s *transactionService) PatchTransaction(ctx context.Context, id int, patch []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.PatchTransaction", trace.WithAttributes(attribute.Int("transaction.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	stored, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	// The patch applies to the amount as entered, in its currency.
+	tx := stored.Entered()
+	if err := tx.Patch(patch); err != nil {
+		slog.WarnContext(ctx, "error applying transaction patch", "transaction_id", id, "error", err)
+		return err
+	}
+	if err := tx.KeepCard(stored); err != nil {
+		slog.WarnContext(ctx, "error moving card transaction", "transaction_id", id, "account_id", tx.AccountID, "error", err)
+		countRejections(err)
+		return err
+	}
+	if err := s.validate(ctx, &tx); err != nil {
+		slog.WarnContext(ctx, "error validating patched transaction", "transaction_id", id, "error", err)
+		countRejections(err)
+		return err
+	}
+	if err := s.convert(ctx, &tx); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateTransaction(ctx, tx); err != nil {
+		slog.ErrorContext(ctx, "error updating transaction", "transaction_id", id, "error", err)
 		return err
 	}
 	return nil
 }
+
+// ReconcileBalances recomputes the balance of every account from its
+// transactions, compares the balances as of the closing date with the given
+// closing balances and looks for amounts with the wrong sign. With Apply each
+// sign violation is corrected by an audited adjustment transaction.
+func (s *transactionService) ReconcileBalances(ctx context.Context, req entity.ReconciliationRequest) (report entity.ReconciliationReport, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.ReconcileBalances", trace.WithAttributes(attribute.Bool("reconciliation.apply", req.Apply)))
+	defer func() { tracing.End(span, err) }()
+
+	report = entity.ReconciliationReport{AsOf: s.cl.Now(), Drifts: []entity.BalanceDrift{}, Adjustments: []entity.Adjustment{}}
+	report.SignViolations, err = s.repo.FindSignViolations(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error finding sign violations", "error", err)
+		return entity.ReconciliationReport{}, err
+	}
+	if report.SignViolations == nil {
+		report.SignViolations = []entity.SignViolation{}
+	}
+	if req.Apply && len(report.SignViolations) > 0 {
+		if report.Adjustments, err = s.adjust(ctx, report.AsOf, report.SignViolations); err != nil {
+			return entity.ReconciliationReport{}, err
+		}
+	}
+
+	report.Balances, err = s.repo.AccountBalances(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "error computing balances", "error", err)
+		return entity.ReconciliationReport{}, err
+	}
+	if req.Closing != nil {
+		report.ClosingAsOf = &req.Closing.AsOf
+		closing, err := s.repo.AccountBalances(ctx, &req.Closing.AsOf)
+		if err != nil {
+			slog.ErrorContext(ctx, "error computing closing balances", "error", err)
+			return entity.ReconciliationReport{}, err
+		}
+		report.Drifts = drifts(req.Closing.Balances, closing)
+	}
+	if !report.Clean() {
+		slog.WarnContext(ctx, "balances do not reconcile",
+			"drifts", len(report.Drifts),
+			"sign_violations", len(report.SignViolations),
+			"adjustments", len(report.Adjustments),
+		)
+	}
+	return report, nil
+}
+
+func (s *transactionService) adjust(ctx context.Context, now time.Time, violations []entity.SignViolation) ([]entity.Adjustment, error) {
+	credit, debit := 0, 0
+	for id, op := range s.opTypes {
+		switch op.Description {
+		case entity.AdjustmentCreditDescription:
+			credit = id
+		case entity.AdjustmentDebitDescription:
+			debit = id
+		}
+	}
+	if credit == 0 || debit == 0 {
+		slog.ErrorContext(ctx, "cannot apply reconciliation adjustments", "error", entity.ErrAdjustmentTypesMissing)
+		return nil, entity.ErrAdjustmentTypesMissing
+	}
+
+	createdBy := ""
+	if p := auth.FromContext(ctx); p != nil {
+		createdBy = p.Subject
+	}
+	adjs := make([]entity.Adjustment, len(violations))
+	for i, v := range violations {
+		adjs[i] = v.Correction(credit, debit)
+		adjs[i].EventDate = now
+		adjs[i].Reason = fmt.Sprintf("amount sign of transaction %d does not match operation type %d", v.TransactionID, v.OperationTypeID)
+		adjs[i].CreatedBy = createdBy
+	}
+	ids, err := s.repo.CreateAdjustments(ctx, adjs)
+	if errors.Is(err, entity.ErrAlreadyAdjusted) {
+		slog.WarnContext(ctx, "sign violations adjusted concurrently", "count", len(adjs))
+		return nil, err
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating adjustments", "count", len(adjs), "error", err)
+		return nil, err
+	}
+	for i := range adjs {
+		adjs[i].TransactionID = ids[i]
+		metrics.TransactionsCreated.WithLabelValues(s.opTypes[adjs[i].OperationTypeID].Description).Inc()
+	}
+	return adjs, nil
+}
+
+// drifts compares the expected closing balances with the recomputed ones. An
+// account missing from either side counts as a zero balance.
+func drifts(expected, actual []entity.AccountBalance) []entity.BalanceDrift {
+	want := make(map[int]decimal.Decimal, len(expected))
+	for _, b := range expected {
+		want[b.AccountID] = b.Balance
+	}
+	result := []entity.BalanceDrift{}
+	for _, b := range actual {
+		if !b.Balance.Equal(want[b.AccountID]) {
+			result = append(result, entity.BalanceDrift{AccountID: b.AccountID, Expected: want[b.AccountID], Actual: b.Balance})
+		}
+		delete(want, b.AccountID)
+	}
+	for id, balance := range want {
+		if !balance.IsZero() {
+			result = append(result, entity.BalanceDrift{AccountID: id, Expected: balance, Actual: decimal.Zero})
+		}
+	}
+	slices.SortFunc(result, func(a, b entity.BalanceDrift) int { return a.AccountID - b.AccountID })
+	return result
+}
+
+func (s *transactionService) find(ctx context.Context, id int) (entity.Transaction, error) {
+	txs, err := s.repo.FindTransactions(ctx, entity.TransactionFilter{ID: &id})
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting transaction to update", "transaction_id", id, "error", err)
+		return entity.Transaction{}, err
+	}
+	if len(txs) == 0 {
+		slog.WarnContext(ctx, "transaction not found", "transaction_id", id)
+		return entity.Transaction{}, entity.ErrTransactionNotFound
+	}
+	return txs[0], nil
+}
+
+func (s *transactionService) validate(ctx context.Context, t *entity.Transaction) (err error) {
+	_, span := tracing.Start(ctx, "Transaction.Validate")
+	defer func() { tracing.End(span, err) }()
+	return t.Validate(s.opTypes)
+}
+
+// countRejections records one validation rejection per invalid field.
+func countRejections(err error) {
+	for _, fe := range entity.FieldErrors(err) {
+		metrics.ValidationRejections.WithLabelValues(fe.Err.Error()).Inc()
+	}
+}
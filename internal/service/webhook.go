@@ -0,0 +1,151 @@
+//go:generate mockgen -destination=./../../tests/mocks/mock_webhook.go -package=mocks -source=webhook.go
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/tracing"
+	"transaction-routine/internal/webhook"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error)
+	GetWebhook(ctx context.Context, id int) (*entity.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, filter entity.DeliveryFilter) ([]entity.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (entity.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo database.Repository
+}
+
+func NewWebhookService(repo database.Repository) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+// CreateWebhook stores a subscription with a new signing secret and returns
+// it. The secret cannot be retrieved again.
+func (s *webhookService) CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (_ entity.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateWebhook")
+	defer func() { tracing.End(span, err) }()
+
+	if err := sub.Validate(); err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	sub.AccountIDs = slices.Clone(sub.AccountIDs)
+	slices.Sort(sub.AccountIDs)
+	sub.AccountIDs = slices.Compact(sub.AccountIDs)
+	if len(sub.AccountIDs) > 0 {
+		found, err := s.repo.FindAccountIDs(ctx, sub.AccountIDs)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting subscription accounts", "error", err)
+			return entity.WebhookSubscription{}, err
+		}
+		if len(found) != len(sub.AccountIDs) {
+			return entity.WebhookSubscription{}, &entity.FieldError{Field: "account_ids", Err: entity.ErrAccountNotFound}
+		}
+	}
+	sub.Secret, err = webhook.NewSecret()
+	if err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	created, err := s.repo.CreateWebhook(ctx, sub)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating webhook subscription", "url", sub.URL, "error", err)
+		return entity.WebhookSubscription{}, err
+	}
+	slog.InfoContext(ctx, "webhook subscription created", "id", created.ID, "url", created.URL)
+	return created, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	subs, err := s.repo.FindWebhooks(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing webhook subscriptions", "error", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id int) (*entity.WebhookSubscription, error) {
+	sub, err := s.repo.FindWebhook(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting webhook subscription", "id", id, "error", err)
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		if !errors.Is(err, entity.ErrWebhookNotFound) {
+			slog.ErrorContext(ctx, "error deleting webhook subscription", "id", id, "error", err)
+		}
+		return err
+	}
+	slog.InfoContext(ctx, "webhook subscription deleted", "id", id)
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a subscription, the dead-letter
+// list when filtered by the dead status.
+func (s *webhookService) ListDeliveries(ctx context.Context, filter entity.DeliveryFilter) (_ []entity.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries", trace.WithAttributes(
+		attribute.Int("webhook.id", filter.SubscriptionID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.find(ctx, filter.SubscriptionID); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = entity.DefaultDeliveryLimit
+	}
+	filter.Limit = min(filter.Limit, entity.MaxDeliveryLimit)
+	deliveries, err := s.repo.FindDeliveries(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing webhook deliveries", "id", filter.SubscriptionID, "error", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ReplayDelivery queues a delivery to be sent again right away, whether it
+// was delivered or dead-lettered.
+func (s *webhookService) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (_ entity.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ReplayDelivery", trace.WithAttributes(
+		attribute.Int("webhook.id", subscriptionID),
+		attribute.Int64("webhook.delivery_id", id),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	d, err := s.repo.ReplayDelivery(ctx, subscriptionID, id)
+	if err != nil {
+		if !errors.Is(err, entity.ErrWebhookDeliveryNotFound) {
+			slog.ErrorContext(ctx, "error replaying webhook delivery", "id", id, "error", err)
+		}
+		return entity.WebhookDelivery{}, err
+	}
+	slog.InfoContext(ctx, "webhook delivery replayed", "id", id, "subscription_id", subscriptionID)
+	return d, nil
+}
+
+func (s *webhookService) find(ctx context.Context, id int) (*entity.WebhookSubscription, error) {
+	sub, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, entity.ErrWebhookNotFound
+	}
+	return sub, nil
+}
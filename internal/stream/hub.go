@@ -0,0 +1,248 @@
+// Package stream pushes the outbox events of an account to live subscribers,
+// such as the server-sent events of GET /accounts/{id}/events.
+package stream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+)
+
+const (
+	// subscriberBuffer is how many events a subscriber may fall behind
+	// before it is dropped. Dropped clients resume with Last-Event-ID.
+	subscriberBuffer = 256
+	pollBatch        = 500
+	backlogPage      = 100
+)
+
+var (
+	ErrClosed      = errors.New("event stream closed")
+	ErrSlowReader  = errors.New("event stream reader fell behind")
+	ErrNotReady    = errors.New("event stream not started")
+	errUnsubscribe = errors.New("unsubscribed")
+)
+
+// Hub polls the outbox for new events and fans them out to the subscribers
+// of their accounts. Every instance runs its own hub, so a client gets the
+// events of every instance whichever one it is connected to.
+type Hub struct {
+	repo     database.Repository
+	cl       clock.Clock
+	interval time.Duration
+	gapWait  time.Duration
+
+	mu       sync.Mutex
+	started  bool
+	closed   bool
+	cursor   int64
+	gapSince time.Time
+	subs     map[int]map[*Subscription]struct{}
+}
+
+// NewHub returns a hub polling every interval. Outbox ids are taken when a
+// transaction inserts them but become visible when it commits, so a missing
+// id holds the stream back for up to gapWait in case it commits late.
+func NewHub(repo database.Repository, cl clock.Clock, interval, gapWait time.Duration) *Hub {
+	return &Hub{repo: repo, cl: cl, interval: interval, gapWait: gapWait, subs: map[int]map[*Subscription]struct{}{}}
+}
+
+// Run polls until ctx is done. Events written before it started are only
+// streamed to clients resuming from an earlier event id.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		n, err := h.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "error polling events to stream", "error", err)
+		}
+		if err == nil && n == pollBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.interval):
+		}
+	}
+}
+
+// Poll hands the new events to their subscribers and returns how many were
+// handed.
+func (h *Hub) Poll(ctx context.Context) (int, error) {
+	h.mu.Lock()
+	started, cursor := h.started, h.cursor
+	h.mu.Unlock()
+	if !started {
+		last, err := h.repo.LastEventID(ctx)
+		if err != nil {
+			return 0, err
+		}
+		h.mu.Lock()
+		h.started, h.cursor = true, last
+		h.mu.Unlock()
+		return 0, nil
+	}
+
+	events, err := h.repo.FindEvents(ctx, entity.EventFilter{AfterID: cursor, Limit: pollBatch})
+	if err != nil {
+		return 0, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, e := range events {
+		if e.ID != h.cursor+1 {
+			now := h.cl.Now()
+			if h.gapSince.IsZero() {
+				h.gapSince = now
+			}
+			if now.Sub(h.gapSince) < h.gapWait {
+				break
+			}
+		}
+		h.gapSince = time.Time{}
+		h.cursor = e.ID
+		h.publish(e)
+		n++
+	}
+	return n, nil
+}
+
+func (h *Hub) publish(e entity.Event) {
+	for _, account := range e.Accounts() {
+		for sub := range h.subs[account] {
+			select {
+			case sub.ch <- e:
+			default:
+				h.remove(sub, ErrSlowReader)
+			}
+		}
+	}
+}
+
+// Subscribe streams the events of an account. With a lastEventID the events
+// after it are read back from the outbox first; otherwise only new events
+// are streamed.
+func (h *Hub) Subscribe(accountID int, lastEventID int64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if !h.started {
+		return nil, ErrNotReady
+	}
+	sub := &Subscription{
+		hub:       h,
+		accountID: accountID,
+		last:      lastEventID,
+		until:     h.cursor,
+		ch:        make(chan entity.Event, subscriberBuffer),
+		done:      make(chan struct{}),
+	}
+	// Events up to the cursor come from the outbox, later ones from the
+	// hub, so none is missed or sent twice.
+	sub.backlog = lastEventID > 0 && lastEventID < h.cursor
+	if lastEventID == 0 {
+		sub.last = h.cursor
+	}
+	if h.subs[accountID] == nil {
+		h.subs[accountID] = map[*Subscription]struct{}{}
+	}
+	h.subs[accountID][sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends every subscription, letting the streams finish before the
+// server shuts down. Later subscriptions fail with ErrClosed.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub, ErrClosed)
+		}
+	}
+}
+
+func (h *Hub) remove(sub *Subscription, err error) {
+	subs := h.subs[sub.accountID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.accountID)
+	}
+	sub.err = err
+	close(sub.done)
+}
+
+// Subscription is the event stream of an account. It is not safe for
+// concurrent use.
+type Subscription struct {
+	hub       *Hub
+	accountID int
+	last      int64
+	until     int64
+	backlog   bool
+	pending   []entity.Event
+	ch        chan entity.Event
+	done      chan struct{}
+	err       error
+}
+
+// Next returns the next event of the account, waiting for one until ctx is
+// done or the subscription ends.
+func (s *Subscription) Next(ctx context.Context) (entity.Event, error) {
+	for {
+		if len(s.pending) > 0 {
+			e := s.pending[0]
+			s.pending = s.pending[1:]
+			s.last = e.ID
+			return e, nil
+		}
+		if s.backlog {
+			events, err := s.hub.repo.FindEvents(ctx, entity.EventFilter{
+				AccountID: s.accountID, AfterID: s.last, UntilID: s.until, Limit: backlogPage,
+			})
+			if err != nil {
+				return entity.Event{}, err
+			}
+			s.backlog = len(events) == backlogPage
+			s.pending = events
+			continue
+		}
+		select {
+		case e := <-s.ch:
+			if e.ID <= s.last {
+				continue
+			}
+			s.last = e.ID
+			return e, nil
+		case <-s.done:
+			return entity.Event{}, s.err
+		case <-ctx.Done():
+			return entity.Event{}, ctx.Err()
+		}
+	}
+}
+
+// Replayed reports whether e was read back from the outbox, having been
+// written before the subscription started.
+func (s *Subscription) Replayed(e entity.Event) bool {
+	return e.ID <= s.until
+}
+
+// Close unsubscribes. Events not read yet are dropped.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, errUnsubscribe)
+}
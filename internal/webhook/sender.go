@@ -0,0 +1,161 @@
+// Package webhook delivers the domain events relayed from the outbox to the
+// partners subscribed to them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"transaction-routine/internal/clock"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+)
+
+// maxErrorBody bounds how much of a failed response is kept in the log.
+const maxErrorBody = 512
+
+// Dispatcher is the outbox publisher that fans each event out to the
+// deliveries of the matching subscriptions.
+type Dispatcher struct {
+	repo database.Repository
+}
+
+func NewDispatcher(repo database.Repository) *Dispatcher {
+	return &Dispatcher{repo: repo}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, event entity.Event) error {
+	_, err := d.repo.EnqueueDeliveries(ctx, event)
+	return err
+}
+
+type Config struct {
+	Interval    time.Duration
+	BatchSize   int
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Sender posts pending deliveries to their subscriptions. A failed attempt is
+// retried with exponential backoff until MaxAttempts, after which the
+// delivery is dead-lettered.
+type Sender struct {
+	repo database.Repository
+	cl   clock.Clock
+	http *http.Client
+	cfg  Config
+}
+
+func NewSender(repo database.Repository, cl clock.Clock, cfg Config) *Sender {
+	return &Sender{repo: repo, cl: cl, http: &http.Client{Timeout: cfg.Timeout}, cfg: cfg}
+}
+
+// Run sends deliveries until ctx is done, waiting for the poll interval
+// whenever a batch is not full.
+func (s *Sender) Run(ctx context.Context) {
+	for {
+		n, err := s.SendOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "error sending webhook deliveries", "error", err)
+		}
+		if err == nil && n == s.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.Interval):
+		}
+	}
+}
+
+// SendOnce attempts a batch of due deliveries and returns how many were
+// attempted.
+func (s *Sender) SendOnce(ctx context.Context) (int, error) {
+	// A claim outlives the slowest attempt, so a delivery is only picked
+	// again if the instance sending it died.
+	lease := 2 * s.cfg.Timeout * time.Duration(s.cfg.BatchSize)
+	deliveries, err := s.repo.ClaimDeliveries(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range deliveries {
+		d = s.attempt(ctx, d)
+		if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends d once and returns it updated with the outcome.
+func (s *Sender) attempt(ctx context.Context, d entity.WebhookDelivery) entity.WebhookDelivery {
+	now := s.cl.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.LastStatusCode, d.LastError = 0, ""
+
+	status, err := s.post(ctx, d, now)
+	d.LastStatusCode = status
+	switch {
+	case err == nil:
+		d.Status = entity.DeliveryDelivered
+		d.DeliveredAt = &now
+	case d.Attempts >= s.cfg.MaxAttempts:
+		d.Status = entity.DeliveryDead
+		d.LastError = err.Error()
+		slog.WarnContext(ctx, "webhook delivery dead-lettered", "delivery_id", d.ID, "subscription_id", d.SubscriptionID, "attempts", d.Attempts, "error", err)
+	default:
+		d.LastError = err.Error()
+		d.NextAttemptAt = now.Add(s.Backoff(d.Attempts))
+	}
+	metrics.WebhookDeliveries.WithLabelValues(string(d.EventType), outcome(d.Status)).Inc()
+	return d
+}
+
+func (s *Sender) post(ctx context.Context, d entity.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Event-ID", strconv.FormatInt(d.EventID, 10))
+	req.Header.Set("X-Event-Type", string(d.EventType))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, now, d.Payload))
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the wait before the attempt following the given one:
+// Backoff doubled for every earlier failure, up to MaxBackoff.
+func (s *Sender) Backoff(attempts int) time.Duration {
+	d := s.cfg.Backoff
+	for i := 1; i < attempts && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.MaxBackoff)
+}
+
+func outcome(status entity.DeliveryStatus) string {
+	if status == entity.DeliveryPending {
+		return "retry"
+	}
+	return string(status)
+}
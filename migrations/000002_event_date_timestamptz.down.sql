@@ -0,0 +1,3 @@
+alter table pismo.transaction
+    alter column event_date type timestamp
+    using event_date at time zone 'America/Sao_Paulo';
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- event_date was stored as America/Sao_Paulo wall-clock time without an offset,
+-- so existing rows are interpreted in that zone when converting.
+alter table pismo.transaction
+    alter column event_date type timestamptz
+    using event_date at time zone 'America/Sao_Paulo';
\ No newline at end of file
@@ -0,0 +1 @@
+drop table if exists pismo.api_key;
\ No newline at end of file
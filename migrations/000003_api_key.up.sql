@@ -0,0 +1,8 @@
+create table if not exists pismo.api_key (
+    id serial primary key,
+    name varchar(255) not null unique,
+    key_hash char(64) not null unique,
+    scopes text[] not null,
+    created_at timestamptz not null default now(),
+    revoked_at timestamptz
+);
\ No newline at end of file
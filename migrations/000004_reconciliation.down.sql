@@ -0,0 +1,4 @@
+drop table if exists pismo.adjustment;
+
+-- The adjustment operation types stay, since transactions may reference them;
+-- the up migration does not create them again.
\ No newline at end of file
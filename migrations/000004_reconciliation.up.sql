@@ -0,0 +1,22 @@
+insert into pismo.operation_type (description, positive_amount)
+select 'AJUSTE CREDITO', true
+where not exists (select 1 from pismo.operation_type where description = 'AJUSTE CREDITO');
+insert into pismo.operation_type (description, positive_amount)
+select 'AJUSTE DEBITO', false
+where not exists (select 1 from pismo.operation_type where description = 'AJUSTE DEBITO');
+
+-- Audit trail of the adjustments posted by balance reconciliations.
+create table if not exists pismo.adjustment (
+    id serial primary key,
+    transaction_id integer not null unique,
+    adjusted_transaction_id integer not null,
+    reason text not null,
+    created_by varchar(255) not null,
+    created_at timestamptz not null default now(),
+    foreign key (transaction_id) references pismo.transaction(id),
+    foreign key (adjusted_transaction_id) references pismo.transaction(id)
+);
+
+-- A transaction is corrected once, even when reconciliations run
+-- concurrently.
+create unique index if not exists adjustment_adjusted_transaction_id_key on pismo.adjustment (adjusted_transaction_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+drop table if exists pismo.outbox_relay;
+
+drop table if exists pismo.outbox;
\ No newline at end of file
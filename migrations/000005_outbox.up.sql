@@ -0,0 +1,23 @@
+-- Domain events written in the same database transaction as the change they
+-- describe, and relayed to the publisher in id order.
+create table if not exists pismo.outbox (
+    id bigserial primary key,
+    type varchar(64) not null,
+    account_id integer not null,
+    payload jsonb not null,
+    created_at timestamptz not null default now(),
+    published_at timestamptz
+);
+
+create index if not exists outbox_unpublished_idx on pismo.outbox (id) where published_at is null;
+
+-- Position of the outbox relay: every event up to last_id was relayed or
+-- failed, and gap_since is when the relay first met an id missing after it,
+-- taken by a transaction that may still commit.
+create table if not exists pismo.outbox_relay (
+    id boolean primary key default true check (id),
+    last_id bigint not null,
+    gap_since timestamptz
+);
+
+insert into pismo.outbox_relay (last_id) values (0) on conflict do nothing;
\ No newline at end of file
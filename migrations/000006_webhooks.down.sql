@@ -0,0 +1,2 @@
+drop table if exists pismo.webhook_delivery;
+drop table if exists pismo.webhook_subscription;
\ No newline at end of file
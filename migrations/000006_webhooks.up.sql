@@ -0,0 +1,31 @@
+-- Partners subscribed to the domain events of their accounts. Empty arrays
+-- match every event type or account.
+create table if not exists pismo.webhook_subscription (
+    id serial primary key,
+    url text not null,
+    secret varchar(128) not null,
+    event_types text[] not null default '{}',
+    account_ids integer[] not null default '{}',
+    created_at timestamptz not null default now()
+);
+
+-- One row per event and subscription, updated after every attempt. Rows in
+-- the dead status form the dead-letter list.
+create table if not exists pismo.webhook_delivery (
+    id bigserial primary key,
+    subscription_id integer not null references pismo.webhook_subscription(id) on delete cascade,
+    event_id bigint not null references pismo.outbox(id),
+    event_type varchar(64) not null,
+    payload jsonb not null,
+    status varchar(16) not null default 'pending',
+    attempts integer not null default 0,
+    next_attempt_at timestamptz not null default now(),
+    last_attempt_at timestamptz,
+    last_status_code integer,
+    last_error text,
+    delivered_at timestamptz,
+    created_at timestamptz not null default now(),
+    unique (subscription_id, event_id)
+);
+
+create index if not exists webhook_delivery_due_idx on pismo.webhook_delivery (next_attempt_at) where status = 'pending';
\ No newline at end of file
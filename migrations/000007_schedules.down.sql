@@ -0,0 +1,2 @@
+drop table if exists pismo.schedule_run;
+drop table if exists pismo.schedule;
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- Transactions posted at a future time, once or on a recurrence. next_run_at
+-- is null once the schedule is completed or canceled.
+create table if not exists pismo.schedule (
+    id serial primary key,
+    account_id integer not null references pismo.account(id),
+    operation_type_id integer not null references pismo.operation_type(id),
+    amount numeric not null,
+    recurrence varchar(16) not null,
+    day integer,
+    start_at timestamptz not null,
+    end_at timestamptz,
+    next_run_at timestamptz,
+    status varchar(16) not null default 'active',
+    created_by varchar(255) not null,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists schedule_due_idx on pismo.schedule (next_run_at) where status = 'active';
+create index if not exists schedule_account_id_idx on pismo.schedule (account_id);
+
+-- One row per run of a schedule. The unique key keeps a run from being
+-- posted twice.
+create table if not exists pismo.schedule_run (
+    id bigserial primary key,
+    schedule_id integer not null references pismo.schedule(id),
+    due_at timestamptz not null,
+    status varchar(16) not null,
+    transaction_id integer references pismo.transaction(id),
+    error text,
+    started_at timestamptz not null,
+    finished_at timestamptz,
+    unique (schedule_id, due_at)
+);
+
+create index if not exists schedule_run_running_idx on pismo.schedule_run (started_at) where status = 'running';
\ No newline at end of file
@@ -0,0 +1,28 @@
+insert into pismo.operation_type (description, positive_amount)
+select 'CREDITO PROVISORIO', true
+where not exists (select 1 from pismo.operation_type where description = 'CREDITO PROVISORIO');
+insert into pismo.operation_type (description, positive_amount)
+select 'ESTORNO CREDITO PROVISORIO', false
+where not exists (select 1 from pismo.operation_type where description = 'ESTORNO CREDITO PROVISORIO');
+
+-- Cardholder disputes of purchases. The credit and its reversal are the
+-- transactions posted as the dispute moves on.
+create table if not exists pismo.dispute (
+    id serial primary key,
+    transaction_id integer not null references pismo.transaction(id),
+    account_id integer not null references pismo.account(id),
+    amount numeric not null check (amount > 0),
+    reason text not null,
+    status varchar(32) not null default 'opened',
+    credit_transaction_id integer references pismo.transaction(id),
+    reversal_transaction_id integer references pismo.transaction(id),
+    credit_due_at timestamptz not null,
+    resolve_by timestamptz not null,
+    opened_by varchar(255) not null,
+    opened_at timestamptz not null default now(),
+    resolved_at timestamptz
+);
+
+-- A purchase has at most one dispute under way or won.
+create unique index if not exists dispute_transaction_id_idx on pismo.dispute (transaction_id) where status in ('opened', 'provisional_credit', 'won');
+create index if not exists dispute_account_id_idx on pismo.dispute (account_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+drop index if exists pismo.transaction_account_id_event_date_idx;
+drop table if exists pismo.fraud_decision;
\ No newline at end of file
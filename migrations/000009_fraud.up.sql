@@ -0,0 +1,19 @@
+-- Screening decisions on new transactions. Denied transactions were never
+-- created, so their decision has no transaction_id.
+create table if not exists pismo.fraud_decision (
+    id bigserial primary key,
+    transaction_id integer references pismo.transaction(id),
+    account_id integer not null references pismo.account(id),
+    operation_type_id integer not null references pismo.operation_type(id),
+    amount numeric not null,
+    action varchar(16) not null,
+    rule varchar(255) not null default '',
+    reason text not null default '',
+    created_at timestamptz not null default now()
+);
+
+create index if not exists fraud_decision_account_id_idx on pismo.fraud_decision (account_id, id);
+create index if not exists fraud_decision_action_idx on pismo.fraud_decision (action, id) where action <> 'allow';
+
+-- Rules look at the recent transactions of an account.
+create index if not exists transaction_account_id_event_date_idx on pismo.transaction (account_id, event_date);
\ No newline at end of file
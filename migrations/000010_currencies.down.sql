@@ -0,0 +1,8 @@
+drop table if exists pismo.fx_rate;
+
+alter table pismo.transaction
+    drop column if exists fx_rate,
+    drop column if exists original_currency,
+    drop column if exists original_amount;
+
+alter table pismo.account drop column if exists currency;
\ No newline at end of file
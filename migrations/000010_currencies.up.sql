@@ -0,0 +1,31 @@
+-- Accounts hold a single currency, BRL for the existing ones. Transactions
+-- keep the amount as entered, its currency and the rate that converted it
+-- into the currency of the account.
+alter table pismo.account add column if not exists currency char(3) not null default 'BRL';
+
+alter table pismo.transaction
+    add column if not exists original_amount numeric,
+    add column if not exists original_currency char(3),
+    add column if not exists fx_rate numeric;
+
+update pismo.transaction t
+set original_amount = t.amount, original_currency = a.currency, fx_rate = 1
+from pismo.account a
+where a.id = t.account_id and t.original_amount is null;
+
+alter table pismo.transaction
+    alter column original_amount set not null,
+    alter column original_currency set not null,
+    alter column fx_rate set not null;
+
+-- One unit of base_currency is worth rate units of quote_currency from
+-- effective_at on, until a later rate of the pair takes effect.
+create table if not exists pismo.fx_rate (
+    id serial primary key,
+    base_currency char(3) not null,
+    quote_currency char(3) not null,
+    rate numeric not null check (rate > 0),
+    effective_at timestamptz not null,
+    created_at timestamptz not null default now(),
+    unique (base_currency, quote_currency, effective_at)
+);
\ No newline at end of file
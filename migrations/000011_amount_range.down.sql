@@ -0,0 +1,7 @@
+alter table pismo.dispute drop constraint if exists dispute_amount_range;
+
+alter table pismo.schedule drop constraint if exists schedule_amount_range;
+
+alter table pismo.transaction
+    drop constraint if exists transaction_original_amount_range,
+    drop constraint if exists transaction_amount_range;
\ No newline at end of file
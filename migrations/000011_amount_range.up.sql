@@ -0,0 +1,12 @@
+-- Amounts have at most 4 decimal places and 15 integer digits. Adding the
+-- constraints fails while a stored amount breaks them, so that it is fixed
+-- rather than silently rounded.
+alter table pismo.transaction
+    add constraint transaction_amount_range check (amount = trunc(amount, 4) and abs(amount) < 1e15),
+    add constraint transaction_original_amount_range check (original_amount = trunc(original_amount, 4) and abs(original_amount) < 1e15);
+
+alter table pismo.schedule
+    add constraint schedule_amount_range check (amount = trunc(amount, 4) and abs(amount) < 1e15);
+
+alter table pismo.dispute
+    add constraint dispute_amount_range check (amount = trunc(amount, 4) and abs(amount) < 1e15);
\ No newline at end of file
@@ -0,0 +1,3 @@
+alter table pismo.account drop column if exists customer_id;
+
+drop table if exists pismo.customer;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- Customers own accounts. Each document number of the existing accounts
+-- becomes a customer, without a name and pending KYC, owning the accounts
+-- opened with it. Accounts keep the document number of their customer.
+create table if not exists pismo.customer (
+    id serial primary key,
+    document_number varchar(255) not null unique,
+    name varchar(255) not null default '',
+    kyc_status varchar(16) not null default 'pending',
+    email varchar(255) not null default '',
+    phone varchar(16) not null default '',
+    created_at timestamptz not null default now()
+);
+
+insert into pismo.customer (document_number)
+select distinct document_number from pismo.account
+on conflict (document_number) do nothing;
+
+alter table pismo.account add column if not exists customer_id integer references pismo.customer(id);
+
+update pismo.account a
+set customer_id = c.id
+from pismo.customer c
+where c.document_number = a.document_number and a.customer_id is null;
+
+alter table pismo.account alter column customer_id set not null;
+
+create index if not exists account_customer_id_idx on pismo.account (customer_id);
\ No newline at end of file
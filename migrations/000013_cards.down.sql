@@ -0,0 +1,3 @@
+alter table pismo.transaction drop column if exists card_id;
+
+drop table if exists pismo.card;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- Cards are issued against accounts. The PAN is only stored encrypted, with
+-- a keyed fingerprint that keeps it unique, and its masked form for display.
+create table if not exists pismo.card (
+    id serial primary key,
+    token varchar(64) not null unique,
+    account_id integer not null references pismo.account(id),
+    pan_ciphertext bytea not null,
+    pan_fingerprint varchar(64) not null unique,
+    masked_pan varchar(19) not null,
+    expiry_month smallint not null check (expiry_month between 1 and 12),
+    expiry_year smallint not null,
+    status varchar(16) not null default 'active',
+    created_at timestamptz not null default now()
+);
+
+create index if not exists card_account_id_idx on pismo.card (account_id);
+
+alter table pismo.transaction add column if not exists card_id integer references pismo.card(id);
\ No newline at end of file
@@ -61,21 +61,47 @@ func (s *accountSvcTestSuite) TestGetAccountByID() {
 func (s *accountSvcTestSuite) TestCreateAccount() {
 	s.T().Run("success", func(t *testing.T) {
 		acc := entity.Account{DocumentNumber: "123456"}
-		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(nil)
-		err := s.accSvc.CreateAccount(s.ctx, acc)
+		s.repo.EXPECT().CreateAccount(gomock.Any(), entity.Account{DocumentNumber: "123456", Currency: "BRL"}).
+			Return(entity.Account{ID: 7, DocumentNumber: "123456", Currency: "BRL", CustomerID: 3}, nil)
+		created, err := s.accSvc.CreateAccount(s.ctx, acc)
 		s.NoError(err)
+		s.Equal(entity.Account{ID: 7, DocumentNumber: "123456", Currency: "BRL", CustomerID: 3}, created)
+	})
+
+	s.T().Run("currency", func(t *testing.T) {
+		acc := entity.Account{DocumentNumber: "123456", Currency: "JPY"}
+		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(entity.Account{ID: 8, DocumentNumber: "123456", Currency: "JPY", CustomerID: 3}, nil)
+		created, err := s.accSvc.CreateAccount(s.ctx, acc)
+		s.NoError(err)
+		s.Equal(entity.Currency("JPY"), created.Currency)
+
+		_, err = s.accSvc.CreateAccount(s.ctx, entity.Account{DocumentNumber: "123456", Currency: "XXX"})
+		s.ErrorIs(err, entity.ErrInvalidCurrency)
 	})
 
 	s.T().Run("repo error", func(t *testing.T) {
-		acc := entity.Account{DocumentNumber: "123456"}
-		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(errors.New("error"))
-		err := s.accSvc.CreateAccount(s.ctx, acc)
+		acc := entity.Account{DocumentNumber: "123456", Currency: "BRL"}
+		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(entity.Account{}, errors.New("error"))
+		_, err := s.accSvc.CreateAccount(s.ctx, acc)
 		s.Error(err)
 	})
 
+	s.T().Run("for a customer", func(t *testing.T) {
+		acc := entity.Account{CustomerID: 3, Currency: "USD"}
+		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(entity.Account{ID: 9, DocumentNumber: "123456", Currency: "USD", CustomerID: 3}, nil)
+		created, err := s.accSvc.CreateAccount(s.ctx, acc)
+		s.NoError(err)
+		s.Equal("123456", created.DocumentNumber)
+
+		acc.CustomerID = 4
+		s.repo.EXPECT().CreateAccount(gomock.Any(), acc).Return(entity.Account{}, entity.ErrCustomerNotFound)
+		_, err = s.accSvc.CreateAccount(s.ctx, acc)
+		s.ErrorIs(err, entity.ErrCustomerNotFound)
+	})
+
 	s.T().Run("missing document number", func(t *testing.T) {
 		acc := entity.Account{}
-		err := s.accSvc.CreateAccount(s.ctx, acc)
+		_, err := s.accSvc.CreateAccount(s.ctx, acc)
 		s.Error(err)
 		s.True(errors.Is(err, entity.ErrMissingDocumentNumber))
 	})
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseAmountLimits(t *testing.T) {
+	limits, err := entity.ParseAmountLimits("default=max:1000000; KWD=scale:2 ;3=scale:0,max:5000")
+	require.NoError(t, err)
+	assert.Equal(t, "1000000", limits.Default.Max.String())
+	assert.Nil(t, limits.Default.Scale)
+	assert.Equal(t, int32(2), *limits.Currencies["KWD"].Scale)
+	assert.Equal(t, int32(0), *limits.OperationTypes[3].Scale)
+	assert.Equal(t, "5000", limits.OperationTypes[3].Max.String())
+
+	limits, err = entity.ParseAmountLimits("")
+	require.NoError(t, err)
+	assert.Equal(t, entity.MaxAmount, limits.Max("BRL", 1))
+
+	for _, s := range []string{
+		"default",
+		"XXX=max:10",
+		"0=max:10",
+		"default=scale:5",
+		"default=scale:-1",
+		"default=max:0",
+		"default=max:1e15",
+		"default=min:1",
+	} {
+		_, err := entity.ParseAmountLimits(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestAmountLimits(t *testing.T) {
+	d := decimal.RequireFromString
+	limits, err := entity.ParseAmountLimits("default=max:1000000;JPY=max:150000000;KWD=scale:2;BRL=scale:4;3=scale:0,max:5000")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		amount   string
+		currency entity.Currency
+		opTypeID int
+		err      error
+	}{
+		{"10.25", "USD", 1, nil},
+		{"10.255", "USD", 1, entity.ErrAmountPrecision},
+		{"10.2555", "BRL", 1, nil},
+		{"10.125", "KWD", 1, entity.ErrAmountPrecision},
+		{"10.12", "KWD", 1, nil},
+		{"1.5", "JPY", 1, entity.ErrAmountPrecision},
+		{"-1000000", "USD", 1, nil},
+		{"-1000000.01", "USD", 1, entity.ErrAmountOutOfRange},
+		{"2000000", "JPY", 1, entity.ErrAmountOutOfRange},
+		{"10.5", "USD", 3, entity.ErrAmountPrecision},
+		{"5001", "USD", 3, entity.ErrAmountOutOfRange},
+		{"5000", "USD", 3, nil},
+	} {
+		err := limits.Check(d(tc.amount), tc.currency, tc.opTypeID)
+		if tc.err == nil {
+			assert.NoError(t, err, tc.amount, tc.currency, tc.opTypeID)
+			continue
+		}
+		assert.ErrorIs(t, err, tc.err, tc.amount, tc.currency, tc.opTypeID)
+		assert.Len(t, entity.FieldErrors(err), 1)
+	}
+
+	t.Run("converted amount", func(t *testing.T) {
+		rate := &entity.FXRate{Base: "USD", Quote: "BRL", Rate: d("5.12345")}
+		tx := entity.Transaction{OperationTypeID: 1, Amount: d("10"), OriginalCurrency: "USD"}
+		require.NoError(t, tx.Convert("BRL", rate, limits))
+		assert.Equal(t, "51.2345", tx.Amount.String(), "rounded to the scale of BRL")
+
+		tx = entity.Transaction{OperationTypeID: 3, Amount: d("10"), OriginalCurrency: "USD"}
+		require.NoError(t, tx.Convert("BRL", rate, limits))
+		assert.Equal(t, "51", tx.Amount.String(), "rounded to the scale of the operation type")
+
+		tx = entity.Transaction{OperationTypeID: 3, Amount: d("1000"), OriginalCurrency: "USD"}
+		assert.ErrorIs(t, tx.Convert("BRL", rate, limits), entity.ErrAmountOutOfRange)
+	})
+}
+
+func TestTransactionAmountLimits(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Now().Return(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)).AnyTimes()
+	opTypes := entity.OperationType{4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true}}
+	limits, err := entity.ParseAmountLimits("4=max:5000")
+	require.NoError(t, err)
+	svc := service.NewTransactionService(cl, repo, opTypes, limits, nil)
+
+	repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+	_, err = svc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(5001)})
+	assert.ErrorIs(t, err, entity.ErrAmountOutOfRange)
+
+	_, err = svc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.RequireFromString("1e30")})
+	assert.ErrorIs(t, err, entity.ErrAmountOutOfRange)
+}
@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/auth"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestJWTVerifier(t *testing.T) {
+	secret := "secret"
+	exp := time.Now().Add(time.Hour).Unix()
+
+	t.Run("hs256", func(t *testing.T) {
+		v, err := auth.NewJWTVerifier(secret, "", "issuer", "")
+		require.NoError(t, err)
+		token := signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{
+			"sub": "client", "iss": "issuer", "exp": exp, "scope": "accounts:read transactions:write",
+		})
+		p, err := v.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, "client", p.Subject)
+		assert.Equal(t, auth.MethodJWT, p.Method)
+		assert.True(t, p.HasScope(auth.ScopeAccountsRead))
+		assert.False(t, p.HasScope(auth.ScopeAdmin))
+	})
+
+	t.Run("rs256", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "public.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+		v, err := auth.NewJWTVerifier("", path, "", "")
+		require.NoError(t, err)
+		p, err := v.Verify(signToken(t, jwt.SigningMethodRS256, key, jwt.MapClaims{"sub": "client", "exp": exp, "scope": "admin"}))
+		require.NoError(t, err)
+		assert.True(t, p.HasScope(auth.ScopeTransactionsWrite))
+
+		_, err = v.Verify(signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{"sub": "client", "exp": exp}))
+		assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		v, err := auth.NewJWTVerifier(secret, "", "issuer", "")
+		require.NoError(t, err)
+		for name, token := range map[string]string{
+			"expired":       signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{"sub": "c", "iss": "issuer", "exp": time.Now().Add(-time.Hour).Unix()}),
+			"no expiration": signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{"sub": "c", "iss": "issuer"}),
+			"wrong secret":  signToken(t, jwt.SigningMethodHS256, []byte("other"), jwt.MapClaims{"sub": "c", "iss": "issuer", "exp": exp}),
+			"wrong issuer":  signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{"sub": "c", "iss": "other", "exp": exp}),
+			"missing sub":   signToken(t, jwt.SigningMethodHS256, []byte(secret), jwt.MapClaims{"iss": "issuer", "exp": exp}),
+			"alg none":      signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"sub": "c", "iss": "issuer", "exp": exp}),
+			"malformed":     "not-a-token",
+		} {
+			_, err := v.Verify(token)
+			assert.ErrorIs(t, err, auth.ErrInvalidToken, name)
+		}
+	})
+}
+
+type authSvcTestSuite struct {
+	suite.Suite
+	ctrl    *gomock.Controller
+	ctx     context.Context
+	repo    *mocks.MockRepository
+	authSvc service.AuthService
+}
+
+func TestAuthSvcSuite(t *testing.T) {
+	suite.Run(t, new(authSvcTestSuite))
+}
+
+func (s *authSvcTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.repo = mocks.NewMockRepository(s.ctrl)
+	v, err := auth.NewJWTVerifier("secret", "", "", "")
+	s.Require().NoError(err)
+	s.authSvc = service.NewAuthService(s.repo, v)
+}
+
+func (s *authSvcTestSuite) TestAuthenticateAPIKey() {
+	s.T().Run("success", func(t *testing.T) {
+		key := entity.APIKey{Name: "integrator", Scopes: []string{auth.ScopeTransactionsWrite}}
+		s.repo.EXPECT().FindAPIKeyByHash(gomock.Any(), auth.HashAPIKey("key")).Return(&key, nil)
+		p, err := s.authSvc.AuthenticateAPIKey(s.ctx, "key")
+		s.NoError(err)
+		s.Equal(&auth.Principal{Subject: "integrator", Method: auth.MethodAPIKey, Scopes: key.Scopes}, p)
+	})
+
+	s.T().Run("unknown key", func(t *testing.T) {
+		s.repo.EXPECT().FindAPIKeyByHash(gomock.Any(), gomock.Any()).Return(nil, nil)
+		_, err := s.authSvc.AuthenticateAPIKey(s.ctx, "key")
+		s.ErrorIs(err, auth.ErrInvalidAPIKey)
+	})
+
+	s.T().Run("revoked key", func(t *testing.T) {
+		now := time.Now()
+		s.repo.EXPECT().FindAPIKeyByHash(gomock.Any(), gomock.Any()).Return(&entity.APIKey{Name: "old", RevokedAt: &now}, nil)
+		_, err := s.authSvc.AuthenticateAPIKey(s.ctx, "key")
+		s.ErrorIs(err, auth.ErrInvalidAPIKey)
+	})
+
+	s.T().Run("repo error", func(t *testing.T) {
+		s.repo.EXPECT().FindAPIKeyByHash(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+		_, err := s.authSvc.AuthenticateAPIKey(s.ctx, "key")
+		s.Error(err)
+		s.NotErrorIs(err, auth.ErrInvalidAPIKey)
+	})
+}
+
+func (s *authSvcTestSuite) TestCreateAPIKey() {
+	s.T().Run("stores only the hash", func(t *testing.T) {
+		var stored entity.APIKey
+		s.repo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k entity.APIKey) error {
+			stored = k
+			return nil
+		})
+		key, err := s.authSvc.CreateAPIKey(s.ctx, entity.APIKey{Name: "integrator", Scopes: []string{auth.ScopeAccountsRead}})
+		s.NoError(err)
+		s.Len(key, 64)
+		s.Equal(auth.HashAPIKey(key), stored.KeyHash)
+		s.NotContains(stored.KeyHash, key)
+	})
+
+	s.T().Run("missing scopes", func(t *testing.T) {
+		_, err := s.authSvc.CreateAPIKey(s.ctx, entity.APIKey{Name: "integrator"})
+		s.ErrorIs(err, entity.ErrMissingAPIKeyScopes)
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	authSvc := mocks.NewMockAuthService(ctrl)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{}, server.Deps{Auth: authSvc, Accounts: accSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path string, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(`{}`))
+		require.NoError(t, err)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	reader := &auth.Principal{Subject: "reader", Scopes: []string{auth.ScopeAccountsRead}}
+
+	t.Run("missing credentials", func(t *testing.T) {
+		resp := do(http.MethodGet, "/accounts/1", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+	})
+
+	t.Run("invalid api key", func(t *testing.T) {
+		authSvc.EXPECT().AuthenticateAPIKey(gomock.Any(), "bad").Return(nil, auth.ErrInvalidAPIKey)
+		resp := do(http.MethodGet, "/accounts/1", map[string]string{"X-API-Key": "bad"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("api key with scope", func(t *testing.T) {
+		authSvc.EXPECT().AuthenticateAPIKey(gomock.Any(), "good").Return(reader, nil)
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, _ int) (*entity.Account, error) {
+			assert.Equal(t, reader, auth.FromContext(ctx))
+			return &entity.Account{ID: 1}, nil
+		})
+		resp := do(http.MethodGet, "/accounts/1", map[string]string{"X-API-Key": "good"})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("bearer token missing scope", func(t *testing.T) {
+		authSvc.EXPECT().AuthenticateToken(gomock.Any(), "token").Return(reader, nil)
+		resp := do(http.MethodPost, "/transactions", map[string]string{"Authorization": "Bearer token"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("probes are public", func(t *testing.T) {
+		resp := do(http.MethodGet, "/livez", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("metrics require their scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/metrics", nil).StatusCode)
+
+		authSvc.EXPECT().AuthenticateAPIKey(gomock.Any(), "reader").Return(reader, nil)
+		assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/metrics", map[string]string{"X-API-Key": "reader"}).StatusCode)
+
+		scraper := &auth.Principal{Subject: "prometheus", Scopes: []string{auth.ScopeMetrics}}
+		authSvc.EXPECT().AuthenticateAPIKey(gomock.Any(), "scraper").Return(scraper, nil)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/metrics", map[string]string{"X-API-Key": "scraper"}).StatusCode)
+	})
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTransactionBatchHandler(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	cfg := &config.Config{AuthDisabled: true, BatchMaxBytes: 1 << 20, BatchMaxItems: 3}
+	srv := server.NewServer(ctx, cfg, server.Deps{Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	post := func(query, contentType, body string) (*http.Response, entity.BatchResult) {
+		resp, err := http.Post(ts.URL+"/v1/transactions/batch"+query, contentType, strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var result entity.BatchResult
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	t.Run("json array", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransactions(gomock.Any(), gomock.Any(), entity.BatchAllOrNothing).
+			DoAndReturn(func(_ context.Context, items []entity.BatchItem, mode entity.BatchMode) (entity.BatchResult, error) {
+				require.Len(t, items, 2)
+				assert.Equal(t, 1, items[1].Index)
+				assert.True(t, decimal.RequireFromString("1.5").Equal(items[1].Transaction.Amount))
+				return entity.BatchResult{Mode: mode, Created: 2, Items: []entity.BatchItemResult{
+					{Index: 0, Status: entity.BatchItemCreated, ID: 1},
+					{Index: 1, Status: entity.BatchItemCreated, ID: 2},
+				}}, nil
+			})
+		resp, result := post("", "application/json",
+			`[{"account_id":1,"operation_type_id":1,"amount":10},{"account_id":2,"operation_type_id":4,"amount":"1.5"}]`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, 2, result.Created)
+	})
+
+	t.Run("ndjson keeps undecodable lines as failed items", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransactions(gomock.Any(), gomock.Any(), entity.BatchBestEffort).
+			DoAndReturn(func(_ context.Context, items []entity.BatchItem, mode entity.BatchMode) (entity.BatchResult, error) {
+				require.Len(t, items, 3)
+				assert.NoError(t, items[0].Err)
+				assert.EqualError(t, items[1].Err, "id: unknown field")
+				assert.Error(t, items[2].Err)
+				return entity.BatchResult{Mode: mode, Created: 1, Failed: 2}, nil
+			})
+		body := "{\"account_id\":1,\"operation_type_id\":1,\"amount\":10}\n\n{\"id\":1}\n{not json\n"
+		resp, _ := post("?mode=best_effort", "application/x-ndjson", body)
+		assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	})
+
+	t.Run("nothing created", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransactions(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(entity.BatchResult{Failed: 1}, nil)
+		resp, _ := post("", "application/json", `[{"account_id":0}]`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("too many items", func(t *testing.T) {
+		resp, _ := post("", "application/json", `[{},{},{},{}]`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		resp, _ := post("?mode=some", "application/json", `[{}]`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		resp, _ := post("", "application/json", `{"account_id":1}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp, _ := post("", "application/json", `[]`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		resp, _ := post("", "text/csv", "1,1,10")
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+}
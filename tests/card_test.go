@@ -0,0 +1,366 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/card"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLuhn(t *testing.T) {
+	for _, pan := range []string{"4242424242424242", "79927398713", "5555555555554444", "378282246310005"} {
+		assert.True(t, card.LuhnValid(pan), pan)
+	}
+	for _, pan := range []string{"4242424242424241", "79927398710", "", "4", "42424242424242a2"} {
+		assert.False(t, card.LuhnValid(pan), pan)
+	}
+}
+
+func TestGeneratePAN(t *testing.T) {
+	for range 50 {
+		pan, err := card.GeneratePAN("999999", 16, rand.Reader)
+		require.NoError(t, err)
+		assert.Len(t, pan, 16)
+		assert.True(t, strings.HasPrefix(pan, "999999"))
+		assert.True(t, card.LuhnValid(pan), pan)
+	}
+	pan, err := card.GeneratePAN("45678901", 19, rand.Reader)
+	require.NoError(t, err)
+	assert.Len(t, pan, 19)
+	assert.True(t, card.LuhnValid(pan), pan)
+
+	_, err = card.GeneratePAN("12345", 16, rand.Reader)
+	assert.ErrorIs(t, err, card.ErrInvalidBIN)
+	_, err = card.GeneratePAN("12345a", 16, rand.Reader)
+	assert.ErrorIs(t, err, card.ErrInvalidBIN)
+	_, err = card.GeneratePAN("999999", 20, rand.Reader)
+	assert.ErrorIs(t, err, card.ErrInvalidPANLength)
+
+	assert.Equal(t, "999999******4242", card.Mask("9999994242424242"))
+}
+
+func TestVault(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	v, err := card.NewVault(key, rand.Reader)
+	require.NoError(t, err)
+
+	sealed, err := v.Seal("9999994242424242")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "4242")
+	again, err := v.Seal("9999994242424242")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "each seal uses a new nonce")
+
+	pan, err := v.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "9999994242424242", pan)
+	assert.Equal(t, v.Fingerprint("9999994242424242"), v.Fingerprint("9999994242424242"))
+	assert.NotEqual(t, v.Fingerprint("9999994242424242"), v.Fingerprint("9999994242424243"))
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = v.Open(sealed)
+	assert.ErrorIs(t, err, card.ErrInvalidCiphertext)
+
+	other, err := card.NewVault(bytes.Repeat([]byte{8}, 32), rand.Reader)
+	require.NoError(t, err)
+	_, err = other.Open(again)
+	assert.ErrorIs(t, err, card.ErrInvalidCiphertext)
+
+	_, err = card.ParseKey("c2hvcnQ=")
+	assert.ErrorIs(t, err, card.ErrInvalidKey)
+	parsed, err := card.ParseKey("BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+}
+
+func TestIssuer(t *testing.T) {
+	v, err := card.NewVault(bytes.Repeat([]byte{7}, 32), rand.Reader)
+	require.NoError(t, err)
+	issuer, err := card.NewIssuer("999999", 16, 48, v, rand.Reader)
+	require.NoError(t, err)
+
+	c, err := issuer.Issue(3, time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.AccountID)
+	assert.Equal(t, entity.CardActive, c.Status)
+	assert.Equal(t, 1, c.ExpiryMonth)
+	assert.Equal(t, 2028, c.ExpiryYear)
+	assert.True(t, strings.HasPrefix(c.Token, "card_"))
+	assert.Regexp(t, `^999999\*{6}[0-9]{4}$`, c.MaskedPAN)
+
+	pan, err := v.Open(c.EncryptedPAN)
+	require.NoError(t, err)
+	assert.True(t, card.LuhnValid(pan))
+	assert.Equal(t, card.Mask(pan), c.MaskedPAN)
+	assert.Equal(t, v.Fingerprint(pan), c.Fingerprint)
+
+	_, err = card.NewIssuer("999", 16, 48, v, rand.Reader)
+	assert.ErrorIs(t, err, card.ErrInvalidBIN)
+}
+
+func TestCardUsable(t *testing.T) {
+	c := entity.Card{ID: 2, AccountID: 1, Status: entity.CardActive, ExpiryMonth: 2, ExpiryYear: 2024}
+	assert.NoError(t, c.Usable(time.Date(2024, 2, 29, 23, 59, 0, 0, time.UTC)), "valid through the expiry month")
+	assert.ErrorIs(t, c.Usable(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), entity.ErrCardExpired)
+
+	c.Status = entity.CardBlocked
+	assert.ErrorIs(t, c.Usable(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), entity.ErrCardBlocked)
+
+	c.Status = entity.CardActive
+	tx := entity.Transaction{CardToken: "card_x", EventDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, tx.UseCard(&c))
+	assert.Equal(t, 1, tx.AccountID)
+	assert.Equal(t, 2, *tx.CardID)
+
+	tx = entity.Transaction{AccountID: 5, EventDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert.ErrorIs(t, tx.UseCard(&c), entity.ErrCardAccount)
+	assert.ErrorIs(t, tx.UseCard(nil), entity.ErrCardNotFound)
+}
+
+func TestCardTransactions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	opTypes := entity.OperationType{1: &entity.Operation{Description: "COMPRA A VISTA"}}
+	newService := func(t *testing.T) (service.TransactionService, *mocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		return service.NewTransactionService(cl, repo, opTypes, entity.AmountLimits{}, nil), repo
+	}
+	active := entity.Card{ID: 4, Token: "card_a", AccountID: 7, Status: entity.CardActive, ExpiryMonth: 12, ExpiryYear: 2027}
+	token := func(s string) entity.CardFilter { return entity.CardFilter{Token: &s} }
+
+	t.Run("resolves the account of the card", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindCards(gomock.Any(), token("card_a")).Return([]entity.Card{active}, nil)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{7}).Return(map[int]entity.Currency{7: "BRL"}, nil)
+		repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (int, error) {
+			assert.Equal(t, 7, tx.AccountID)
+			require.NotNil(t, tx.CardID)
+			assert.Equal(t, 4, *tx.CardID)
+			assert.Equal(t, "-10", tx.Amount.String())
+			return 9, nil
+		})
+		created, err := svc.CreateTransaction(ctx, entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(10)})
+		require.NoError(t, err)
+		assert.Equal(t, 9, created.ID)
+	})
+
+	t.Run("rejects unusable cards", func(t *testing.T) {
+		svc, repo := newService(t)
+		blocked := active
+		blocked.Status = entity.CardBlocked
+		repo.EXPECT().FindCards(gomock.Any(), token("card_a")).Return([]entity.Card{blocked}, nil)
+		_, err := svc.CreateTransaction(ctx, entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(10)})
+		assert.ErrorIs(t, err, entity.ErrCardBlocked)
+
+		expired := active
+		expired.ExpiryYear = 2023
+		repo.EXPECT().FindCards(gomock.Any(), token("card_a")).Return([]entity.Card{expired}, nil)
+		_, err = svc.CreateTransaction(ctx, entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(10)})
+		assert.ErrorIs(t, err, entity.ErrCardExpired)
+
+		repo.EXPECT().FindCards(gomock.Any(), token("card_b")).Return(nil, nil)
+		_, err = svc.CreateTransaction(ctx, entity.Transaction{CardToken: "card_b", OperationTypeID: 1, Amount: decimal.NewFromInt(10)})
+		assert.ErrorIs(t, err, entity.ErrCardNotFound)
+		require.Len(t, entity.FieldErrors(err), 1)
+		assert.Equal(t, "card_token", entity.FieldErrors(err)[0].Field)
+	})
+
+	t.Run("batch looks each card up once", func(t *testing.T) {
+		svc, repo := newService(t)
+		blocked := entity.Card{ID: 5, Token: "card_b", AccountID: 8, Status: entity.CardBlocked, ExpiryMonth: 12, ExpiryYear: 2027}
+		repo.EXPECT().FindCards(gomock.Any(), token("card_a")).Return([]entity.Card{active}, nil)
+		repo.EXPECT().FindCards(gomock.Any(), token("card_b")).Return([]entity.Card{blocked}, nil)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{7}).Return(map[int]entity.Currency{7: "BRL"}, nil)
+		repo.EXPECT().CreateTransactions(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, txs []entity.Transaction) ([]int, error) {
+			require.Len(t, txs, 2)
+			for _, tx := range txs {
+				assert.Equal(t, 7, tx.AccountID)
+				assert.Equal(t, 4, *tx.CardID)
+			}
+			return []int{1, 2}, nil
+		})
+		result, err := svc.CreateTransactions(ctx, []entity.BatchItem{
+			{Index: 0, Transaction: entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(10)}},
+			{Index: 1, Transaction: entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(3)}},
+			{Index: 2, Transaction: entity.Transaction{CardToken: "card_b", OperationTypeID: 1, Amount: decimal.NewFromInt(3)}},
+		}, entity.BatchBestEffort)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, entity.ErrCardBlocked.Error(), result.Items[2].Error)
+	})
+
+	t.Run("updates keep the card account", func(t *testing.T) {
+		svc, repo := newService(t)
+		stored := entity.Transaction{ID: 9, AccountID: 7, OperationTypeID: 1, Amount: decimal.NewFromInt(-10), EventDate: now, CardID: &active.ID}
+		byID := entity.TransactionFilter{ID: &stored.ID}
+		repo.EXPECT().FindTransactions(gomock.Any(), byID).Return([]entity.Transaction{stored}, nil).Times(3)
+
+		err := svc.UpdateTransaction(ctx, entity.Transaction{ID: 9, AccountID: 8, OperationTypeID: 1, Amount: decimal.NewFromInt(10), EventDate: now})
+		assert.ErrorIs(t, err, entity.ErrCardAccount)
+		require.Len(t, entity.FieldErrors(err), 1)
+		assert.Equal(t, "account_id", entity.FieldErrors(err)[0].Field)
+
+		err = svc.PatchTransaction(ctx, 9, []byte(`{"account_id":8}`))
+		assert.ErrorIs(t, err, entity.ErrCardAccount)
+
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{7}).Return(map[int]entity.Currency{7: "BRL"}, nil)
+		repo.EXPECT().UpdateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) error {
+			require.NotNil(t, tx.CardID)
+			assert.Equal(t, 4, *tx.CardID)
+			return nil
+		})
+		require.NoError(t, svc.UpdateTransaction(ctx, entity.Transaction{ID: 9, AccountID: 7, OperationTypeID: 1, Amount: decimal.NewFromInt(20), EventDate: now}))
+	})
+}
+
+func TestCardService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	newService := func(t *testing.T, issuer service.CardIssuer) (service.CardService, *mocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		cl.EXPECT().Location().Return(time.UTC).AnyTimes()
+		return service.NewCardService(cl, repo, issuer), repo
+	}
+	accountID := func(id int) entity.AccountFilter { return entity.AccountFilter{ID: &id} }
+
+	t.Run("issue retries taken numbers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		issuer := mocks.NewMockCardIssuer(ctrl)
+		svc, repo := newService(t, issuer)
+		repo.EXPECT().FindAccounts(gomock.Any(), accountID(1)).Return([]entity.Account{{ID: 1}}, nil)
+		issuer.EXPECT().Issue(1, now).Return(entity.Card{Token: "card_a", AccountID: 1}, nil)
+		issuer.EXPECT().Issue(1, now).Return(entity.Card{Token: "card_b", AccountID: 1}, nil)
+		repo.EXPECT().CreateCard(gomock.Any(), entity.Card{Token: "card_a", AccountID: 1}).Return(entity.Card{}, entity.ErrCardExists)
+		repo.EXPECT().CreateCard(gomock.Any(), entity.Card{Token: "card_b", AccountID: 1}).Return(entity.Card{ID: 2, Token: "card_b", AccountID: 1}, nil)
+		c, err := svc.IssueCard(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "card_b", c.Token)
+	})
+
+	t.Run("issue", func(t *testing.T) {
+		svc, _ := newService(t, nil)
+		_, err := svc.IssueCard(ctx, 1)
+		assert.ErrorIs(t, err, entity.ErrCardsDisabled)
+
+		ctrl := gomock.NewController(t)
+		svc, repo := newService(t, mocks.NewMockCardIssuer(ctrl))
+		repo.EXPECT().FindAccounts(gomock.Any(), accountID(2)).Return(nil, nil)
+		_, err = svc.IssueCard(ctx, 2)
+		assert.ErrorIs(t, err, entity.ErrAccountNotFound)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		svc, repo := newService(t, nil)
+		repo.EXPECT().UpdateCardStatus(gomock.Any(), "card_a", entity.CardBlocked).Return(entity.Card{Token: "card_a", Status: entity.CardBlocked}, nil)
+		c, err := svc.SetCardStatus(ctx, "card_a", entity.CardBlocked)
+		require.NoError(t, err)
+		assert.Equal(t, entity.CardBlocked, c.Status)
+
+		_, err = svc.SetCardStatus(ctx, "card_a", "lost")
+		assert.ErrorIs(t, err, entity.ErrInvalidCardStatus)
+	})
+}
+
+func TestCardHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cardSvc := mocks.NewMockCardService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Transactions: txSvc, Cards: cardSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+	issued := entity.Card{
+		ID: 2, Token: "card_a", AccountID: 1, MaskedPAN: "999999******4242", ExpiryMonth: 1, ExpiryYear: 2028,
+		Status: entity.CardActive, CreatedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		EncryptedPAN: []byte("secret"), Fingerprint: "f00d",
+	}
+
+	t.Run("issue", func(t *testing.T) {
+		cardSvc.EXPECT().IssueCard(gomock.Any(), 1).Return(issued, nil)
+		resp, body := do(http.MethodPost, "/v1/accounts/1/cards", "")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/cards/card_a", resp.Header.Get("Location"))
+		assert.JSONEq(t, `{"id":2,"token":"card_a","account_id":1,"masked_pan":"999999******4242",
+			"expiry_month":1,"expiry_year":2028,"status":"active","created_at":"2024-01-02T10:00:00+00:00"}`, body)
+
+		cardSvc.EXPECT().IssueCard(gomock.Any(), 1).Return(entity.Card{}, entity.ErrCardsDisabled)
+		resp, _ = do(http.MethodPost, "/v1/accounts/1/cards", "")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+		cardSvc.EXPECT().IssueCard(gomock.Any(), 3).Return(entity.Card{}, entity.ErrAccountNotFound)
+		resp, _ = do(http.MethodPost, "/v1/accounts/3/cards", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("get and block", func(t *testing.T) {
+		cardSvc.EXPECT().ListCards(gomock.Any(), 1).Return([]entity.Card{issued}, nil)
+		resp, body := do(http.MethodGet, "/v1/accounts/1/cards", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"token":"card_a"`)
+
+		cardSvc.EXPECT().GetCard(gomock.Any(), "card_x").Return(nil, nil)
+		resp, _ = do(http.MethodGet, "/v1/cards/card_x", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		blocked := issued
+		blocked.Status = entity.CardBlocked
+		cardSvc.EXPECT().SetCardStatus(gomock.Any(), "card_a", entity.CardBlocked).Return(blocked, nil)
+		resp, body = do(http.MethodPost, "/v1/cards/card_a/block", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"status":"blocked"`)
+
+		cardSvc.EXPECT().SetCardStatus(gomock.Any(), "card_x", entity.CardActive).Return(entity.Card{}, entity.ErrCardNotFound)
+		resp, _ = do(http.MethodPost, "/v1/cards/card_x/unblock", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("transaction by card token", func(t *testing.T) {
+		cardID := 2
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(10)}).
+			Return(entity.Transaction{ID: 5, AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(-10), CardID: &cardID}, nil)
+		resp, body := do(http.MethodPost, "/v1/transactions", `{"card_token":"card_a","operation_type_id":1,"amount":10}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Contains(t, body, `"card_id":2`)
+		assert.NotContains(t, body, "card_token")
+
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(entity.Transaction{}, entity.ErrCardBlocked)
+		resp, _ = do(http.MethodPost, "/v1/transactions", `{"card_token":"card_a","operation_type_id":1,"amount":10}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/cli"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCLI(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	opSvc := mocks.NewMockOpTypeService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, OpTypes: opSvc, Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := cli.Run(ctx, append([]string{"-config", configPath, "-url", ts.URL}, args...), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("create account as json", func(t *testing.T) {
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{DocumentNumber: "123"}).Return(entity.Account{ID: 5, DocumentNumber: "123"}, nil)
+		code, out, _ := run("-o", "json", "accounts", "create", "-document", "123")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.JSONEq(t, `{"id":5,"document_number":"123"}`, out)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 9).Return(nil, nil)
+		code, _, errOut := run("accounts", "get", "9")
+		assert.Equal(t, cli.ExitNotFound, code)
+		assert.Contains(t, errOut, "account not found")
+	})
+
+	t.Run("balance as table", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 5).Return(&entity.Account{ID: 5, Currency: "BRL"}, nil)
+		accSvc.EXPECT().GetAccountBalance(gomock.Any(), 5).Return(decimal.RequireFromString("-12.5"), nil)
+		code, out, _ := run("accounts", "balance", "5")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Equal(t, "ACCOUNT  BALANCE\n5        -12.5\n", out)
+	})
+
+	t.Run("invalid transaction lists field errors", func(t *testing.T) {
+		code, _, errOut := run("transactions", "create", "-account", "0", "-type", "1", "-amount", "10")
+		assert.Equal(t, cli.ExitInvalid, code)
+		assert.Contains(t, errOut, "account_id:")
+	})
+
+	t.Run("create by card in another currency", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), entity.Transaction{CardToken: "card_a", OperationTypeID: 1, Amount: decimal.NewFromInt(20), OriginalCurrency: "USD"}).
+			Return(entity.Transaction{ID: 8, AccountID: 5, OperationTypeID: 1, Amount: decimal.NewFromInt(-100)}, nil)
+		code, _, _ := run("transactions", "create", "-card", "card_a", "-type", "1", "-amount", "20", "-currency", "USD")
+		assert.Equal(t, cli.ExitOK, code)
+	})
+
+	t.Run("update patches only the given fields", func(t *testing.T) {
+		txSvc.EXPECT().PatchTransaction(gomock.Any(), 3, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, patch []byte) error {
+			assert.JSONEq(t, `{"amount":"7.25"}`, string(patch))
+			return nil
+		})
+		code, _, _ := run("transactions", "update", "3", "-amount", "7.25")
+		assert.Equal(t, cli.ExitOK, code)
+	})
+
+	t.Run("replace requires every field", func(t *testing.T) {
+		code, _, _ := run("transactions", "update", "3", "-amount", "7.25", "-replace")
+		assert.Equal(t, cli.ExitUsage, code)
+		code, _, _ = run("transactions", "update", "3", "-account", "5", "-type", "1", "-amount", "7.25", "-currency", "USD", "-replace")
+		assert.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("replace keeps the given currency", func(t *testing.T) {
+		eventDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		txSvc.EXPECT().UpdateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) error {
+			assert.Equal(t, entity.Currency("USD"), tx.OriginalCurrency)
+			assert.True(t, tx.EventDate.Equal(eventDate))
+			return nil
+		})
+		code, _, _ := run("transactions", "update", "3", "-account", "5", "-type", "1", "-amount", "7.25", "-currency", "USD", "-event-date", "2024-01-02T03:04:05Z", "-replace")
+		assert.Equal(t, cli.ExitOK, code)
+	})
+
+	t.Run("list transactions", func(t *testing.T) {
+		accountID := 5
+		eventDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		txSvc.EXPECT().ListTransactions(gomock.Any(), entity.TransactionFilter{AccountID: &accountID}).Return([]entity.Transaction{
+			{ID: 1, AccountID: 5, OperationTypeID: 4, Amount: decimal.NewFromInt(10), EventDate: eventDate},
+		}, nil)
+		code, out, _ := run("-o", "json", "transactions", "list", "-account", "5")
+		assert.Equal(t, cli.ExitOK, code)
+		var txs []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &txs))
+		require.Len(t, txs, 1)
+		assert.Equal(t, "2024-01-02T03:04:05+00:00", txs[0]["event_date"])
+	})
+
+	t.Run("operation types sorted by id", func(t *testing.T) {
+		opSvc.EXPECT().GetAllOperationTypes(gomock.Any()).Return(entity.OperationType{
+			4: {Description: "PAGAMENTO", PositiveAmount: true},
+			1: {Description: "COMPRA A VISTA"},
+		}, nil)
+		code, out, _ := run("operation-types", "list")
+		assert.Equal(t, cli.ExitOK, code)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[1], "1 "))
+		assert.True(t, strings.HasPrefix(lines[2], "4 "))
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		opSvc.EXPECT().CreateOperationType(gomock.Any(), entity.Operation{Description: "ESTORNO", PositiveAmount: true}).Return(assert.AnError)
+		code, _, _ := run("operation-types", "create", "-description", "ESTORNO", "-positive")
+		assert.Equal(t, cli.ExitServer, code)
+	})
+
+	t.Run("reconcile writes a report usable as closing balances", func(t *testing.T) {
+		asOf := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		balances := []entity.AccountBalance{{AccountID: 5, Balance: decimal.NewFromInt(-12)}}
+		txSvc.EXPECT().ReconcileBalances(gomock.Any(), entity.ReconciliationRequest{}).Return(entity.ReconciliationReport{AsOf: asOf, Balances: balances}, nil)
+		first := filepath.Join(t.TempDir(), "first.json")
+		code, _, _ := run("accounts", "reconcile", "-report", first)
+		require.Equal(t, cli.ExitOK, code)
+
+		txSvc.EXPECT().ReconcileBalances(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req entity.ReconciliationRequest) (entity.ReconciliationReport, error) {
+			require.NotNil(t, req.Closing)
+			assert.True(t, asOf.Equal(req.Closing.AsOf))
+			require.Len(t, req.Closing.Balances, 1)
+			assert.True(t, balances[0].Balance.Equal(req.Closing.Balances[0].Balance))
+			return entity.ReconciliationReport{
+				AsOf:     asOf.Add(time.Hour),
+				Balances: balances,
+				Drifts:   []entity.BalanceDrift{{AccountID: 5, Expected: decimal.NewFromInt(-12), Actual: decimal.NewFromInt(-2)}},
+			}, nil
+		})
+		second := filepath.Join(t.TempDir(), "second.json")
+		code, out, _ := run("accounts", "reconcile", "-closing", first, "-report", second)
+		assert.Equal(t, cli.ExitMismatch, code)
+		assert.Contains(t, out, "drift")
+		assert.FileExists(t, second)
+	})
+
+	t.Run("profiles", func(t *testing.T) {
+		code, _, _ := run("profiles", "set", "staging", "-url", "https://staging.example.com", "-api-key", "secret")
+		require.Equal(t, cli.ExitOK, code)
+		code, _, _ = run("profiles", "set", "prod", "-url", "https://prod.example.com")
+		require.Equal(t, cli.ExitOK, code)
+		code, _, _ = run("profiles", "use", "prod")
+		require.Equal(t, cli.ExitOK, code)
+
+		code, out, _ := run("profiles", "list")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Contains(t, out, "*        prod")
+		assert.NotContains(t, out, "secret")
+
+		code, _, _ = run("profiles", "use", "missing")
+		assert.Equal(t, cli.ExitUsage, code)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		code, _, _ := run("accounts", "delete")
+		assert.Equal(t, cli.ExitUsage, code)
+	})
+}
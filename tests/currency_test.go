@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCurrency(t *testing.T) {
+	d := decimal.RequireFromString
+
+	t.Run("minor units", func(t *testing.T) {
+		assert.True(t, entity.Currency("BRL").Fits(d("10.25")))
+		assert.False(t, entity.Currency("BRL").Fits(d("10.255")))
+		assert.True(t, entity.Currency("JPY").Fits(d("1500")))
+		assert.False(t, entity.Currency("JPY").Fits(d("1500.5")))
+		assert.True(t, entity.Currency("KWD").Fits(d("1.125")))
+		assert.False(t, entity.Currency("XXX").Valid())
+	})
+
+	t.Run("rounds half to even", func(t *testing.T) {
+		assert.Equal(t, "0.12", entity.Currency("USD").Round(d("0.125")).String())
+		assert.Equal(t, "0.14", entity.Currency("USD").Round(d("0.135")).String())
+		assert.Equal(t, "2", entity.Currency("JPY").Round(d("2.5")).String())
+	})
+
+	rate := &entity.FXRate{Base: "USD", Quote: "BRL", Rate: d("5.1234")}
+	for _, tc := range []struct {
+		name     string
+		tx       entity.Transaction
+		account  entity.Currency
+		rate     *entity.FXRate
+		amount   string
+		fxRate   string
+		original entity.Currency
+		err      error
+	}{
+		{"account currency", entity.Transaction{Amount: d("10.5")}, "BRL", nil, "10.5", "1", "BRL", nil},
+		{"same currency", entity.Transaction{Amount: d("10.5"), OriginalCurrency: "BRL"}, "BRL", nil, "10.5", "1", "BRL", nil},
+		{"converted", entity.Transaction{Amount: d("-10.01"), OriginalCurrency: "USD"}, "BRL", rate, "-51.29", "5.1234", "USD", nil},
+		{"no rate", entity.Transaction{Amount: d("10"), OriginalCurrency: "USD"}, "BRL", nil, "", "", "", entity.ErrFXRateNotFound},
+		{"yen has no cents", entity.Transaction{Amount: d("100.5"), OriginalCurrency: "JPY"}, "BRL", nil, "", "", "", entity.ErrAmountPrecision},
+		{"yen account", entity.Transaction{Amount: d("100.5")}, "JPY", nil, "", "", "", entity.ErrAmountPrecision},
+		{"unknown currency", entity.Transaction{Amount: d("1"), OriginalCurrency: "XXX"}, "BRL", nil, "", "", "", entity.ErrInvalidCurrency},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := tc.tx
+			err := tx.Convert(tc.account, tc.rate, entity.AmountLimits{})
+			assert.ErrorIs(t, err, tc.err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.amount, tx.Amount.String())
+			assert.Equal(t, tc.fxRate, tx.FXRate.String())
+			assert.Equal(t, tc.original, tx.OriginalCurrency)
+			assert.True(t, tc.tx.Amount.Equal(tx.OriginalAmount))
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		tx := entity.Transaction{ID: 1, AccountID: 1, OperationTypeID: 4, Amount: d("10"), OriginalCurrency: "USD", EventDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+		require.NoError(t, tx.Convert("BRL", &entity.FXRate{Base: "USD", Quote: "BRL", Rate: d("5")}, entity.AmountLimits{}))
+		b, err := json.Marshal(tx)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"account_id":1,"operation_type_id":4,"amount":"50","event_date":"2024-01-02T03:04:05+00:00","original_amount":"10","original_currency":"USD","fx_rate":"5"}`, string(b))
+	})
+}
+
+func TestTransactionConversion(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	opTypes := entity.OperationType{4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true}}
+	newService := func(t *testing.T) (service.TransactionService, *mocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		return service.NewTransactionService(cl, repo, opTypes, entity.AmountLimits{}, nil), repo
+	}
+
+	t.Run("converts at the rate in effect", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "JPY"}, nil)
+		repo.EXPECT().FindFXRate(gomock.Any(), entity.Currency("USD"), entity.Currency("JPY"), now).
+			Return(&entity.FXRate{Base: "USD", Quote: "JPY", Rate: decimal.RequireFromString("148.25")}, nil)
+		repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (int, error) {
+			assert.Equal(t, "1484", tx.Amount.String(), "10.01 USD in yen, without cents")
+			assert.Equal(t, "10.01", tx.OriginalAmount.String())
+			assert.Equal(t, entity.Currency("USD"), tx.OriginalCurrency)
+			assert.Equal(t, "148.25", tx.FXRate.String())
+			return 2, nil
+		})
+		created, err := svc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.RequireFromString("10.01"), OriginalCurrency: "USD"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, created.ID)
+	})
+
+	t.Run("no rate", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		repo.EXPECT().FindFXRate(gomock.Any(), entity.Currency("EUR"), entity.Currency("BRL"), now).Return(nil, nil)
+		_, err := svc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10), OriginalCurrency: "EUR"})
+		assert.ErrorIs(t, err, entity.ErrFXRateNotFound)
+	})
+
+	t.Run("patch changes the entered currency", func(t *testing.T) {
+		svc, repo := newService(t)
+		id := 5
+		stored := entity.Transaction{ID: 5, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(50), EventDate: now,
+			OriginalAmount: decimal.NewFromInt(10), OriginalCurrency: "USD", FXRate: decimal.NewFromInt(5)}
+		repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &id}).Return([]entity.Transaction{stored}, nil).Times(2)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil).Times(2)
+		// The event date comes back from the merged JSON document.
+		repo.EXPECT().FindFXRate(gomock.Any(), entity.Currency("EUR"), entity.Currency("BRL"), gomock.Any()).
+			Return(&entity.FXRate{Base: "EUR", Quote: "BRL", Rate: decimal.NewFromInt(6)}, nil)
+		repo.EXPECT().UpdateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) error {
+			assert.Equal(t, "60", tx.Amount.String(), "10 EUR")
+			assert.Equal(t, entity.Currency("EUR"), tx.OriginalCurrency)
+			return nil
+		})
+		require.NoError(t, svc.PatchTransaction(ctx, 5, []byte(`{"original_currency":"EUR"}`)))
+
+		repo.EXPECT().UpdateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) error {
+			assert.Equal(t, "10", tx.Amount.String(), "a cleared currency is the account one")
+			assert.Equal(t, entity.Currency("BRL"), tx.OriginalCurrency)
+			return nil
+		})
+		require.NoError(t, svc.PatchTransaction(ctx, 5, []byte(`{"original_currency":null}`)))
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{}, nil)
+		_, err := svc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10)})
+		assert.ErrorIs(t, err, entity.ErrAccountNotFound)
+	})
+
+	t.Run("batch looks each pair up once", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		repo.EXPECT().FindFXRate(gomock.Any(), entity.Currency("USD"), entity.Currency("BRL"), now).
+			Return(&entity.FXRate{Base: "USD", Quote: "BRL", Rate: decimal.NewFromInt(5)}, nil)
+		repo.EXPECT().CreateTransactions(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, txs []entity.Transaction) ([]int, error) {
+			require.Len(t, txs, 2)
+			assert.Equal(t, "50", txs[0].Amount.String())
+			assert.Equal(t, "15", txs[1].Amount.String())
+			return []int{3, 4}, nil
+		})
+		result, err := svc.CreateTransactions(ctx, []entity.BatchItem{
+			{Index: 0, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10), OriginalCurrency: "USD"}},
+			{Index: 1, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(3), OriginalCurrency: "USD"}},
+			{Index: 2, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.RequireFromString("0.001")}},
+		}, entity.BatchBestEffort)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, "amount: "+entity.ErrAmountPrecision.Error(), result.Items[2].Error)
+	})
+}
+
+func TestFXHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	fxSvc := mocks.NewMockFXService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, FX: fxSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("create rate", func(t *testing.T) {
+		effective := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		fxSvc.EXPECT().CreateRate(gomock.Any(), entity.FXRate{Base: "USD", Quote: "BRL", Rate: decimal.RequireFromString("4.95"), EffectiveAt: effective}).
+			Return(entity.FXRate{ID: 1, Base: "USD", Quote: "BRL", Rate: decimal.RequireFromString("4.95"), EffectiveAt: effective}, nil)
+		resp, body := do(http.MethodPost, "/v1/fx-rates", `{"base":"USD","quote":"BRL","rate":"4.95","effective_at":"2024-01-02T00:00:00Z"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Contains(t, body, `"rate":"4.95"`)
+
+		fxSvc.EXPECT().CreateRate(gomock.Any(), gomock.Any()).Return(entity.FXRate{}, entity.ErrFXRateExists)
+		resp, _ = do(http.MethodPost, "/v1/fx-rates", `{"base":"USD","quote":"BRL","rate":"4.95","effective_at":"2024-01-02T00:00:00Z"}`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("list rates", func(t *testing.T) {
+		fxSvc.EXPECT().ListRates(gomock.Any(), entity.FXRateFilter{Base: "USD"}).Return(nil, nil)
+		resp, body := do(http.MethodGet, "/v1/fx-rates?base=USD", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"rates":[]}`, body)
+
+		resp, _ = do(http.MethodGet, "/v1/fx-rates?quote=usd", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("balance in the account currency", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 3).Return(&entity.Account{ID: 3, Currency: "JPY"}, nil)
+		accSvc.EXPECT().GetAccountBalance(gomock.Any(), 3).Return(decimal.NewFromInt(1500), nil)
+		resp, body := do(http.MethodGet, "/v1/accounts/3/balance", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"balance":"1500","currency":"JPY"}`, body)
+	})
+}
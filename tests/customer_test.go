@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCustomerValidate(t *testing.T) {
+	c := entity.Customer{DocumentNumber: "12345678900", Name: "Ana", Email: "ana@example.com", Phone: "+5511987654321"}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, entity.KYCPending, c.KYCStatus)
+
+	c = entity.Customer{KYCStatus: "approved", Email: "Ana <ana@example.com>", Phone: "11987654321"}
+	err := c.Validate()
+	var fields []string
+	for _, fe := range entity.FieldErrors(err) {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"document_number", "name", "kyc_status", "email", "phone"}, fields)
+}
+
+func TestCustomerBalances(t *testing.T) {
+	d := decimal.RequireFromString
+	b := entity.NewCustomerBalances(1, []entity.CustomerAccountBalance{
+		{AccountID: 1, Currency: "BRL", Balance: d("100.50")},
+		{AccountID: 2, Currency: "USD", Balance: d("-20")},
+		{AccountID: 3, Currency: "BRL", Balance: d("-0.5")},
+	})
+	require.Len(t, b.Totals, 2)
+	assert.Equal(t, entity.Currency("BRL"), b.Totals[0].Currency)
+	assert.Equal(t, "100", b.Totals[0].Balance.String())
+	assert.Equal(t, 2, b.Totals[0].Accounts)
+	assert.Equal(t, entity.Currency("USD"), b.Totals[1].Currency)
+	assert.Equal(t, "-20", b.Totals[1].Balance.String())
+	assert.Equal(t, 1, b.Totals[1].Accounts)
+
+	b = entity.NewCustomerBalances(2, nil)
+	assert.NotNil(t, b.Accounts)
+	assert.NotNil(t, b.Totals)
+}
+
+func TestCustomerService(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Location().Return(time.UTC).AnyTimes()
+	svc := service.NewCustomerService(cl, repo)
+
+	t.Run("create", func(t *testing.T) {
+		c := entity.Customer{DocumentNumber: "123", Name: "Ana", KYCStatus: entity.KYCPending}
+		repo.EXPECT().CreateCustomer(gomock.Any(), c).Return(entity.Customer{ID: 1, DocumentNumber: "123", Name: "Ana", KYCStatus: entity.KYCPending}, nil)
+		created, err := svc.CreateCustomer(ctx, entity.Customer{DocumentNumber: "123", Name: "Ana"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, created.ID)
+
+		repo.EXPECT().CreateCustomer(gomock.Any(), c).Return(entity.Customer{}, entity.ErrCustomerExists)
+		_, err = svc.CreateCustomer(ctx, c)
+		assert.ErrorIs(t, err, entity.ErrCustomerExists)
+
+		_, err = svc.CreateCustomer(ctx, entity.Customer{DocumentNumber: "123"})
+		assert.ErrorIs(t, err, entity.ErrMissingCustomerName)
+	})
+
+	t.Run("balances", func(t *testing.T) {
+		id := 1
+		repo.EXPECT().FindCustomers(gomock.Any(), entity.CustomerFilter{ID: &id}).Return([]entity.Customer{{ID: 1}}, nil)
+		repo.EXPECT().FindCustomerBalances(gomock.Any(), 1).Return([]entity.CustomerAccountBalance{
+			{AccountID: 4, Currency: "BRL", Balance: decimal.NewFromInt(10)},
+		}, nil)
+		b, err := svc.GetCustomerBalances(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, b.CustomerID)
+		require.Len(t, b.Totals, 1)
+		assert.Equal(t, "10", b.Totals[0].Balance.String())
+
+		id = 2
+		repo.EXPECT().FindCustomers(gomock.Any(), entity.CustomerFilter{ID: &id}).Return(nil, nil)
+		b, err = svc.GetCustomerBalances(ctx, 2)
+		require.NoError(t, err)
+		assert.Nil(t, b)
+	})
+}
+
+func TestCustomerHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	custSvc := mocks.NewMockCustomerService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, Customers: custSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		custSvc.EXPECT().CreateCustomer(gomock.Any(), entity.Customer{DocumentNumber: "123", Name: "Ana", KYCStatus: entity.KYCPending, Email: "ana@example.com"}).
+			Return(entity.Customer{ID: 2, DocumentNumber: "123", Name: "Ana", KYCStatus: entity.KYCPending}, nil)
+		resp, body := do(http.MethodPost, "/v1/customers", `{"document_number":"123","name":"Ana","email":"ana@example.com"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/customers/2", resp.Header.Get("Location"))
+		assert.Contains(t, body, `"kyc_status":"pending"`)
+
+		custSvc.EXPECT().CreateCustomer(gomock.Any(), gomock.Any()).Return(entity.Customer{}, entity.ErrCustomerExists)
+		resp, _ = do(http.MethodPost, "/v1/customers", `{"document_number":"123","name":"Ana"}`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp, body = do(http.MethodPost, "/v1/customers", `{"document_number":"123","name":"Ana","kyc_status":"approved"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "kyc_status")
+	})
+
+	t.Run("open account", func(t *testing.T) {
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{CustomerID: 2, Currency: "USD"}).
+			Return(entity.Account{ID: 8, DocumentNumber: "123", Currency: "USD", CustomerID: 2}, nil)
+		resp, body := do(http.MethodPost, "/v1/customers/2/accounts", `{"currency":"USD"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/accounts/8", resp.Header.Get("Location"))
+		assert.JSONEq(t, `{"id":8,"document_number":"123","currency":"USD","customer_id":2}`, body)
+
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{CustomerID: 3}).Return(entity.Account{}, entity.ErrCustomerNotFound)
+		resp, _ = do(http.MethodPost, "/v1/customers/3/accounts", `{}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("balances", func(t *testing.T) {
+		b := entity.NewCustomerBalances(2, []entity.CustomerAccountBalance{
+			{AccountID: 7, Currency: "BRL", Balance: decimal.RequireFromString("10.50")},
+			{AccountID: 8, Currency: "USD", Balance: decimal.NewFromInt(-3)},
+		})
+		custSvc.EXPECT().GetCustomerBalances(gomock.Any(), 2).Return(&b, nil)
+		resp, body := do(http.MethodGet, "/v1/customers/2/balances", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"customer_id":2,
+			"accounts":[{"account_id":7,"currency":"BRL","balance":"10.5"},{"account_id":8,"currency":"USD","balance":"-3"}],
+			"totals":[{"currency":"BRL","balance":"10.5","accounts":1},{"currency":"USD","balance":"-3","accounts":1}]}`, body)
+
+		custSvc.EXPECT().GetCustomerBalances(gomock.Any(), 3).Return(nil, nil)
+		resp, _ = do(http.MethodGet, "/v1/customers/3/balances", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		custSvc.EXPECT().GetCustomer(gomock.Any(), 9).Return(nil, nil)
+		resp, _ := do(http.MethodGet, "/v1/customers/9", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
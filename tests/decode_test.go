@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type validationResponse struct {
+	Message string `json:"message"`
+	Errors  []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (v validationResponse) fields() []string {
+	var fields []string
+	for _, e := range v.Errors {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestRequestDecoding(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	send := func(method, path, contentType, body string) (*http.Response, validationResponse) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var v validationResponse
+		_ = json.Unmarshal(b, &v)
+		return resp, v
+	}
+
+	t.Run("client cannot set id or event date", func(t *testing.T) {
+		resp, v := send(http.MethodPost, "/v1/transactions", "application/json",
+			`{"id":7,"account_id":1,"operation_type_id":1,"amount":10,"event_date":"2024-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.ElementsMatch(t, []string{"id", "event_date"}, v.fields())
+	})
+
+	t.Run("lists every invalid field", func(t *testing.T) {
+		resp, v := send(http.MethodPost, "/v1/transactions", "application/json", `{"account_id":0,"amount":true}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid request body", v.Message)
+		assert.ElementsMatch(t, []string{"account_id", "amount", "operation_type_id"}, v.fields())
+	})
+
+	t.Run("lists every field rejected by the service", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (entity.Transaction, error) {
+			return entity.Transaction{}, tx.Validate(entity.OperationType{})
+		})
+		resp, v := send(http.MethodPost, "/v1/transactions", "application/json", `{"account_id":1,"operation_type_id":9,"amount":"0"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.ElementsMatch(t, []string{"amount", "event_date", "operation_type_id"}, v.fields())
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		resp, _ := send(http.MethodPost, "/v1/accounts", "application/json", `{"document_number":"1"}{"document_number":"2"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("wrong content type", func(t *testing.T) {
+		resp, _ := send(http.MethodPost, "/v1/accounts", "text/plain", `{"document_number":"1"}`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+
+	t.Run("content type with charset", func(t *testing.T) {
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{DocumentNumber: "1"}).Return(entity.Account{ID: 1, DocumentNumber: "1"}, nil)
+		resp, _ := send(http.MethodPost, "/v1/accounts", "application/json; charset=utf-8", `{"document_number":"1"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		body := `{"document_number":"` + strings.Repeat("1", 2<<20) + `"}`
+		resp, _ := send(http.MethodPost, "/v1/accounts", "application/json", body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
+
+func TestPatchTransactionHandler(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	patch := func(contentType, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPatch, ts.URL+"/v1/transactions/1", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("forwards explicit nulls and omits absent fields", func(t *testing.T) {
+		txSvc.EXPECT().PatchTransaction(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, p []byte) error {
+			assert.JSONEq(t, `{"amount":"12.50","event_date":null}`, string(p))
+			return nil
+		})
+		resp := patch("application/merge-patch+json", `{"amount":"12.50","event_date":null}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("currency patches the entered currency", func(t *testing.T) {
+		txSvc.EXPECT().PatchTransaction(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, p []byte) error {
+			assert.JSONEq(t, `{"original_currency":"USD"}`, string(p))
+			return nil
+		})
+		resp := patch("application/merge-patch+json", `{"currency":"USD"}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		resp := patch("application/merge-patch+json", `{"id":2}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		txSvc.EXPECT().PatchTransaction(gomock.Any(), 1, gomock.Any()).Return(entity.ErrTransactionNotFound)
+		resp := patch("application/json", `{"amount":1}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		resp := patch("application/json-patch+json", `[]`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+}
@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDisputeTransition(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	opened := entity.Dispute{ID: 1, AccountID: 2, Amount: decimal.NewFromInt(40), Status: entity.DisputeOpened}
+	credited := opened
+	credited.Status = entity.DisputeProvisionalCredit
+
+	for _, tc := range []struct {
+		name    string
+		from    entity.Dispute
+		to      entity.DisputeStatus
+		posting *entity.Transaction
+		err     error
+	}{
+		{"credit", opened, entity.DisputeProvisionalCredit, &entity.Transaction{AccountID: 2, OperationTypeID: 8, Amount: decimal.NewFromInt(40), EventDate: now}, nil},
+		{"win uncredited", opened, entity.DisputeWon, &entity.Transaction{AccountID: 2, OperationTypeID: 8, Amount: decimal.NewFromInt(40), EventDate: now}, nil},
+		{"win credited", credited, entity.DisputeWon, nil, nil},
+		{"lose credited", credited, entity.DisputeLost, &entity.Transaction{AccountID: 2, OperationTypeID: 9, Amount: decimal.NewFromInt(-40), EventDate: now}, nil},
+		{"withdraw uncredited", opened, entity.DisputeWithdrawn, nil, nil},
+		{"credit twice", credited, entity.DisputeProvisionalCredit, nil, entity.ErrInvalidDisputeTransition},
+		{"reopen", credited, entity.DisputeOpened, nil, entity.ErrInvalidDisputeTransition},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.from
+			posting, err := d.Transition(tc.to, 8, 9, now)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.posting, posting)
+			if err == nil {
+				assert.Equal(t, tc.to, d.Status)
+				assert.Equal(t, d.Resolved(), d.ResolvedAt != nil)
+			}
+		})
+	}
+
+	t.Run("resolved disputes stay put", func(t *testing.T) {
+		d := opened
+		d.Status = entity.DisputeLost
+		_, err := d.Transition(entity.DisputeWon, 8, 9, now)
+		assert.ErrorIs(t, err, entity.ErrInvalidDisputeTransition)
+	})
+
+	t.Run("deadlines", func(t *testing.T) {
+		d := opened
+		d.CreditDueAt, d.ResolveBy = now, now.Add(time.Hour)
+		assert.False(t, d.PastDeadline(now))
+		assert.True(t, d.PastDeadline(now.Add(time.Minute)))
+		d.Status = entity.DisputeProvisionalCredit
+		assert.False(t, d.PastDeadline(now.Add(time.Minute)))
+		assert.True(t, d.PastDeadline(now.Add(2*time.Hour)))
+		d.Status = entity.DisputeWon
+		assert.False(t, d.PastDeadline(now.Add(2*time.Hour)))
+	})
+
+	t.Run("deadline status", func(t *testing.T) {
+		d := opened
+		d.CreditDueAt, d.ResolveBy = now, now.Add(time.Hour)
+		_, ok := d.DeadlineStatus(now)
+		assert.False(t, ok)
+		status, ok := d.DeadlineStatus(now.Add(time.Minute))
+		assert.True(t, ok)
+		assert.Equal(t, entity.DisputeProvisionalCredit, status)
+		status, ok = d.DeadlineStatus(now.Add(2 * time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, entity.DisputeWon, status)
+	})
+}
+
+func TestDisputeService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	opTypes := entity.OperationType{
+		1: &entity.Operation{Description: "COMPRA A VISTA"},
+		4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true},
+		8: &entity.Operation{Description: entity.DisputeCreditDescription, PositiveAmount: true},
+		9: &entity.Operation{Description: entity.DisputeReversalDescription},
+	}
+	newService := func(t *testing.T) (service.DisputeService, *mocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		cl.EXPECT().Location().Return(time.UTC).AnyTimes()
+		return service.NewDisputeService(cl, repo, opTypes, 10*24*time.Hour, 45*24*time.Hour), repo
+	}
+
+	t.Run("open", func(t *testing.T) {
+		svc, repo := newService(t)
+		id := 7
+		repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &id}).
+			Return([]entity.Transaction{{ID: 7, AccountID: 2, OperationTypeID: 1, Amount: decimal.NewFromInt(-50)}}, nil)
+		repo.EXPECT().CreateDispute(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d entity.Dispute) (int, error) {
+			assert.True(t, d.Amount.Equal(decimal.NewFromInt(50)), "the whole purchase is disputed by default")
+			assert.Equal(t, 2, d.AccountID)
+			assert.Equal(t, now.Add(10*24*time.Hour), d.CreditDueAt)
+			assert.Equal(t, now.Add(45*24*time.Hour), d.ResolveBy)
+			return 3, nil
+		})
+		d, err := svc.OpenDispute(ctx, entity.Dispute{TransactionID: 7, Reason: "not received"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, d.ID)
+		assert.Equal(t, entity.DisputeOpened, d.Status)
+	})
+
+	t.Run("open a payment", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindTransactions(gomock.Any(), gomock.Any()).
+			Return([]entity.Transaction{{ID: 7, AccountID: 2, OperationTypeID: 4, Amount: decimal.NewFromInt(50)}}, nil)
+		_, err := svc.OpenDispute(ctx, entity.Dispute{TransactionID: 7, Reason: "not received"})
+		assert.ErrorIs(t, err, entity.ErrNotDisputable)
+	})
+
+	t.Run("open too much", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindTransactions(gomock.Any(), gomock.Any()).
+			Return([]entity.Transaction{{ID: 7, AccountID: 2, OperationTypeID: 1, Amount: decimal.NewFromInt(-50)}}, nil)
+		_, err := svc.OpenDispute(ctx, entity.Dispute{TransactionID: 7, Amount: decimal.NewFromInt(60), Reason: "not received"})
+		assert.ErrorIs(t, err, entity.ErrInvalidDisputeAmount)
+	})
+
+	t.Run("lose reverses the credit", func(t *testing.T) {
+		svc, repo := newService(t)
+		id, credit := 3, 11
+		repo.EXPECT().FindDisputes(gomock.Any(), entity.DisputeFilter{ID: &id, AsOf: now}).Return([]entity.Dispute{{
+			ID: 3, AccountID: 2, Amount: decimal.NewFromInt(50), Status: entity.DisputeProvisionalCredit, CreditTransactionID: &credit,
+		}}, nil)
+		repo.EXPECT().TransitionDispute(gomock.Any(), gomock.Any(), entity.DisputeProvisionalCredit, &entity.Transaction{
+			AccountID: 2, OperationTypeID: 9, Amount: decimal.NewFromInt(-50), EventDate: now,
+		}).DoAndReturn(func(_ context.Context, d entity.Dispute, _ entity.DisputeStatus, _ *entity.Transaction) (entity.Dispute, error) {
+			reversal := 12
+			d.ReversalTransactionID = &reversal
+			return d, nil
+		})
+		d, err := svc.TransitionDispute(ctx, 3, entity.DisputeLost)
+		require.NoError(t, err)
+		assert.Equal(t, entity.DisputeLost, d.Status)
+		assert.Equal(t, 12, *d.ReversalTransactionID)
+		assert.Equal(t, now, *d.ResolvedAt)
+	})
+
+	t.Run("invalid move", func(t *testing.T) {
+		svc, repo := newService(t)
+		repo.EXPECT().FindDisputes(gomock.Any(), gomock.Any()).Return([]entity.Dispute{{ID: 3, Status: entity.DisputeWithdrawn}}, nil)
+		_, err := svc.TransitionDispute(ctx, 3, entity.DisputeWon)
+		assert.ErrorIs(t, err, entity.ErrInvalidDisputeTransition)
+	})
+
+	t.Run("enforce deadlines", func(t *testing.T) {
+		svc, repo := newService(t)
+		credit := 11
+		repo.EXPECT().FindDisputes(gomock.Any(), entity.DisputeFilter{Overdue: true, AsOf: now}).Return([]entity.Dispute{
+			{ID: 3, AccountID: 2, Amount: decimal.NewFromInt(50), Status: entity.DisputeOpened, CreditDueAt: now.Add(-time.Hour), ResolveBy: now.Add(time.Hour)},
+			{ID: 4, AccountID: 2, Amount: decimal.NewFromInt(20), Status: entity.DisputeProvisionalCredit, CreditTransactionID: &credit, ResolveBy: now.Add(-time.Hour)},
+			{ID: 5, AccountID: 2, Amount: decimal.NewFromInt(30), Status: entity.DisputeOpened, CreditDueAt: now.Add(-time.Hour), ResolveBy: now.Add(time.Hour)},
+		}, nil)
+		repo.EXPECT().TransitionDispute(gomock.Any(), gomock.Any(), entity.DisputeOpened, &entity.Transaction{
+			AccountID: 2, OperationTypeID: 8, Amount: decimal.NewFromInt(50), EventDate: now,
+		}).DoAndReturn(func(_ context.Context, d entity.Dispute, _ entity.DisputeStatus, _ *entity.Transaction) (entity.Dispute, error) {
+			assert.Equal(t, entity.DisputeProvisionalCredit, d.Status)
+			return d, nil
+		})
+		repo.EXPECT().TransitionDispute(gomock.Any(), gomock.Any(), entity.DisputeProvisionalCredit, nil).
+			DoAndReturn(func(_ context.Context, d entity.Dispute, _ entity.DisputeStatus, _ *entity.Transaction) (entity.Dispute, error) {
+				assert.Equal(t, entity.DisputeWon, d.Status, "the cardholder keeps the credit")
+				return d, nil
+			})
+		repo.EXPECT().TransitionDispute(gomock.Any(), gomock.Any(), entity.DisputeOpened, gomock.Any()).Return(entity.Dispute{}, entity.ErrInvalidDisputeTransition)
+		moved, err := svc.EnforceDeadlines(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, moved, "disputes moved meanwhile are skipped")
+	})
+
+	t.Run("missing operation types", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		cl.EXPECT().Location().Return(time.UTC).AnyTimes()
+		repo.EXPECT().FindDisputes(gomock.Any(), gomock.Any()).Return([]entity.Dispute{{ID: 3, Status: entity.DisputeOpened}}, nil)
+		svc := service.NewDisputeService(cl, repo, entity.OperationType{1: &entity.Operation{Description: "COMPRA A VISTA"}}, time.Hour, time.Hour)
+		_, err := svc.TransitionDispute(ctx, 3, entity.DisputeProvisionalCredit)
+		assert.ErrorIs(t, err, entity.ErrDisputeTypesMissing)
+	})
+}
+
+func TestDisputeHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	dispSvc := mocks.NewMockDisputeService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Disputes: dispSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("open", func(t *testing.T) {
+		dispSvc.EXPECT().OpenDispute(gomock.Any(), entity.Dispute{TransactionID: 7, Reason: "not received"}).
+			Return(entity.Dispute{ID: 3, Status: entity.DisputeOpened}, nil)
+		resp, body := do(http.MethodPost, "/v1/disputes", `{"transaction_id":7,"reason":"not received"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/disputes/3", resp.Header.Get("Location"))
+		assert.Contains(t, body, `"status":"opened"`)
+
+		dispSvc.EXPECT().OpenDispute(gomock.Any(), gomock.Any()).Return(entity.Dispute{}, entity.ErrAlreadyDisputed)
+		resp, _ = do(http.MethodPost, "/v1/disputes", `{"transaction_id":7,"reason":"not received"}`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("missing reason", func(t *testing.T) {
+		resp, body := do(http.MethodPost, "/v1/disputes", `{"transaction_id":7}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "reason")
+	})
+
+	t.Run("list overdue", func(t *testing.T) {
+		dispSvc.EXPECT().ListDisputes(gomock.Any(), entity.DisputeFilter{Status: entity.DisputeOpened, Overdue: true}).Return(nil, nil)
+		resp, body := do(http.MethodGet, "/v1/disputes?status=opened&overdue=true", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"disputes":[]}`, body)
+
+		resp, _ = do(http.MethodGet, "/v1/disputes?status=closed", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		credit := 11
+		dispSvc.EXPECT().TransitionDispute(gomock.Any(), 3, entity.DisputeProvisionalCredit).
+			Return(entity.Dispute{ID: 3, Status: entity.DisputeProvisionalCredit, CreditTransactionID: &credit}, nil)
+		resp, body := do(http.MethodPost, "/v1/disputes/3/provisional-credit", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"credit_transaction_id":11`)
+
+		dispSvc.EXPECT().TransitionDispute(gomock.Any(), 3, entity.DisputeWithdrawn).Return(entity.Dispute{}, entity.ErrInvalidDisputeTransition)
+		resp, _ = do(http.MethodPost, "/v1/disputes/3/withdraw", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		dispSvc.EXPECT().TransitionDispute(gomock.Any(), 4, entity.DisputeWon).Return(entity.Dispute{}, entity.ErrDisputeNotFound)
+		resp, _ = do(http.MethodPost, "/v1/disputes/4/win", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
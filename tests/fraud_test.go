@@ -0,0 +1,298 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/fraud"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const fraudRules = `
+rules:
+  - name: large-withdrawal
+    type: max_amount
+    action: deny
+    operation_type_id: 3
+    max_amount: 1000
+  - name: burst
+    type: velocity
+    action: review
+    max_count: 3
+    window: 1m
+  - name: daily-withdrawals
+    type: daily_total
+    action: deny
+    operation_type_id: 3
+    max_amount: 1500
+  - name: repeated
+    type: duplicate
+    action: review
+    window: 30s
+`
+
+func TestFraudRules(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	rules, err := fraud.ParseRules([]byte(fraudRules))
+	require.NoError(t, err)
+	byName := map[string]fraud.Rule{}
+	for _, rule := range rules {
+		byName[rule.Name()] = rule
+	}
+	withdrawal := func(amount int64, ago time.Duration) entity.Transaction {
+		return entity.Transaction{ID: int(ago), AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(-amount), EventDate: now.Add(-ago)}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		rule    string
+		tx      entity.Transaction
+		history []entity.Transaction
+		broken  bool
+	}{
+		{"amount within limit", "large-withdrawal", withdrawal(1000, 0), nil, false},
+		{"amount above limit", "large-withdrawal", withdrawal(1001, 0), nil, true},
+		{"amount of other type", "large-withdrawal", entity.Transaction{OperationTypeID: 4, Amount: decimal.NewFromInt(5000)}, nil, false},
+		{"few transactions", "burst", withdrawal(1, 0), []entity.Transaction{withdrawal(2, 10*time.Second), withdrawal(3, 20*time.Second)}, false},
+		{"too many transactions", "burst", withdrawal(1, 0), []entity.Transaction{withdrawal(2, 10*time.Second), withdrawal(3, 20*time.Second), withdrawal(4, 30*time.Second)}, true},
+		{"old transactions", "burst", withdrawal(1, 0), []entity.Transaction{withdrawal(2, 10*time.Second), withdrawal(3, 2*time.Minute), withdrawal(4, 3*time.Minute)}, false},
+		{"daily total within limit", "daily-withdrawals", withdrawal(500, 0), []entity.Transaction{withdrawal(1000, time.Hour)}, false},
+		{"daily total above limit", "daily-withdrawals", withdrawal(501, 0), []entity.Transaction{withdrawal(1000, time.Hour)}, true},
+		{"yesterday", "daily-withdrawals", withdrawal(501, 0), []entity.Transaction{withdrawal(1000, 11*time.Hour)}, false},
+		{"duplicate", "repeated", withdrawal(50, 0), []entity.Transaction{withdrawal(50, 20*time.Second)}, true},
+		{"old duplicate", "repeated", withdrawal(50, 0), []entity.Transaction{withdrawal(50, 40*time.Second)}, false},
+		{"other amount", "repeated", withdrawal(50, 0), []entity.Transaction{withdrawal(51, 20*time.Second)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := byName[tc.rule].Check(tc.tx, tc.history, now)
+			assert.Equal(t, tc.broken, reason != "", reason)
+		})
+	}
+
+	t.Run("daily total starts at midnight", func(t *testing.T) {
+		loc := time.FixedZone("BRT", -3*60*60)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, loc), byName["daily-withdrawals"].Since(now.In(loc)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, data := range []string{
+			`rules: [{name: a, type: max_amount, action: deny}]`,
+			`rules: [{name: a, type: velocity, action: deny, window: 1m}]`,
+			`rules: [{name: a, type: unknown, action: deny}]`,
+			`rules: [{name: a, type: duplicate, action: allow, window: 1m}]`,
+			`rules: [{type: duplicate, action: deny, window: 1m}]`,
+			`rules: [{name: a, type: duplicate, action: deny, window: 1m}, {name: a, type: duplicate, action: deny, window: 2m}]`,
+			`rules: {`,
+		} {
+			_, err := fraud.ParseRules([]byte(data))
+			assert.ErrorIs(t, err, entity.ErrInvalidFraudRules, data)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		rules, err := fraud.ParseRules([]byte(`{"rules":[{"name":"a","type":"velocity","action":"deny","max_count":5,"window":"10m"}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-10*time.Minute), rules[0].Since(now))
+	})
+}
+
+func TestFraudEngine(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fraudRules), 0o600))
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Now().Return(now).AnyTimes()
+	engine, err := fraud.NewEngine(repo, cl, path)
+	require.NoError(t, err)
+
+	t.Run("most severe action wins", func(t *testing.T) {
+		repo.EXPECT().RecentTransactions(gomock.Any(), 1, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)).Return([]entity.Transaction{
+			{ID: 9, AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(-900), EventDate: now.Add(-10 * time.Second)},
+		}, nil)
+		d, err := engine.Screen(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(-900)})
+		require.NoError(t, err)
+		assert.Equal(t, entity.FraudDeny, d.Action)
+		assert.Equal(t, "daily-withdrawals", d.Rule)
+		assert.Equal(t, 1, d.AccountID)
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		repo.EXPECT().RecentTransactions(gomock.Any(), 1, gomock.Any()).Return(nil, nil)
+		d, err := engine.Screen(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10)})
+		require.NoError(t, err)
+		assert.Equal(t, entity.FraudAllow, d.Action)
+		assert.Empty(t, d.Rule)
+	})
+
+	t.Run("batch items count against each other", func(t *testing.T) {
+		repo.EXPECT().RecentTransactions(gomock.Any(), 1, gomock.Any()).Return(nil, nil)
+		withdrawal := func(amount int64) entity.Transaction {
+			return entity.Transaction{AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(-amount), EventDate: now}
+		}
+		decisions, err := engine.ScreenBatch(ctx, []entity.Transaction{withdrawal(900), withdrawal(800), withdrawal(500)})
+		require.NoError(t, err)
+		require.Len(t, decisions, 3)
+		assert.Equal(t, entity.FraudAllow, decisions[0].Action)
+		assert.Equal(t, entity.FraudDeny, decisions[1].Action)
+		assert.Equal(t, "daily-withdrawals", decisions[1].Rule)
+		assert.Equal(t, entity.FraudAllow, decisions[2].Action, "denied items do not count")
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`rules: [{name: cap, type: max_amount, action: review, max_amount: 5}]`), 0o600))
+		require.NoError(t, engine.Reload())
+		require.Len(t, engine.Rules(), 1)
+		d, err := engine.Screen(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10)})
+		require.NoError(t, err)
+		assert.Equal(t, entity.FraudReview, d.Action)
+		assert.Equal(t, "cap", d.Rule)
+
+		require.NoError(t, os.WriteFile(path, []byte(`rules: [{name: cap, type: max_amount, action: review}]`), 0o600))
+		assert.ErrorIs(t, engine.Reload(), entity.ErrInvalidFraudRules)
+		assert.Len(t, engine.Rules(), 1, "invalid files keep the current rules")
+	})
+}
+
+func TestTransactionScreening(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	opTypes := entity.OperationType{3: &entity.Operation{Description: "SAQUE"}}
+	newService := func(t *testing.T) (service.TransactionService, *mocks.MockRepository, *mocks.MockScreener) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		screener := mocks.NewMockScreener(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		return service.NewTransactionService(cl, repo, opTypes, entity.AmountLimits{}, screener), repo, screener
+	}
+	tx := entity.Transaction{AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(2000)}
+
+	t.Run("deny", func(t *testing.T) {
+		svc, repo, screener := newService(t)
+		decision := entity.FraudDecision{AccountID: 1, Action: entity.FraudDeny, Rule: "large-withdrawal"}
+		screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Return(decision, nil)
+		repo.EXPECT().CreateFraudDecision(gomock.Any(), decision).Return(int64(1), nil)
+		_, err := svc.CreateTransaction(ctx, tx)
+		assert.ErrorIs(t, err, entity.ErrTransactionDenied)
+	})
+
+	t.Run("review", func(t *testing.T) {
+		svc, repo, screener := newService(t)
+		screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Return(entity.FraudDecision{AccountID: 1, Action: entity.FraudReview, Rule: "burst"}, nil)
+		repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(5, nil)
+		repo.EXPECT().CreateFraudDecision(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d entity.FraudDecision) (int64, error) {
+			require.NotNil(t, d.TransactionID)
+			assert.Equal(t, 5, *d.TransactionID)
+			assert.Equal(t, "burst", d.Rule)
+			return 1, nil
+		})
+		created, err := svc.CreateTransaction(ctx, tx)
+		require.NoError(t, err)
+		assert.Equal(t, 5, created.ID)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		batch := func() []entity.BatchItem {
+			return []entity.BatchItem{
+				{Index: 0, Transaction: tx},
+				{Index: 1, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 3, Amount: decimal.NewFromInt(50)}},
+			}
+		}
+		denied := entity.FraudDecision{AccountID: 1, Action: entity.FraudDeny, Rule: "large-withdrawal"}
+		review := entity.FraudDecision{AccountID: 1, Action: entity.FraudReview, Rule: "burst"}
+
+		svc, repo, screener := newService(t)
+		screener.EXPECT().ScreenBatch(gomock.Any(), gomock.Len(2)).Return([]entity.FraudDecision{denied, review}, nil)
+		repo.EXPECT().CreateFraudDecision(gomock.Any(), denied).Return(int64(1), nil)
+		repo.EXPECT().CreateTransactions(gomock.Any(), gomock.Len(1)).Return([]int{8}, nil)
+		repo.EXPECT().CreateFraudDecision(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d entity.FraudDecision) (int64, error) {
+			require.NotNil(t, d.TransactionID)
+			assert.Equal(t, 8, *d.TransactionID)
+			assert.Equal(t, "burst", d.Rule)
+			return 2, nil
+		})
+		result, err := svc.CreateTransactions(ctx, batch(), entity.BatchBestEffort)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, entity.BatchItemFailed, result.Items[0].Status)
+		assert.Equal(t, entity.ErrTransactionDenied.Error(), result.Items[0].Error)
+		assert.Equal(t, 8, result.Items[1].ID)
+
+		svc, repo, screener = newService(t)
+		screener.EXPECT().ScreenBatch(gomock.Any(), gomock.Len(2)).Return([]entity.FraudDecision{denied, review}, nil)
+		repo.EXPECT().CreateFraudDecision(gomock.Any(), denied).Return(int64(1), nil)
+		result, err = svc.CreateTransactions(ctx, batch(), entity.BatchAllOrNothing)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Created)
+		assert.Equal(t, entity.BatchItemRejected, result.Items[1].Status)
+	})
+
+	t.Run("screening error", func(t *testing.T) {
+		svc, _, screener := newService(t)
+		screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Return(entity.FraudDecision{}, assert.AnError)
+		_, err := svc.CreateTransaction(ctx, tx)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestFraudHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(entity.Transaction{}, entity.ErrTransactionDenied)
+		resp, body := do(http.MethodPost, "/v1/transactions", `{"account_id":1,"operation_type_id":3,"amount":2000}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Contains(t, body, "transaction denied")
+	})
+
+	t.Run("list decisions", func(t *testing.T) {
+		id := 1
+		txSvc.EXPECT().ListFraudDecisions(gomock.Any(), entity.FraudDecisionFilter{AccountID: &id, Action: entity.FraudDeny, Limit: entity.DefaultFraudDecisionLimit}).
+			Return([]entity.FraudDecision{{ID: 2, AccountID: 1, Action: entity.FraudDeny, Rule: "large-withdrawal", Amount: decimal.NewFromInt(-2000)}}, nil)
+		resp, body := do(http.MethodGet, "/v1/fraud-decisions?account_id=1&action=deny", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"rule":"large-withdrawal"`)
+
+		resp, _ = do(http.MethodGet, "/v1/fraud-decisions?action=maybe", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"transaction-routine/internal/config"
 	"transaction-routine/internal/entity"
@@ -16,14 +17,15 @@ import (
 
 type handlersTestSuite struct {
 	suite.Suite
-	ctrl   *gomock.Controller
-	ctx    context.Context
-	cfg    *config.Config
-	accSvc *mocks.MockAccountService
-	opSvc  *mocks.MockOpTypeService
-	txSvc  *mocks.MockTransactionService
-	srv    *http.Server
-	url    string
+	ctrl      *gomock.Controller
+	ctx       context.Context
+	cfg       *config.Config
+	healthSvc *mocks.MockHealthService
+	accSvc    *mocks.MockAccountService
+	opSvc     *mocks.MockOpTypeService
+	txSvc     *mocks.MockTransactionService
+	srv       *httptest.Server
+	url       string
 }
 
 func TestHandlersSuite(t *testing.T) {
@@ -34,24 +36,20 @@ func (s *handlersTestSuite) SetupTest() {
 	s.ctx = context.Background()
 	s.ctrl = gomock.NewController(s.T())
 	s.cfg = &config.Config{
-		Port: 8081,
+		Port:         8081,
+		AuthDisabled: true,
 	}
-	s.url = "http://localhost:8081"
+	s.healthSvc = mocks.NewMockHealthService(s.ctrl)
 	s.opSvc = mocks.NewMockOpTypeService(s.ctrl)
 	s.accSvc = mocks.NewMockAccountService(s.ctrl)
 	s.txSvc = mocks.NewMockTransactionService(s.ctrl)
-	s.srv = server.NewServer(s.ctx, s.cfg, nil, s.accSvc, s.opSvc, s.txSvc)
-	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.T().Fail()
-		}
-	}()
+	srv := server.NewServer(s.ctx, s.cfg, server.Deps{Health: s.healthSvc, Accounts: s.accSvc, OpTypes: s.opSvc, Transactions: s.txSvc})
+	s.srv = httptest.NewServer(srv.Handler)
+	s.url = s.srv.URL
 }
 
-func (s *handlersTestSuite) TearDownSuite() {
-	if err := s.srv.Shutdown(s.ctx); err != nil {
-		s.T().Fail()
-	}
+func (s *handlersTestSuite) TearDownTest() {
+	s.srv.Close()
 }
 
 func (s *handlersTestSuite) TestHandlers() {
@@ -109,4 +107,77 @@ func (s *handlersTestSuite) TestHandlers() {
 			t.Errorf("getAccountHandler body: %s", body)
 		}
 	})
+	s.T().Run("request id is propagated", func(t *testing.T) {
+		s.accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(nil, nil)
+		req, _ := http.NewRequest(http.MethodGet, s.url+"/accounts/1", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		if got := resp.Header.Get("X-Request-ID"); got != "req-1" {
+			t.Errorf("X-Request-ID: %s", got)
+		}
+	})
+	s.T().Run("request id is generated", func(t *testing.T) {
+		s.accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(nil, nil)
+		resp, err := http.Get(s.url + "/accounts/1")
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get("X-Request-ID") == "" {
+			t.Errorf("missing X-Request-ID")
+		}
+	})
+}
+
+func (s *handlersTestSuite) TestHealthHandlers() {
+	s.T().Run("livenessHandler", func(t *testing.T) {
+		resp, err := http.Get(s.url + "/livez")
+		if err != nil {
+			t.Fatalf("livenessHandler request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("livenessHandler status code: %d", resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"status":"up"}` {
+			t.Errorf("livenessHandler body: %s", body)
+		}
+	})
+	s.T().Run("readinessHandler ready", func(t *testing.T) {
+		s.healthSvc.EXPECT().Readiness(gomock.Any()).Return(entity.HealthReport{
+			Status: entity.HealthStatusUp,
+			Checks: map[string]entity.HealthCheck{"database": {Status: entity.HealthStatusUp}},
+		})
+		resp, err := http.Get(s.url + "/readyz")
+		if err != nil {
+			t.Fatalf("readinessHandler request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("readinessHandler status code: %d", resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"status":"up","checks":{"database":{"status":"up"}}}` {
+			t.Errorf("readinessHandler body: %s", body)
+		}
+	})
+	s.T().Run("readinessHandler not ready", func(t *testing.T) {
+		s.healthSvc.EXPECT().Readiness(gomock.Any()).Return(entity.HealthReport{
+			Status: entity.HealthStatusDown,
+			Checks: map[string]entity.HealthCheck{"shutdown": {Status: entity.HealthStatusDown, Error: "shutting down"}},
+		})
+		resp, err := http.Get(s.url + "/readyz")
+		if err != nil {
+			t.Fatalf("readinessHandler request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("readinessHandler status code: %d", resp.StatusCode)
+		}
+	})
 }
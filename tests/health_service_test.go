@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type healthSvcTestSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	ctx       context.Context
+	repo      *mocks.MockRepository
+	healthSvc service.HealthService
+}
+
+func TestHealthSvcSuite(t *testing.T) {
+	suite.Run(t, new(healthSvcTestSuite))
+}
+
+func (s *healthSvcTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.ctrl = gomock.NewController(s.T())
+	s.repo = mocks.NewMockRepository(s.ctrl)
+	opTypes := entity.OperationType{1: &entity.Operation{Description: "COMPRA A VISTA"}}
+	s.healthSvc = service.NewHealthService(s.repo, opTypes)
+}
+
+func (s *healthSvcTestSuite) expectChecks(healthErr error, stats entity.PoolStats, dirty bool) {
+	s.repo.EXPECT().Health(gomock.Any()).Return(healthErr)
+	s.repo.EXPECT().Stat().Return(stats)
+	s.repo.EXPECT().MigrationVersion(gomock.Any()).Return(2, dirty, nil)
+}
+
+func (s *healthSvcTestSuite) TestReadiness() {
+	s.T().Run("ready", func(t *testing.T) {
+		s.expectChecks(nil, entity.PoolStats{Acquired: 1, Max: 10}, false)
+		report := s.healthSvc.Readiness(s.ctx)
+		s.True(report.IsUp())
+		s.Equal(2, report.Checks["migrations"].Details["version"])
+		s.Equal(0.1, report.Checks["pool"].Details["saturation"])
+	})
+
+	s.T().Run("database down", func(t *testing.T) {
+		s.expectChecks(errors.New("error"), entity.PoolStats{Max: 10}, false)
+		report := s.healthSvc.Readiness(s.ctx)
+		s.False(report.IsUp())
+		s.Equal(entity.HealthStatusDown, report.Checks["database"].Status)
+		s.Equal("error", report.Checks["database"].Error)
+	})
+
+	s.T().Run("pool saturated stays ready", func(t *testing.T) {
+		s.expectChecks(nil, entity.PoolStats{Acquired: 10, Max: 10}, false)
+		report := s.healthSvc.Readiness(s.ctx)
+		s.True(report.IsUp())
+		s.Equal(entity.HealthStatusUp, report.Checks["pool"].Status)
+		s.Equal(1.0, report.Checks["pool"].Details["saturation"])
+	})
+
+	s.T().Run("dirty migration", func(t *testing.T) {
+		s.expectChecks(nil, entity.PoolStats{Max: 10}, true)
+		report := s.healthSvc.Readiness(s.ctx)
+		s.False(report.IsUp())
+		s.Equal(entity.HealthStatusDown, report.Checks["migrations"].Status)
+	})
+
+	s.T().Run("draining", func(t *testing.T) {
+		s.expectChecks(nil, entity.PoolStats{Max: 10}, false)
+		s.healthSvc.Drain()
+		report := s.healthSvc.Readiness(s.ctx)
+		s.False(report.IsUp())
+		s.Equal(entity.HealthStatusDown, report.Checks["shutdown"].Status)
+	})
+}
+
+func (s *healthSvcTestSuite) TestReadinessWithoutOperationTypes() {
+	healthSvc := service.NewHealthService(s.repo, entity.OperationType{})
+	s.expectChecks(nil, entity.PoolStats{Max: 10}, false)
+	report := healthSvc.Readiness(s.ctx)
+	s.False(report.IsUp())
+	s.Equal(entity.HealthStatusDown, report.Checks["operation_types"].Status)
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"transaction-routine/internal/client"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/loadgen"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeLedger keeps balances in memory behind the service mocks. Every
+// dropEvery-th transaction is acknowledged without being stored.
+type fakeLedger struct {
+	mu        sync.Mutex
+	nextID    int
+	balances  map[int]decimal.Decimal
+	created   int
+	dropEvery int
+}
+
+func newLoadgenServer(t *testing.T, ledger *fakeLedger) *httptest.Server {
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	opSvc := mocks.NewMockOpTypeService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	opTypes := entity.OperationType{
+		1: {Description: "COMPRA A VISTA"},
+		4: {Description: "PAGAMENTO", PositiveAmount: true},
+		5: {Description: "AJUSTE CREDITO", PositiveAmount: true},
+		7: {Description: "CREDITO PROVISORIO", PositiveAmount: true},
+	}
+
+	opSvc.EXPECT().GetAllOperationTypes(gomock.Any()).Return(opTypes, nil).AnyTimes()
+	accSvc.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, acc entity.Account) (entity.Account, error) {
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		ledger.nextID++
+		acc.ID = ledger.nextID
+		ledger.balances[acc.ID] = decimal.Zero
+		return acc, nil
+	}).AnyTimes()
+	accSvc.EXPECT().GetAccountByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id int) (*entity.Account, error) {
+		return &entity.Account{ID: id, DocumentNumber: "1"}, nil
+	}).AnyTimes()
+	accSvc.EXPECT().GetAccountBalance(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id int) (decimal.Decimal, error) {
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		return ledger.balances[id], nil
+	}).AnyTimes()
+	txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (entity.Transaction, error) {
+		if !opTypes[tx.OperationTypeID].PositiveAmount {
+			tx.Amount = tx.Amount.Neg()
+		}
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		ledger.created++
+		if ledger.dropEvery == 0 || ledger.created%ledger.dropEvery != 0 {
+			ledger.balances[tx.AccountID] = ledger.balances[tx.AccountID].Add(tx.Amount)
+		}
+		tx.ID = ledger.created
+		return tx, nil
+	}).AnyTimes()
+
+	srv := server.NewServer(context.Background(), &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, OpTypes: opSvc, Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestLoadgen(t *testing.T) {
+	ctx := context.Background()
+	mix, err := loadgen.ParseMix("transaction=6,balance=2,account=2")
+	require.NoError(t, err)
+
+	t.Run("balances add up", func(t *testing.T) {
+		ts := newLoadgenServer(t, &fakeLedger{balances: map[int]decimal.Decimal{}})
+		result, err := loadgen.Run(ctx, loadgen.Config{
+			Client:      client.Config{URL: ts.URL, Timeout: time.Second},
+			Accounts:    3,
+			Concurrency: 8,
+			Requests:    300,
+			Mix:         mix,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 300, result.Total.Requests)
+		assert.Zero(t, result.Total.Errors)
+		assert.Equal(t, result.Operations[loadgen.OpTransaction].Statuses["201"], result.Operations[loadgen.OpTransaction].Requests)
+		assert.Greater(t, result.Total.Latency.P99, 0.0)
+		assert.LessOrEqual(t, result.Total.Latency.P50, result.Total.Latency.P99)
+		assert.True(t, result.Balances.Passed)
+		assert.Len(t, result.Balances.Accounts, 3)
+		assert.Equal(t, []int{1, 4}, result.Settings.OperationTypes)
+	})
+
+	t.Run("target rate", func(t *testing.T) {
+		ts := newLoadgenServer(t, &fakeLedger{balances: map[int]decimal.Decimal{}})
+		start := time.Now()
+		result, err := loadgen.Run(ctx, loadgen.Config{
+			Client:      client.Config{URL: ts.URL, Timeout: time.Second},
+			Accounts:    1,
+			Concurrency: 4,
+			RPS:         200,
+			Requests:    40,
+			Mix:         mix,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 40, result.Total.Requests+result.Dropped)
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	})
+
+	t.Run("lost transactions fail the check", func(t *testing.T) {
+		ts := newLoadgenServer(t, &fakeLedger{balances: map[int]decimal.Decimal{}, dropEvery: 5})
+		result, err := loadgen.Run(ctx, loadgen.Config{
+			Client:      client.Config{URL: ts.URL, Timeout: time.Second},
+			Accounts:    1,
+			Concurrency: 2,
+			Requests:    50,
+			Mix:         loadgen.Mix{loadgen.OpTransaction: 1},
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Balances.Passed)
+	})
+
+	t.Run("system operation types are refused", func(t *testing.T) {
+		ts := newLoadgenServer(t, &fakeLedger{balances: map[int]decimal.Decimal{}})
+		_, err := loadgen.Run(ctx, loadgen.Config{
+			Client:         client.Config{URL: ts.URL, Timeout: time.Second},
+			Accounts:       1,
+			Concurrency:    1,
+			Requests:       1,
+			Mix:            mix,
+			OperationTypes: []int{1, 5},
+		})
+		assert.ErrorContains(t, err, "operation type 5 is reserved")
+	})
+
+	t.Run("invalid mix", func(t *testing.T) {
+		_, err := loadgen.ParseMix("transaction=1,delete=1")
+		assert.ErrorIs(t, err, loadgen.ErrInvalidMix)
+	})
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("invalid level", func(t *testing.T) {
+		_, err := logger.New(&bytes.Buffer{}, "verbose")
+		assert.Error(t, err)
+	})
+
+	t.Run("level filters records", func(t *testing.T) {
+		var buf bytes.Buffer
+		lg, err := logger.New(&buf, "warn")
+		require.NoError(t, err)
+		lg.Info("ignored")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("request id from context", func(t *testing.T) {
+		var buf bytes.Buffer
+		lg, err := logger.New(&buf, "info")
+		require.NoError(t, err)
+		ctx := logger.WithRequestID(context.Background(), "abc123")
+		lg.With("service", "account").InfoContext(ctx, "hello")
+
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+		assert.Equal(t, "abc123", rec["request_id"])
+		assert.Equal(t, "account", rec["service"])
+	})
+
+	t.Run("document number is masked", func(t *testing.T) {
+		var buf bytes.Buffer
+		lg, err := logger.New(&buf, "info")
+		require.NoError(t, err)
+		lg.Info("account", "account", entity.Account{ID: 1, DocumentNumber: "12345678900"})
+
+		assert.NotContains(t, buf.String(), "12345678900")
+		var rec struct {
+			Account map[string]any `json:"account"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+		assert.Equal(t, "*******8900", rec.Account["document_number"])
+	})
+}
+
+func TestMaskDocument(t *testing.T) {
+	assert.Equal(t, "*******8900", logger.MaskDocument("12345678900"))
+	assert.Equal(t, "***", logger.MaskDocument("123"))
+	assert.Equal(t, "", logger.MaskDocument(""))
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"testing"
+	"transaction-routine/internal/mergepatch"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Cases from RFC 7396, Appendix A.
+func TestMergePatchApply(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		got, err := mergepatch.Apply([]byte(c.doc), []byte(c.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, c.want, string(got), "doc %s patch %s", c.doc, c.patch)
+	}
+
+	t.Run("keeps decimal precision", func(t *testing.T) {
+		got, err := mergepatch.Apply([]byte(`{"amount":0.1}`), []byte(`{"b":12345678901234567.89}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"amount":0.1,"b":12345678901234567.89}`, string(got))
+	})
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("repository latency per method", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		repo.EXPECT().FindAccounts(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+		before := testutil.CollectAndCount(metrics.DBQueryDuration)
+
+		_, err := database.NewInstrumented(repo).FindAccounts(ctx, entity.AccountFilter{})
+		assert.Error(t, err)
+		assert.Equal(t, before+1, testutil.CollectAndCount(metrics.DBQueryDuration))
+	})
+
+	t.Run("transactions created per operation type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		opTypes := entity.OperationType{4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true}}
+		txSvc := service.NewTransactionService(cl, repo, opTypes, entity.AmountLimits{}, nil)
+		created := metrics.TransactionsCreated.WithLabelValues("PAGAMENTO")
+		rejected := metrics.ValidationRejections.WithLabelValues(entity.ErrInvalidAmount.Error())
+		createdBefore, rejectedBefore := testutil.ToFloat64(created), testutil.ToFloat64(rejected)
+
+		cl.EXPECT().Now().Return(time.Now()).Times(2)
+		repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(1, nil)
+		_, err := txSvc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(1)})
+		assert.NoError(t, err)
+		_, err = txSvc.CreateTransaction(ctx, entity.Transaction{AccountID: 1, OperationTypeID: 4})
+		assert.Error(t, err)
+
+		assert.Equal(t, createdBefore+1, testutil.ToFloat64(created))
+		assert.Equal(t, rejectedBefore+1, testutil.ToFloat64(rejected))
+	})
+
+	t.Run("metrics endpoint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		accSvc := mocks.NewMockAccountService(ctrl)
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(nil, nil)
+		srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc})
+		ts := httptest.NewServer(srv.Handler)
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/accounts/1")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		resp, err = http.Get(ts.URL + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), `transaction_routine_http_request_duration_seconds_count{method="GET",route="/accounts/{id}",status="404"}`)
+	})
+}
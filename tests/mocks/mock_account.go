@@ -42,11 +42,12 @@ func (m *MockAccountService) EXPECT() *MockAccountServiceMockRecorder {
 }
 
 // CreateAccount mocks base method.
-func (m *MockAccountService) CreateAccount(ctx context.Context, acc entity.Account) error {
+func (m *MockAccountService) CreateAccount(ctx context.Context, acc entity.Account) (entity.Account, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateAccount", ctx, acc)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateAccount indicates an expected call of CreateAccount.
@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: card.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=./../../tests/mocks/mock_card.go -package=mocks -source=card.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	entity "transaction-routine/internal/entity"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCardService is a mock of CardService interface.
+type MockCardService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCardServiceMockRecorder
+}
+
+// MockCardServiceMockRecorder is the mock recorder for MockCardService.
+type MockCardServiceMockRecorder struct {
+	mock *MockCardService
+}
+
+// NewMockCardService creates a new mock instance.
+func NewMockCardService(ctrl *gomock.Controller) *MockCardService {
+	mock := &MockCardService{ctrl: ctrl}
+	mock.recorder = &MockCardServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCardService) EXPECT() *MockCardServiceMockRecorder {
+	return m.recorder
+}
+
+// GetCard mocks base method.
+func (m *MockCardService) GetCard(ctx context.Context, token string) (*entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCard", ctx, token)
+	ret0, _ := ret[0].(*entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCard indicates an expected call of GetCard.
+func (mr *MockCardServiceMockRecorder) GetCard(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCard", reflect.TypeOf((*MockCardService)(nil).GetCard), ctx, token)
+}
+
+// IssueCard mocks base method.
+func (m *MockCardService) IssueCard(ctx context.Context, accountID int) (entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueCard", ctx, accountID)
+	ret0, _ := ret[0].(entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueCard indicates an expected call of IssueCard.
+func (mr *MockCardServiceMockRecorder) IssueCard(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueCard", reflect.TypeOf((*MockCardService)(nil).IssueCard), ctx, accountID)
+}
+
+// ListCards mocks base method.
+func (m *MockCardService) ListCards(ctx context.Context, accountID int) ([]entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCards", ctx, accountID)
+	ret0, _ := ret[0].([]entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCards indicates an expected call of ListCards.
+func (mr *MockCardServiceMockRecorder) ListCards(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCards", reflect.TypeOf((*MockCardService)(nil).ListCards), ctx, accountID)
+}
+
+// SetCardStatus mocks base method.
+func (m *MockCardService) SetCardStatus(ctx context.Context, token string, status entity.CardStatus) (entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCardStatus", ctx, token, status)
+	ret0, _ := ret[0].(entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCardStatus indicates an expected call of SetCardStatus.
+func (mr *MockCardServiceMockRecorder) SetCardStatus(ctx, token, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCardStatus", reflect.TypeOf((*MockCardService)(nil).SetCardStatus), ctx, token, status)
+}
+
+// MockCardIssuer is a mock of CardIssuer interface.
+type MockCardIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCardIssuerMockRecorder
+}
+
+// MockCardIssuerMockRecorder is the mock recorder for MockCardIssuer.
+type MockCardIssuerMockRecorder struct {
+	mock *MockCardIssuer
+}
+
+// NewMockCardIssuer creates a new mock instance.
+func NewMockCardIssuer(ctrl *gomock.Controller) *MockCardIssuer {
+	mock := &MockCardIssuer{ctrl: ctrl}
+	mock.recorder = &MockCardIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCardIssuer) EXPECT() *MockCardIssuerMockRecorder {
+	return m.recorder
+}
+
+// Issue mocks base method.
+func (m *MockCardIssuer) Issue(accountID int, now time.Time) (entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", accountID, now)
+	ret0, _ := ret[0].(entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockCardIssuerMockRecorder) Issue(accountID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockCardIssuer)(nil).Issue), accountID, now)
+}
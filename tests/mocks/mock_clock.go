@@ -39,6 +39,20 @@ func (m *MockClock) EXPECT() *MockClockMockRecorder {
 	return m.recorder
 }
 
+// Location mocks base method.
+func (m *MockClock) Location() *time.Location {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Location")
+	ret0, _ := ret[0].(*time.Location)
+	return ret0
+}
+
+// Location indicates an expected call of Location.
+func (mr *MockClockMockRecorder) Location() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Location", reflect.TypeOf((*MockClock)(nil).Location))
+}
+
 // Now mocks base method.
 func (m *MockClock) Now() time.Time {
 	m.ctrl.T.Helper()
@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dispute.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=./../../tests/mocks/mock_dispute.go -package=mocks -source=dispute.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "transaction-routine/internal/entity"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDisputeService is a mock of DisputeService interface.
+type MockDisputeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeServiceMockRecorder
+}
+
+// MockDisputeServiceMockRecorder is the mock recorder for MockDisputeService.
+type MockDisputeServiceMockRecorder struct {
+	mock *MockDisputeService
+}
+
+// NewMockDisputeService creates a new mock instance.
+func NewMockDisputeService(ctrl *gomock.Controller) *MockDisputeService {
+	mock := &MockDisputeService{ctrl: ctrl}
+	mock.recorder = &MockDisputeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeService) EXPECT() *MockDisputeServiceMockRecorder {
+	return m.recorder
+}
+
+// EnforceDeadlines mocks base method.
+func (m *MockDisputeService) EnforceDeadlines(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnforceDeadlines", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnforceDeadlines indicates an expected call of EnforceDeadlines.
+func (mr *MockDisputeServiceMockRecorder) EnforceDeadlines(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnforceDeadlines", reflect.TypeOf((*MockDisputeService)(nil).EnforceDeadlines), ctx)
+}
+
+// GetDispute mocks base method.
+func (m *MockDisputeService) GetDispute(ctx context.Context, id int) (*entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDispute", ctx, id)
+	ret0, _ := ret[0].(*entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDispute indicates an expected call of GetDispute.
+func (mr *MockDisputeServiceMockRecorder) GetDispute(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDispute", reflect.TypeOf((*MockDisputeService)(nil).GetDispute), ctx, id)
+}
+
+// ListDisputes mocks base method.
+func (m *MockDisputeService) ListDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisputes", ctx, filter)
+	ret0, _ := ret[0].([]entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisputes indicates an expected call of ListDisputes.
+func (mr *MockDisputeServiceMockRecorder) ListDisputes(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisputes", reflect.TypeOf((*MockDisputeService)(nil).ListDisputes), ctx, filter)
+}
+
+// OpenDispute mocks base method.
+func (m *MockDisputeService) OpenDispute(ctx context.Context, d entity.Dispute) (entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDispute", ctx, d)
+	ret0, _ := ret[0].(entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenDispute indicates an expected call of OpenDispute.
+func (mr *MockDisputeServiceMockRecorder) OpenDispute(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDispute", reflect.TypeOf((*MockDisputeService)(nil).OpenDispute), ctx, d)
+}
+
+// TransitionDispute mocks base method.
+func (m *MockDisputeService) TransitionDispute(ctx context.Context, id int, status entity.DisputeStatus) (entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionDispute", ctx, id, status)
+	ret0, _ := ret[0].(entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionDispute indicates an expected call of TransitionDispute.
+func (mr *MockDisputeServiceMockRecorder) TransitionDispute(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionDispute", reflect.TypeOf((*MockDisputeService)(nil).TransitionDispute), ctx, id, status)
+}
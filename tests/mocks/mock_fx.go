@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: fx.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=./../../tests/mocks/mock_fx.go -package=mocks -source=fx.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "transaction-routine/internal/entity"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFXService is a mock of FXService interface.
+type MockFXService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFXServiceMockRecorder
+}
+
+// MockFXServiceMockRecorder is the mock recorder for MockFXService.
+type MockFXServiceMockRecorder struct {
+	mock *MockFXService
+}
+
+// NewMockFXService creates a new mock instance.
+func NewMockFXService(ctrl *gomock.Controller) *MockFXService {
+	mock := &MockFXService{ctrl: ctrl}
+	mock.recorder = &MockFXServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFXService) EXPECT() *MockFXServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateRate mocks base method.
+func (m *MockFXService) CreateRate(ctx context.Context, rate entity.FXRate) (entity.FXRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRate", ctx, rate)
+	ret0, _ := ret[0].(entity.FXRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRate indicates an expected call of CreateRate.
+func (mr *MockFXServiceMockRecorder) CreateRate(ctx, rate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRate", reflect.TypeOf((*MockFXService)(nil).CreateRate), ctx, rate)
+}
+
+// ListRates mocks base method.
+func (m *MockFXService) ListRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRates", ctx, filter)
+	ret0, _ := ret[0].([]entity.FXRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRates indicates an expected call of ListRates.
+func (mr *MockFXServiceMockRecorder) ListRates(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRates", reflect.TypeOf((*MockFXService)(nil).ListRates), ctx, filter)
+}
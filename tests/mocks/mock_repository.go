@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	entity "transaction-routine/internal/entity"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,20 +41,184 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CreateAccount mocks base method.
-func (m *MockRepository) CreateAccount(ctx context.Context, acc entity.Account) error {
+// AccountBalances mocks base method.
+func (m *MockRepository) AccountBalances(ctx context.Context, asOf *time.Time) ([]entity.AccountBalance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, acc)
+	ret := m.ctrl.Call(m, "AccountBalances", ctx, asOf)
+	ret0, _ := ret[0].([]entity.AccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountBalances indicates an expected call of AccountBalances.
+func (mr *MockRepositoryMockRecorder) AccountBalances(ctx, asOf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountBalances", reflect.TypeOf((*MockRepository)(nil).AccountBalances), ctx, asOf)
+}
+
+// CancelSchedule mocks base method.
+func (m *MockRepository) CancelSchedule(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule.
+func (mr *MockRepositoryMockRecorder) CancelSchedule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockRepository)(nil).CancelSchedule), ctx, id)
+}
+
+// ClaimDeliveries mocks base method.
+func (m *MockRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDeliveries", ctx, limit, lease)
+	ret0, _ := ret[0].([]entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDeliveries indicates an expected call of ClaimDeliveries.
+func (mr *MockRepositoryMockRecorder) ClaimDeliveries(ctx, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDeliveries", reflect.TypeOf((*MockRepository)(nil).ClaimDeliveries), ctx, limit, lease)
+}
+
+// ClaimScheduleRuns mocks base method.
+func (m *MockRepository) ClaimScheduleRuns(ctx context.Context, now time.Time, limit int) ([]entity.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimScheduleRuns", ctx, now, limit)
+	ret0, _ := ret[0].([]entity.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimScheduleRuns indicates an expected call of ClaimScheduleRuns.
+func (mr *MockRepositoryMockRecorder) ClaimScheduleRuns(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimScheduleRuns", reflect.TypeOf((*MockRepository)(nil).ClaimScheduleRuns), ctx, now, limit)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockRepository) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, key)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockRepositoryMockRecorder) CreateAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockRepository)(nil).CreateAPIKey), ctx, key)
+}
+
+// CreateAccount mocks base method.
+func (m *MockRepository) CreateAccount(ctx context.Context, acc entity.Account) (entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, acc)
+	ret0, _ := ret[0].(entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // CreateAccount indicates an expected call of CreateAccount.
 func (mr *MockRepositoryMockRecorder) CreateAccount(ctx, acc any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockRepository)(nil).CreateAccount), ctx, acc)
 }
 
+// CreateAdjustments mocks base method.
+func (m *MockRepository) CreateAdjustments(ctx context.Context, adjs []entity.Adjustment) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAdjustments", ctx, adjs)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAdjustments indicates an expected call of CreateAdjustments.
+func (mr *MockRepositoryMockRecorder) CreateAdjustments(ctx, adjs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdjustments", reflect.TypeOf((*MockRepository)(nil).CreateAdjustments), ctx, adjs)
+}
+
+// CreateCard mocks base method.
+func (m *MockRepository) CreateCard(ctx context.Context, c entity.Card) (entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCard", ctx, c)
+	ret0, _ := ret[0].(entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCard indicates an expected call of CreateCard.
+func (mr *MockRepositoryMockRecorder) CreateCard(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCard", reflect.TypeOf((*MockRepository)(nil).CreateCard), ctx, c)
+}
+
+// CreateCustomer mocks base method.
+func (m *MockRepository) CreateCustomer(ctx context.Context, c entity.Customer) (entity.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCustomer", ctx, c)
+	ret0, _ := ret[0].(entity.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCustomer indicates an expected call of CreateCustomer.
+func (mr *MockRepositoryMockRecorder) CreateCustomer(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCustomer", reflect.TypeOf((*MockRepository)(nil).CreateCustomer), ctx, c)
+}
+
+// CreateDispute mocks base method.
+func (m *MockRepository) CreateDispute(ctx context.Context, d entity.Dispute) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDispute", ctx, d)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDispute indicates an expected call of CreateDispute.
+func (mr *MockRepositoryMockRecorder) CreateDispute(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDispute", reflect.TypeOf((*MockRepository)(nil).CreateDispute), ctx, d)
+}
+
+// CreateFXRate mocks base method.
+func (m *MockRepository) CreateFXRate(ctx context.Context, rate entity.FXRate) (entity.FXRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFXRate", ctx, rate)
+	ret0, _ := ret[0].(entity.FXRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFXRate indicates an expected call of CreateFXRate.
+func (mr *MockRepositoryMockRecorder) CreateFXRate(ctx, rate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFXRate", reflect.TypeOf((*MockRepository)(nil).CreateFXRate), ctx, rate)
+}
+
+// CreateFraudDecision mocks base method.
+func (m *MockRepository) CreateFraudDecision(ctx context.Context, d entity.FraudDecision) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFraudDecision", ctx, d)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFraudDecision indicates an expected call of CreateFraudDecision.
+func (mr *MockRepositoryMockRecorder) CreateFraudDecision(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFraudDecision", reflect.TypeOf((*MockRepository)(nil).CreateFraudDecision), ctx, d)
+}
+
 // CreateOperationType mocks base method.
 func (m *MockRepository) CreateOperationType(ctx context.Context, op entity.Operation) error {
 	m.ctrl.T.Helper()
@@ -68,12 +233,28 @@ func (mr *MockRepositoryMockRecorder) CreateOperationType(ctx, op any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOperationType", reflect.TypeOf((*MockRepository)(nil).CreateOperationType), ctx, op)
 }
 
+// CreateSchedule mocks base method.
+func (m *MockRepository) CreateSchedule(ctx context.Context, s entity.Schedule) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", ctx, s)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockRepositoryMockRecorder) CreateSchedule(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockRepository)(nil).CreateSchedule), ctx, s)
+}
+
 // CreateTransaction mocks base method.
-func (m *MockRepository) CreateTransaction(ctx context.Context, tx entity.Transaction) error {
+func (m *MockRepository) CreateTransaction(ctx context.Context, tx entity.Transaction) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateTransaction", ctx, tx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateTransaction indicates an expected call of CreateTransaction.
@@ -82,6 +263,110 @@ func (mr *MockRepositoryMockRecorder) CreateTransaction(ctx, tx any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockRepository)(nil).CreateTransaction), ctx, tx)
 }
 
+// CreateTransactions mocks base method.
+func (m *MockRepository) CreateTransactions(ctx context.Context, txs []entity.Transaction) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransactions", ctx, txs)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransactions indicates an expected call of CreateTransactions.
+func (mr *MockRepositoryMockRecorder) CreateTransactions(ctx, txs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransactions", reflect.TypeOf((*MockRepository)(nil).CreateTransactions), ctx, txs)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockRepository) CreateWebhook(ctx context.Context, sub entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, sub)
+	ret0, _ := ret[0].(entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockRepositoryMockRecorder) CreateWebhook(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockRepository)(nil).CreateWebhook), ctx, sub)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockRepository) DeleteWebhook(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockRepositoryMockRecorder) DeleteWebhook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockRepository)(nil).DeleteWebhook), ctx, id)
+}
+
+// EnqueueDeliveries mocks base method.
+func (m *MockRepository) EnqueueDeliveries(ctx context.Context, event entity.Event) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueDeliveries", ctx, event)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueDeliveries indicates an expected call of EnqueueDeliveries.
+func (mr *MockRepositoryMockRecorder) EnqueueDeliveries(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueDeliveries", reflect.TypeOf((*MockRepository)(nil).EnqueueDeliveries), ctx, event)
+}
+
+// FindAPIKeyByHash mocks base method.
+func (m *MockRepository) FindAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(*entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAPIKeyByHash indicates an expected call of FindAPIKeyByHash.
+func (mr *MockRepositoryMockRecorder) FindAPIKeyByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAPIKeyByHash", reflect.TypeOf((*MockRepository)(nil).FindAPIKeyByHash), ctx, hash)
+}
+
+// FindAccountCurrencies mocks base method.
+func (m *MockRepository) FindAccountCurrencies(ctx context.Context, ids []int) (map[int]entity.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAccountCurrencies", ctx, ids)
+	ret0, _ := ret[0].(map[int]entity.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAccountCurrencies indicates an expected call of FindAccountCurrencies.
+func (mr *MockRepositoryMockRecorder) FindAccountCurrencies(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAccountCurrencies", reflect.TypeOf((*MockRepository)(nil).FindAccountCurrencies), ctx, ids)
+}
+
+// FindAccountIDs mocks base method.
+func (m *MockRepository) FindAccountIDs(ctx context.Context, ids []int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAccountIDs", ctx, ids)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAccountIDs indicates an expected call of FindAccountIDs.
+func (mr *MockRepositoryMockRecorder) FindAccountIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAccountIDs", reflect.TypeOf((*MockRepository)(nil).FindAccountIDs), ctx, ids)
+}
+
 // FindAccounts mocks base method.
 func (m *MockRepository) FindAccounts(ctx context.Context, filter entity.AccountFilter) ([]entity.Account, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +382,141 @@ func (mr *MockRepositoryMockRecorder) FindAccounts(ctx, filter any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAccounts", reflect.TypeOf((*MockRepository)(nil).FindAccounts), ctx, filter)
 }
 
+// FindCards mocks base method.
+func (m *MockRepository) FindCards(ctx context.Context, filter entity.CardFilter) ([]entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCards", ctx, filter)
+	ret0, _ := ret[0].([]entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCards indicates an expected call of FindCards.
+func (mr *MockRepositoryMockRecorder) FindCards(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCards", reflect.TypeOf((*MockRepository)(nil).FindCards), ctx, filter)
+}
+
+// FindCustomerBalances mocks base method.
+func (m *MockRepository) FindCustomerBalances(ctx context.Context, customerID int) ([]entity.CustomerAccountBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCustomerBalances", ctx, customerID)
+	ret0, _ := ret[0].([]entity.CustomerAccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCustomerBalances indicates an expected call of FindCustomerBalances.
+func (mr *MockRepositoryMockRecorder) FindCustomerBalances(ctx, customerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCustomerBalances", reflect.TypeOf((*MockRepository)(nil).FindCustomerBalances), ctx, customerID)
+}
+
+// FindCustomers mocks base method.
+func (m *MockRepository) FindCustomers(ctx context.Context, filter entity.CustomerFilter) ([]entity.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCustomers", ctx, filter)
+	ret0, _ := ret[0].([]entity.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCustomers indicates an expected call of FindCustomers.
+func (mr *MockRepositoryMockRecorder) FindCustomers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCustomers", reflect.TypeOf((*MockRepository)(nil).FindCustomers), ctx, filter)
+}
+
+// FindDeliveries mocks base method.
+func (m *MockRepository) FindDeliveries(ctx context.Context, filter entity.DeliveryFilter) ([]entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeliveries", ctx, filter)
+	ret0, _ := ret[0].([]entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeliveries indicates an expected call of FindDeliveries.
+func (mr *MockRepositoryMockRecorder) FindDeliveries(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeliveries", reflect.TypeOf((*MockRepository)(nil).FindDeliveries), ctx, filter)
+}
+
+// FindDisputes mocks base method.
+func (m *MockRepository) FindDisputes(ctx context.Context, filter entity.DisputeFilter) ([]entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDisputes", ctx, filter)
+	ret0, _ := ret[0].([]entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDisputes indicates an expected call of FindDisputes.
+func (mr *MockRepositoryMockRecorder) FindDisputes(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDisputes", reflect.TypeOf((*MockRepository)(nil).FindDisputes), ctx, filter)
+}
+
+// FindEvents mocks base method.
+func (m *MockRepository) FindEvents(ctx context.Context, filter entity.EventFilter) ([]entity.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEvents", ctx, filter)
+	ret0, _ := ret[0].([]entity.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEvents indicates an expected call of FindEvents.
+func (mr *MockRepositoryMockRecorder) FindEvents(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEvents", reflect.TypeOf((*MockRepository)(nil).FindEvents), ctx, filter)
+}
+
+// FindFXRate mocks base method.
+func (m *MockRepository) FindFXRate(ctx context.Context, base, quote entity.Currency, at time.Time) (*entity.FXRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFXRate", ctx, base, quote, at)
+	ret0, _ := ret[0].(*entity.FXRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFXRate indicates an expected call of FindFXRate.
+func (mr *MockRepositoryMockRecorder) FindFXRate(ctx, base, quote, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFXRate", reflect.TypeOf((*MockRepository)(nil).FindFXRate), ctx, base, quote, at)
+}
+
+// FindFXRates mocks base method.
+func (m *MockRepository) FindFXRates(ctx context.Context, filter entity.FXRateFilter) ([]entity.FXRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFXRates", ctx, filter)
+	ret0, _ := ret[0].([]entity.FXRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFXRates indicates an expected call of FindFXRates.
+func (mr *MockRepositoryMockRecorder) FindFXRates(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFXRates", reflect.TypeOf((*MockRepository)(nil).FindFXRates), ctx, filter)
+}
+
+// FindFraudDecisions mocks base method.
+func (m *MockRepository) FindFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFraudDecisions", ctx, filter)
+	ret0, _ := ret[0].([]entity.FraudDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFraudDecisions indicates an expected call of FindFraudDecisions.
+func (mr *MockRepositoryMockRecorder) FindFraudDecisions(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFraudDecisions", reflect.TypeOf((*MockRepository)(nil).FindFraudDecisions), ctx, filter)
+}
+
 // FindOperationType mocks base method.
 func (m *MockRepository) FindOperationType(ctx context.Context) (entity.OperationType, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +532,51 @@ func (mr *MockRepositoryMockRecorder) FindOperationType(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOperationType", reflect.TypeOf((*MockRepository)(nil).FindOperationType), ctx)
 }
 
+// FindScheduleRuns mocks base method.
+func (m *MockRepository) FindScheduleRuns(ctx context.Context, scheduleID int) ([]entity.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindScheduleRuns", ctx, scheduleID)
+	ret0, _ := ret[0].([]entity.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindScheduleRuns indicates an expected call of FindScheduleRuns.
+func (mr *MockRepositoryMockRecorder) FindScheduleRuns(ctx, scheduleID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindScheduleRuns", reflect.TypeOf((*MockRepository)(nil).FindScheduleRuns), ctx, scheduleID)
+}
+
+// FindSchedules mocks base method.
+func (m *MockRepository) FindSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSchedules", ctx, filter)
+	ret0, _ := ret[0].([]entity.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSchedules indicates an expected call of FindSchedules.
+func (mr *MockRepositoryMockRecorder) FindSchedules(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSchedules", reflect.TypeOf((*MockRepository)(nil).FindSchedules), ctx, filter)
+}
+
+// FindSignViolations mocks base method.
+func (m *MockRepository) FindSignViolations(ctx context.Context) ([]entity.SignViolation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSignViolations", ctx)
+	ret0, _ := ret[0].([]entity.SignViolation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSignViolations indicates an expected call of FindSignViolations.
+func (mr *MockRepositoryMockRecorder) FindSignViolations(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSignViolations", reflect.TypeOf((*MockRepository)(nil).FindSignViolations), ctx)
+}
+
 // FindTransactions mocks base method.
 func (m *MockRepository) FindTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -127,6 +592,50 @@ func (mr *MockRepositoryMockRecorder) FindTransactions(ctx, filter any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactions", reflect.TypeOf((*MockRepository)(nil).FindTransactions), ctx, filter)
 }
 
+// FindWebhook mocks base method.
+func (m *MockRepository) FindWebhook(ctx context.Context, id int) (*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhook", ctx, id)
+	ret0, _ := ret[0].(*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhook indicates an expected call of FindWebhook.
+func (mr *MockRepositoryMockRecorder) FindWebhook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhook", reflect.TypeOf((*MockRepository)(nil).FindWebhook), ctx, id)
+}
+
+// FindWebhooks mocks base method.
+func (m *MockRepository) FindWebhooks(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhooks", ctx)
+	ret0, _ := ret[0].([]entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhooks indicates an expected call of FindWebhooks.
+func (mr *MockRepositoryMockRecorder) FindWebhooks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhooks", reflect.TypeOf((*MockRepository)(nil).FindWebhooks), ctx)
+}
+
+// FinishScheduleRun mocks base method.
+func (m *MockRepository) FinishScheduleRun(ctx context.Context, run entity.ScheduleRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishScheduleRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishScheduleRun indicates an expected call of FinishScheduleRun.
+func (mr *MockRepositoryMockRecorder) FinishScheduleRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishScheduleRun", reflect.TypeOf((*MockRepository)(nil).FinishScheduleRun), ctx, run)
+}
+
 // Health mocks base method.
 func (m *MockRepository) Health(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -141,6 +650,169 @@ func (mr *MockRepositoryMockRecorder) Health(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockRepository)(nil).Health), ctx)
 }
 
+// LastEventID mocks base method.
+func (m *MockRepository) LastEventID(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastEventID", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastEventID indicates an expected call of LastEventID.
+func (mr *MockRepositoryMockRecorder) LastEventID(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastEventID", reflect.TypeOf((*MockRepository)(nil).LastEventID), ctx)
+}
+
+// MigrationVersion mocks base method.
+func (m *MockRepository) MigrationVersion(ctx context.Context) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationVersion", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrationVersion indicates an expected call of MigrationVersion.
+func (mr *MockRepositoryMockRecorder) MigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationVersion", reflect.TypeOf((*MockRepository)(nil).MigrationVersion), ctx)
+}
+
+// RecentTransactions mocks base method.
+func (m *MockRepository) RecentTransactions(ctx context.Context, accountID int, since time.Time) ([]entity.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecentTransactions", ctx, accountID, since)
+	ret0, _ := ret[0].([]entity.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecentTransactions indicates an expected call of RecentTransactions.
+func (mr *MockRepositoryMockRecorder) RecentTransactions(ctx, accountID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecentTransactions", reflect.TypeOf((*MockRepository)(nil).RecentTransactions), ctx, accountID, since)
+}
+
+// ReclaimScheduleRuns mocks base method.
+func (m *MockRepository) ReclaimScheduleRuns(ctx context.Context, before, now time.Time, limit int) ([]entity.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReclaimScheduleRuns", ctx, before, now, limit)
+	ret0, _ := ret[0].([]entity.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReclaimScheduleRuns indicates an expected call of ReclaimScheduleRuns.
+func (mr *MockRepositoryMockRecorder) ReclaimScheduleRuns(ctx, before, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReclaimScheduleRuns", reflect.TypeOf((*MockRepository)(nil).ReclaimScheduleRuns), ctx, before, now, limit)
+}
+
+// RelayEvents mocks base method.
+func (m *MockRepository) RelayEvents(ctx context.Context, limit int, gapWait time.Duration, publish func(entity.Event) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayEvents", ctx, limit, gapWait, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayEvents indicates an expected call of RelayEvents.
+func (mr *MockRepositoryMockRecorder) RelayEvents(ctx, limit, gapWait, publish any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayEvents", reflect.TypeOf((*MockRepository)(nil).RelayEvents), ctx, limit, gapWait, publish)
+}
+
+// ReplayDelivery mocks base method.
+func (m *MockRepository) ReplayDelivery(ctx context.Context, subscriptionID int, id int64) (entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDelivery", ctx, subscriptionID, id)
+	ret0, _ := ret[0].(entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayDelivery indicates an expected call of ReplayDelivery.
+func (mr *MockRepositoryMockRecorder) ReplayDelivery(ctx, subscriptionID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDelivery", reflect.TypeOf((*MockRepository)(nil).ReplayDelivery), ctx, subscriptionID, id)
+}
+
+// StartScheduleRun mocks base method.
+func (m *MockRepository) StartScheduleRun(ctx context.Context, id int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartScheduleRun", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartScheduleRun indicates an expected call of StartScheduleRun.
+func (mr *MockRepositoryMockRecorder) StartScheduleRun(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartScheduleRun", reflect.TypeOf((*MockRepository)(nil).StartScheduleRun), ctx, id, at)
+}
+
+// Stat mocks base method.
+func (m *MockRepository) Stat() entity.PoolStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat")
+	ret0, _ := ret[0].(entity.PoolStats)
+	return ret0
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockRepositoryMockRecorder) Stat() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockRepository)(nil).Stat))
+}
+
+// TransitionDispute mocks base method.
+func (m *MockRepository) TransitionDispute(ctx context.Context, d entity.Dispute, from entity.DisputeStatus, posting *entity.Transaction) (entity.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionDispute", ctx, d, from, posting)
+	ret0, _ := ret[0].(entity.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionDispute indicates an expected call of TransitionDispute.
+func (mr *MockRepositoryMockRecorder) TransitionDispute(ctx, d, from, posting any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionDispute", reflect.TypeOf((*MockRepository)(nil).TransitionDispute), ctx, d, from, posting)
+}
+
+// UpdateCardStatus mocks base method.
+func (m *MockRepository) UpdateCardStatus(ctx context.Context, token string, status entity.CardStatus) (entity.Card, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCardStatus", ctx, token, status)
+	ret0, _ := ret[0].(entity.Card)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCardStatus indicates an expected call of UpdateCardStatus.
+func (mr *MockRepositoryMockRecorder) UpdateCardStatus(ctx, token, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCardStatus", reflect.TypeOf((*MockRepository)(nil).UpdateCardStatus), ctx, token, status)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockRepository) UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockRepositoryMockRecorder) UpdateDelivery(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockRepository)(nil).UpdateDelivery), ctx, d)
+}
+
 // UpdateTransaction mocks base method.
 func (m *MockRepository) UpdateTransaction(ctx context.Context, tx entity.Transaction) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: schedule.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=./../../tests/mocks/mock_schedule.go -package=mocks -source=schedule.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "transaction-routine/internal/entity"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockScheduleService is a mock of ScheduleService interface.
+type MockScheduleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleServiceMockRecorder
+}
+
+// MockScheduleServiceMockRecorder is the mock recorder for MockScheduleService.
+type MockScheduleServiceMockRecorder struct {
+	mock *MockScheduleService
+}
+
+// NewMockScheduleService creates a new mock instance.
+func NewMockScheduleService(ctrl *gomock.Controller) *MockScheduleService {
+	mock := &MockScheduleService{ctrl: ctrl}
+	mock.recorder = &MockScheduleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleService) EXPECT() *MockScheduleServiceMockRecorder {
+	return m.recorder
+}
+
+// CancelSchedule mocks base method.
+func (m *MockScheduleService) CancelSchedule(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule.
+func (mr *MockScheduleServiceMockRecorder) CancelSchedule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockScheduleService)(nil).CancelSchedule), ctx, id)
+}
+
+// CreateSchedule mocks base method.
+func (m *MockScheduleService) CreateSchedule(ctx context.Context, s entity.Schedule) (entity.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", ctx, s)
+	ret0, _ := ret[0].(entity.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockScheduleServiceMockRecorder) CreateSchedule(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockScheduleService)(nil).CreateSchedule), ctx, s)
+}
+
+// GetSchedule mocks base method.
+func (m *MockScheduleService) GetSchedule(ctx context.Context, id int) (*entity.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", ctx, id)
+	ret0, _ := ret[0].(*entity.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockScheduleServiceMockRecorder) GetSchedule(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockScheduleService)(nil).GetSchedule), ctx, id)
+}
+
+// ListRuns mocks base method.
+func (m *MockScheduleService) ListRuns(ctx context.Context, id int) ([]entity.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRuns", ctx, id)
+	ret0, _ := ret[0].([]entity.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRuns indicates an expected call of ListRuns.
+func (mr *MockScheduleServiceMockRecorder) ListRuns(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRuns", reflect.TypeOf((*MockScheduleService)(nil).ListRuns), ctx, id)
+}
+
+// ListSchedules mocks base method.
+func (m *MockScheduleService) ListSchedules(ctx context.Context, filter entity.ScheduleFilter) ([]entity.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSchedules", ctx, filter)
+	ret0, _ := ret[0].([]entity.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSchedules indicates an expected call of ListSchedules.
+func (mr *MockScheduleServiceMockRecorder) ListSchedules(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSchedules", reflect.TypeOf((*MockScheduleService)(nil).ListSchedules), ctx, filter)
+}
@@ -41,11 +41,12 @@ func (m *MockTransactionService) EXPECT() *MockTransactionServiceMockRecorder {
 }
 
 // CreateTransaction mocks base method.
-func (m *MockTransactionService) CreateTransaction(ctx context.Context, t entity.Transaction) error {
+func (m *MockTransactionService) CreateTransaction(ctx context.Context, t entity.Transaction) (entity.Transaction, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateTransaction", ctx, t)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateTransaction indicates an expected call of CreateTransaction.
@@ -54,6 +55,80 @@ func (mr *MockTransactionServiceMockRecorder) CreateTransaction(ctx, t any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockTransactionService)(nil).CreateTransaction), ctx, t)
 }
 
+// CreateTransactions mocks base method.
+func (m *MockTransactionService) CreateTransactions(ctx context.Context, items []entity.BatchItem, mode entity.BatchMode) (entity.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransactions", ctx, items, mode)
+	ret0, _ := ret[0].(entity.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransactions indicates an expected call of CreateTransactions.
+func (mr *MockTransactionServiceMockRecorder) CreateTransactions(ctx, items, mode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransactions", reflect.TypeOf((*MockTransactionService)(nil).CreateTransactions), ctx, items, mode)
+}
+
+// ListFraudDecisions mocks base method.
+func (m *MockTransactionService) ListFraudDecisions(ctx context.Context, filter entity.FraudDecisionFilter) ([]entity.FraudDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFraudDecisions", ctx, filter)
+	ret0, _ := ret[0].([]entity.FraudDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFraudDecisions indicates an expected call of ListFraudDecisions.
+func (mr *MockTransactionServiceMockRecorder) ListFraudDecisions(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFraudDecisions", reflect.TypeOf((*MockTransactionService)(nil).ListFraudDecisions), ctx, filter)
+}
+
+// ListTransactions mocks base method.
+func (m *MockTransactionService) ListTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, filter)
+	ret0, _ := ret[0].([]entity.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockTransactionServiceMockRecorder) ListTransactions(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockTransactionService)(nil).ListTransactions), ctx, filter)
+}
+
+// PatchTransaction mocks base method.
+func (m *MockTransactionService) PatchTransaction(ctx context.Context, id int, patch []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchTransaction", ctx, id, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchTransaction indicates an expected call of PatchTransaction.
+func (mr *MockTransactionServiceMockRecorder) PatchTransaction(ctx, id, patch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchTransaction", reflect.TypeOf((*MockTransactionService)(nil).PatchTransaction), ctx, id, patch)
+}
+
+// ReconcileBalances mocks base method.
+func (m *MockTransactionService) ReconcileBalances(ctx context.Context, req entity.ReconciliationRequest) (entity.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileBalances", ctx, req)
+	ret0, _ := ret[0].(entity.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileBalances indicates an expected call of ReconcileBalances.
+func (mr *MockTransactionServiceMockRecorder) ReconcileBalances(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileBalances", reflect.TypeOf((*MockTransactionService)(nil).ReconcileBalances), ctx, req)
+}
+
 // UpdateTransaction mocks base method.
 func (m *MockTransactionService) UpdateTransaction(ctx context.Context, t entity.Transaction) error {
 	m.ctrl.T.Helper()
@@ -67,3 +142,56 @@ func (mr *MockTransactionServiceMockRecorder) UpdateTransaction(ctx, t any) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransaction", reflect.TypeOf((*MockTransactionService)(nil).UpdateTransaction), ctx, t)
 }
+
+// MockScreener is a mock of Screener interface.
+type MockScreener struct {
+	ctrl     *gomock.Controller
+	recorder *MockScreenerMockRecorder
+}
+
+// MockScreenerMockRecorder is the mock recorder for MockScreener.
+type MockScreenerMockRecorder struct {
+	mock *MockScreener
+}
+
+// NewMockScreener creates a new mock instance.
+func NewMockScreener(ctrl *gomock.Controller) *MockScreener {
+	mock := &MockScreener{ctrl: ctrl}
+	mock.recorder = &MockScreenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScreener) EXPECT() *MockScreenerMockRecorder {
+	return m.recorder
+}
+
+// Screen mocks base method.
+func (m *MockScreener) Screen(ctx context.Context, tx entity.Transaction) (entity.FraudDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Screen", ctx, tx)
+	ret0, _ := ret[0].(entity.FraudDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Screen indicates an expected call of Screen.
+func (mr *MockScreenerMockRecorder) Screen(ctx, tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Screen", reflect.TypeOf((*MockScreener)(nil).Screen), ctx, tx)
+}
+
+// ScreenBatch mocks base method.
+func (m *MockScreener) ScreenBatch(ctx context.Context, txs []entity.Transaction) ([]entity.FraudDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScreenBatch", ctx, txs)
+	ret0, _ := ret[0].([]entity.FraudDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScreenBatch indicates an expected call of ScreenBatch.
+func (mr *MockScreenerMockRecorder) ScreenBatch(ctx, txs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScreenBatch", reflect.TypeOf((*MockScreener)(nil).ScreenBatch), ctx, txs)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVersionedAPI(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	post := func(path, body string) (*http.Response, string) {
+		resp, err := http.Post(ts.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("openapi document", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/v1/openapi.json")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var doc map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		assert.Equal(t, "3.0.3", doc["openapi"])
+	})
+
+	t.Run("versioned route", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(&entity.Account{ID: 1}, nil)
+		resp, err := http.Get(ts.URL + "/v1/accounts/1")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Deprecation"))
+	})
+
+	t.Run("deprecated alias", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(&entity.Account{ID: 1}, nil)
+		resp, err := http.Get(ts.URL + "/accounts/1")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+		assert.Equal(t, `</v1/accounts/1>; rel="successor-version"`, resp.Header.Get("Link"))
+	})
+
+	t.Run("valid body", func(t *testing.T) {
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(entity.Transaction{ID: 1}, nil)
+		resp, _ := post("/v1/transactions", `{"account_id":1,"operation_type_id":1,"amount":"10.50"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("wrong field type", func(t *testing.T) {
+		resp, body := post("/v1/transactions", `{"account_id":"1","operation_type_id":1,"amount":10}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "account_id")
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		resp, body := post("/transactions", `{"account_id":1,"amount":10}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "operation_type_id")
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		resp, _ := post("/v1/accounts", ``)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
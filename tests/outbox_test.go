@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/outbox"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	tx := entity.Transaction{ID: 3, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10), EventDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	t.Run("relay keeps the order of each account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		pub := mocks.NewMockPublisher(ctrl)
+		events := []entity.Event{
+			{ID: 1, AccountID: 1},
+			{ID: 2, AccountID: 2},
+			{ID: 3, AccountID: 1},
+			{ID: 4, AccountID: 2},
+		}
+		var accepted []int64
+		repo.EXPECT().RelayEvents(gomock.Any(), 10, 5*time.Second, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, _ time.Duration, publish func(entity.Event) error) (int, error) {
+			for _, e := range events {
+				if publish(e) == nil {
+					accepted = append(accepted, e.ID)
+				}
+			}
+			return len(accepted), nil
+		})
+		gomock.InOrder(
+			pub.EXPECT().Publish(gomock.Any(), events[0]).Return(errors.New("unavailable")),
+			pub.EXPECT().Publish(gomock.Any(), events[1]).Return(nil),
+			pub.EXPECT().Publish(gomock.Any(), events[3]).Return(nil),
+		)
+
+		n, err := outbox.NewRelay(repo, pub, time.Second, 5*time.Second, 10).RelayOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []int64{2, 4}, accepted)
+	})
+
+	t.Run("relay holds back both accounts of a moved transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		pub := mocks.NewMockPublisher(ctrl)
+		moved := tx
+		moved.AccountID = 2
+		events := []entity.Event{
+			{ID: 1, AccountID: 1},
+			entity.NewTransactionUpdated(moved, tx),
+			{ID: 3, AccountID: 2},
+			{ID: 4, AccountID: 3},
+		}
+		events[1].ID = 2
+		var accepted []int64
+		repo.EXPECT().RelayEvents(gomock.Any(), 10, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ int, _ time.Duration, publish func(entity.Event) error) (int, error) {
+			for _, e := range events {
+				if publish(e) == nil {
+					accepted = append(accepted, e.ID)
+				}
+			}
+			return len(accepted), nil
+		})
+		gomock.InOrder(
+			pub.EXPECT().Publish(gomock.Any(), events[0]).Return(errors.New("unavailable")),
+			pub.EXPECT().Publish(gomock.Any(), events[3]).Return(nil),
+		)
+
+		n, err := outbox.NewRelay(repo, pub, time.Second, 5*time.Second, 10).RelayOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []int64{4}, accepted)
+	})
+
+	t.Run("relay drains full batches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		ctx, cancel := context.WithCancel(ctx)
+		gomock.InOrder(
+			repo.EXPECT().RelayEvents(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(2, nil),
+			repo.EXPECT().RelayEvents(gomock.Any(), 2, gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, int, time.Duration, func(entity.Event) error) (int, error) {
+				cancel()
+				return 1, nil
+			}),
+		)
+		outbox.NewRelay(repo, mocks.NewMockPublisher(ctrl), time.Hour, time.Second, 2).Run(ctx)
+	})
+
+	t.Run("file publisher writes json lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		pub := outbox.NewFilePublisher(&buf)
+		require.NoError(t, pub.Publish(ctx, entity.NewTransactionCreated(tx)))
+		require.NoError(t, pub.Publish(ctx, entity.NewAccountCreated(entity.Account{ID: 1, DocumentNumber: "123"})))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+		assert.Equal(t, "TransactionCreated", event["type"])
+		assert.Equal(t, float64(1), event["account_id"])
+		assert.Equal(t, "2024-01-02T03:04:05+00:00", event["payload"].(map[string]any)["event_date"])
+	})
+
+	t.Run("http publisher", func(t *testing.T) {
+		status := http.StatusAccepted
+		var got *http.Request
+		var body []byte
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			var buf bytes.Buffer
+			_, _ = buf.ReadFrom(r.Body)
+			body = buf.Bytes()
+			w.WriteHeader(status)
+		}))
+		defer ts.Close()
+		pub := outbox.NewHTTPPublisher(ts.URL, time.Second)
+
+		event := entity.NewTransactionUpdated(tx, entity.Transaction{ID: 3, AccountID: 2})
+		event.ID = 42
+		require.NoError(t, pub.Publish(ctx, event))
+		assert.Equal(t, "42", got.Header.Get("X-Event-ID"))
+		assert.Equal(t, "TransactionUpdated", got.Header.Get("X-Event-Type"))
+		var decoded entity.Event
+		require.NoError(t, json.Unmarshal(body, &decoded))
+		var change entity.TransactionChange
+		require.NoError(t, json.Unmarshal(decoded.Payload, &change))
+		assert.Equal(t, 1, change.Transaction.AccountID)
+		assert.Equal(t, 2, change.Previous.AccountID)
+
+		status = http.StatusServiceUnavailable
+		assert.Error(t, pub.Publish(ctx, event))
+	})
+}
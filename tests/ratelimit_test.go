@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/metrics"
+	"transaction-routine/internal/ratelimit"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParsePolicies(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := ratelimit.ParsePolicies("default=client:100/s; POST /transactions=client:20/m,account:5/h")
+		require.NoError(t, err)
+		assert.Equal(t, ratelimit.Rule{Limit: 100, Period: time.Second}, p.For("GET /accounts/{id}")[ratelimit.KeyClient])
+		assert.Equal(t, ratelimit.Rule{Limit: 20, Period: time.Minute}, p.For("POST /transactions")[ratelimit.KeyClient])
+		assert.Equal(t, ratelimit.Rule{Limit: 5, Period: time.Hour}, p.For("POST /transactions")[ratelimit.KeyAccount])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{"default", "default=ip:1/s", "default=client:0/s", "default=client:1/d", "default=client:1"} {
+			_, err := ratelimit.ParsePolicies(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	cl := mocks.NewMockClock(gomock.NewController(t))
+	cl.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+	l := ratelimit.New(cl, nil)
+	rule := ratelimit.Rule{Limit: 2, Period: time.Second}
+
+	res := l.Allow("a", rule)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+	assert.True(t, l.Allow("a", rule).Allowed)
+
+	res = l.Allow("a", rule)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	assert.Equal(t, time.Second, res.Reset)
+
+	assert.True(t, l.Allow("b", rule).Allowed, "buckets are per key")
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow("a", rule).Allowed)
+	assert.False(t, l.Allow("a", rule).Allowed)
+
+	// Either every bucket gives its tokens or none does.
+	res = l.AllowEach(map[string]int{"a": 1, "c": 2}, rule)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	res = l.AllowEach(map[string]int{"c": 2}, rule)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// Across rules too: the roomier bucket keeps its tokens when another one
+	// is empty.
+	wide := ratelimit.Rule{Limit: 5, Period: time.Second}
+	results := l.Take([]ratelimit.Cost{{Key: "d", Rule: wide, Tokens: 1}, {Key: "c", Rule: rule, Tokens: 1}})
+	assert.False(t, results[0].Allowed)
+	assert.Equal(t, 5, results[0].Remaining)
+	assert.Equal(t, 1, ratelimit.Tightest(results))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(entity.Transaction{}, nil).AnyTimes()
+	policies, err := ratelimit.ParsePolicies("default=client:100/s;POST /transactions=client:10/s,account:1/s")
+	require.NoError(t, err)
+	now := time.Now()
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Limiter: ratelimit.New(cl, policies), Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	post := func(accountID string) *http.Response {
+		resp, err := http.Post(ts.URL+"/transactions", "application/json", strings.NewReader(`{"account_id":`+accountID+`,"operation_type_id":1,"amount":1}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	throttled := metrics.ThrottledRequests.WithLabelValues("POST /transactions", ratelimit.KeyAccount)
+	before := testutil.ToFloat64(throttled)
+
+	resp := post("1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	resp = post("1")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	assert.Equal(t, before+1, testutil.ToFloat64(throttled))
+
+	assert.Equal(t, http.StatusCreated, post("2").StatusCode, "other accounts are not affected")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusCreated, post("1").StatusCode)
+}
+
+func TestRateLimitTakesAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(entity.Transaction{}, nil).AnyTimes()
+	policies, err := ratelimit.ParsePolicies("default=client:100/s;POST /transactions=client:2/s,account:1/s")
+	require.NoError(t, err)
+	now := time.Now()
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Limiter: ratelimit.New(cl, policies), Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	post := func(accountID string) int {
+		resp, err := http.Post(ts.URL+"/transactions", "application/json", strings.NewReader(`{"account_id":`+accountID+`,"operation_type_id":1,"amount":1}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusCreated, post("1"))
+	assert.Equal(t, http.StatusTooManyRequests, post("1"))
+	assert.Equal(t, http.StatusCreated, post("2"), "the throttled request took no client token")
+	assert.Equal(t, http.StatusTooManyRequests, post("3"))
+}
+
+func TestRateLimitBatch(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	txSvc.EXPECT().CreateTransactions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, items []entity.BatchItem, _ entity.BatchMode) (entity.BatchResult, error) {
+		return entity.BatchResult{Created: len(items), Items: make([]entity.BatchItemResult, len(items))}, nil
+	}).AnyTimes()
+	policies, err := ratelimit.ParsePolicies("default=client:100/s;POST /transactions/batch=client:10/s,account:3/s")
+	require.NoError(t, err)
+	now := time.Now()
+	cl := mocks.NewMockClock(ctrl)
+	cl.EXPECT().Now().DoAndReturn(func() time.Time { return now }).AnyTimes()
+	cfg := &config.Config{AuthDisabled: true, BatchMaxBytes: 1 << 20, BatchMaxItems: 10}
+	srv := server.NewServer(ctx, cfg, server.Deps{Limiter: ratelimit.New(cl, policies), Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	var body []byte
+	post := func(accountIDs ...string) *http.Response {
+		items := make([]string, len(accountIDs))
+		for i, id := range accountIDs {
+			items[i] = `{"account_id":` + id + `,"operation_type_id":1,"amount":1}`
+		}
+		resp, err := http.Post(ts.URL+"/transactions/batch", "application/json", strings.NewReader("["+strings.Join(items, ",")+"]"))
+		require.NoError(t, err)
+		body, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post("1", "1", "2")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"), "each item takes a token of its account")
+
+	resp = post("1", "1", "2")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "account 1 has a token left, not two")
+	assert.Equal(t, http.StatusCreated, post("1", "2").StatusCode, "a throttled batch takes no tokens")
+	resp = post("3", "3", "3", "3")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, "more items than the account limit never pass")
+	assert.Empty(t, resp.Header.Get("Retry-After"))
+	assert.JSONEq(t, `{"message":"batch has 4 items of account 3, the limit is 3 per 1s"}`, string(body))
+}
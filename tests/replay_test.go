@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/cli"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/replay"
+	"transaction-routine/internal/server"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const capture = `{"time":"2024-05-01T10:00:00Z","method":"POST","path":"/v1/accounts","header":{"X-API-Key":"production"},"body":{"document_number":"123"},"status":201,"response":{"id":7,"document_number":"123"}}
+
+{"time":"2024-05-01T10:00:01Z","method":"GET","path":"/v1/accounts/7","status":200}
+{"time":"2024-05-01T10:00:02Z","method":"POST","path":"/v1/transactions","body":{"account_id":7,"operation_type_id":1,"amount":"10.50"},"status":201,"response":{"id":30}}
+{"time":"2024-05-01T10:00:03Z","method":"GET","path":"/v1/transactions?account_id=7","status":200}
+{"time":"2024-05-01T10:00:04Z","method":"GET","path":"/v1/accounts/8","status":200}
+`
+
+const nestedCapture = `{"time":"2024-05-01T10:00:00Z","method":"POST","path":"/v1/customers/3/accounts","body":{"currency":"BRL"},"status":201,"response":{"id":7,"customer_id":3,"currency":"BRL"}}
+{"time":"2024-05-01T10:00:01Z","method":"POST","path":"/v1/accounts/7/cards","status":201,"response":{"id":2,"token":"card_recorded","account_id":7}}
+{"time":"2024-05-01T10:00:02Z","method":"POST","path":"/v1/transactions","body":{"card_token":"card_recorded","operation_type_id":1,"amount":"10.50"},"status":201,"response":{"id":30}}
+{"time":"2024-05-01T10:00:03Z","method":"POST","path":"/v1/cards/card_recorded/block","status":200,"response":{"id":2,"token":"card_recorded","status":"blocked"}}
+`
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	txSvc := mocks.NewMockTransactionService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	expectTraffic := func() {
+		accountID := 42
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{DocumentNumber: "123"}).Return(entity.Account{ID: accountID, DocumentNumber: "123"}, nil)
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), accountID).Return(&entity.Account{ID: accountID}, nil)
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (entity.Transaction, error) {
+			assert.Equal(t, accountID, tx.AccountID)
+			tx.ID = 90
+			return tx, nil
+		})
+		txSvc.EXPECT().ListTransactions(gomock.Any(), entity.TransactionFilter{AccountID: &accountID}).Return(nil, nil)
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 8).Return(nil, nil)
+	}
+
+	t.Run("rewrites ids and reports status differences", func(t *testing.T) {
+		expectTraffic()
+		report, err := replay.New(replay.Config{URL: ts.URL}).Run(ctx, strings.NewReader(capture))
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.Total)
+		assert.Equal(t, 4, report.Matched)
+		assert.Equal(t, []replay.Result{{Line: 6, Method: "GET", Path: "/v1/accounts/8", Recorded: 200, Replayed: 404}}, report.Diffs)
+	})
+
+	t.Run("keeps the recorded timing scaled by the speed", func(t *testing.T) {
+		expectTraffic()
+		start := time.Now()
+		_, err := replay.New(replay.Config{URL: ts.URL, Speed: 40}).Run(ctx, strings.NewReader(capture))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("maps ids created under another resource", func(t *testing.T) {
+		cardSvc := mocks.NewMockCardService(ctrl)
+		srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Accounts: accSvc, Transactions: txSvc, Cards: cardSvc})
+		ts := httptest.NewServer(srv.Handler)
+		defer ts.Close()
+
+		accSvc.EXPECT().CreateAccount(gomock.Any(), entity.Account{CustomerID: 3, Currency: "BRL"}).Return(entity.Account{ID: 42, CustomerID: 3, Currency: "BRL"}, nil)
+		cardSvc.EXPECT().IssueCard(gomock.Any(), 42).Return(entity.Card{ID: 5, Token: "card_replayed", AccountID: 42}, nil)
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) (entity.Transaction, error) {
+			assert.Equal(t, "card_replayed", tx.CardToken)
+			tx.ID, tx.AccountID = 90, 42
+			return tx, nil
+		})
+		cardSvc.EXPECT().SetCardStatus(gomock.Any(), "card_replayed", entity.CardBlocked).Return(entity.Card{ID: 5, Token: "card_replayed", Status: entity.CardBlocked}, nil)
+
+		report, err := replay.New(replay.Config{URL: ts.URL}).Run(ctx, strings.NewReader(nestedCapture))
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Matched)
+		assert.Empty(t, report.Diffs)
+	})
+
+	t.Run("malformed capture", func(t *testing.T) {
+		_, err := replay.New(replay.Config{URL: ts.URL}).Run(ctx, strings.NewReader(`{"method":"GET"}`))
+		assert.ErrorContains(t, err, "line 1")
+	})
+
+	t.Run("trctl exits with mismatch", func(t *testing.T) {
+		expectTraffic()
+		file := filepath.Join(t.TempDir(), "capture.jsonl")
+		require.NoError(t, os.WriteFile(file, []byte(capture), 0o600))
+		var stdout, stderr bytes.Buffer
+		code := cli.Run(ctx, []string{"-config", filepath.Join(t.TempDir(), "config.json"), "-url", ts.URL, "traffic", "replay", file, "-speed", "0"}, &stdout, &stderr)
+		assert.Equal(t, cli.ExitMismatch, code)
+		assert.Contains(t, stdout.String(), "/v1/accounts/8")
+		assert.Contains(t, stderr.String(), "5 requests replayed, 4 matched")
+	})
+}
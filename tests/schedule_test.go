@@ -0,0 +1,328 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/scheduler"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScheduleRecurrence(t *testing.T) {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	require.NoError(t, err)
+	day := func(d int) *int { return &d }
+
+	t.Run("monthly on the 31st", func(t *testing.T) {
+		s := entity.Schedule{Recurrence: entity.RecurrenceMonthly, Day: day(31), StartAt: time.Date(2024, 1, 10, 9, 0, 0, 0, loc)}
+		s.Start(loc)
+		assert.Equal(t, time.Date(2024, 1, 31, 9, 0, 0, 0, loc), *s.NextRunAt)
+		next := s.NextRun(*s.NextRunAt)
+		assert.Equal(t, time.Date(2024, 2, 29, 9, 0, 0, 0, loc), *next, "shorter months run on their last day")
+		next = s.NextRun(*next)
+		assert.Equal(t, time.Date(2024, 3, 31, 9, 0, 0, 0, loc), *next)
+	})
+
+	t.Run("weekly from the start weekday", func(t *testing.T) {
+		// 2024-01-03 is a Wednesday.
+		s := entity.Schedule{Recurrence: entity.RecurrenceWeekly, StartAt: time.Date(2024, 1, 3, 8, 30, 0, 0, loc)}
+		s.Start(loc)
+		assert.Equal(t, 3, *s.Day)
+		assert.Equal(t, s.StartAt, *s.NextRunAt)
+		assert.Equal(t, time.Date(2024, 1, 10, 8, 30, 0, 0, loc), *s.NextRun(*s.NextRunAt))
+	})
+
+	t.Run("ends", func(t *testing.T) {
+		end := time.Date(2024, 1, 20, 0, 0, 0, 0, loc)
+		s := entity.Schedule{Recurrence: entity.RecurrenceWeekly, Day: day(1), StartAt: time.Date(2024, 1, 1, 8, 0, 0, 0, loc), EndAt: &end}
+		s.Start(loc)
+		next := s.NextRun(*s.NextRun(*s.NextRunAt))
+		assert.Equal(t, time.Date(2024, 1, 15, 8, 0, 0, 0, loc), *next)
+		assert.Nil(t, s.NextRun(*next))
+
+		late := entity.Schedule{Recurrence: entity.RecurrenceMonthly, Day: day(25), StartAt: time.Date(2024, 1, 1, 8, 0, 0, 0, loc), EndAt: &end}
+		late.Start(loc)
+		assert.Equal(t, entity.ScheduleCompleted, late.Status, "the first run falls after the end")
+		assert.Nil(t, late.NextRunAt)
+
+		once := entity.Schedule{Recurrence: entity.RecurrenceOnce, StartAt: end}
+		once.Start(loc)
+		assert.Equal(t, end, *once.NextRunAt)
+		assert.Nil(t, once.NextRun(end))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+		past := now.Add(-time.Hour)
+		err := entity.Schedule{Recurrence: "daily", StartAt: past, EndAt: &past}.Validate(now)
+		fields := map[string]bool{}
+		for _, fe := range entity.FieldErrors(err) {
+			fields[fe.Field] = true
+		}
+		assert.Equal(t, map[string]bool{"recurrence": true, "start_at": true, "end_at": true}, fields)
+		assert.Error(t, entity.Schedule{Recurrence: entity.RecurrenceWeekly, Day: day(7), StartAt: now.Add(time.Hour)}.Validate(now))
+		assert.NoError(t, entity.Schedule{Recurrence: entity.RecurrenceMonthly, Day: day(31), StartAt: now.Add(time.Hour)}.Validate(now))
+	})
+}
+
+func TestScheduleWorker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	cfg := scheduler.Config{BatchSize: 10, RunTimeout: time.Minute}
+	sch := entity.Schedule{ID: 3, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(150)}
+
+	t.Run("records each outcome", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		txSvc := mocks.NewMockTransactionService(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+
+		runs := []entity.ScheduleRun{
+			{ID: 1, ScheduleID: 3, DueAt: now, Status: entity.RunRunning, Schedule: sch},
+			{ID: 2, ScheduleID: 3, DueAt: now.Add(time.Hour), Status: entity.RunRunning, Schedule: sch},
+		}
+		repo.EXPECT().ReclaimScheduleRuns(gomock.Any(), now.Add(-2*time.Minute), now, 10).Return(nil, nil)
+		repo.EXPECT().ClaimScheduleRuns(gomock.Any(), now, 10).Return(runs, nil)
+		repo.EXPECT().StartScheduleRun(gomock.Any(), gomock.Any(), now).Return(nil).Times(2)
+		gomock.InOrder(
+			txSvc.EXPECT().CreateTransaction(gomock.Any(), runs[0].Transaction()).Return(entity.Transaction{ID: 42}, nil),
+			txSvc.EXPECT().CreateTransaction(gomock.Any(), runs[1].Transaction()).Return(entity.Transaction{}, &entity.FieldError{Field: "account_id", Err: entity.ErrAccountNotFound}),
+		)
+		var finished []entity.ScheduleRun
+		repo.EXPECT().FinishScheduleRun(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, run entity.ScheduleRun) error {
+			finished = append(finished, run)
+			return nil
+		})
+
+		n, err := scheduler.NewWorker(repo, txSvc, cl, cfg).RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		require.Len(t, finished, 1)
+		assert.Equal(t, int64(2), finished[0].ID)
+		assert.Equal(t, entity.RunFailed, finished[0].Status)
+		assert.Nil(t, finished[0].TransactionID)
+		assert.Equal(t, "account_id: account not found", finished[0].Error)
+	})
+
+	t.Run("resumes interrupted runs first", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		txSvc := mocks.NewMockTransactionService(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+
+		stale := entity.ScheduleRun{ID: 7, ScheduleID: 3, DueAt: now.Add(-time.Hour), Status: entity.RunRunning, Schedule: sch}
+		repo.EXPECT().ReclaimScheduleRuns(gomock.Any(), now.Add(-2*time.Minute), now, 10).Return([]entity.ScheduleRun{stale}, nil)
+		repo.EXPECT().ClaimScheduleRuns(gomock.Any(), now, 9).Return(nil, nil)
+		repo.EXPECT().StartScheduleRun(gomock.Any(), int64(7), now).Return(nil)
+		// The instance that stopped had posted it after all.
+		txSvc.EXPECT().CreateTransaction(gomock.Any(), stale.Transaction()).Return(entity.Transaction{}, entity.ErrRunNotRunning)
+
+		n, err := scheduler.NewWorker(repo, txSvc, cl, cfg).RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("skips runs finished meanwhile", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+
+		repo.EXPECT().ReclaimScheduleRuns(gomock.Any(), gomock.Any(), now, 10).Return(nil, nil)
+		repo.EXPECT().ClaimScheduleRuns(gomock.Any(), now, 10).Return([]entity.ScheduleRun{{ID: 1, Schedule: sch}}, nil)
+		repo.EXPECT().StartScheduleRun(gomock.Any(), int64(1), now).Return(entity.ErrRunNotRunning)
+
+		n, err := scheduler.NewWorker(repo, nil, cl, cfg).RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("leaves claimed runs on shutdown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		repo.EXPECT().ReclaimScheduleRuns(gomock.Any(), gomock.Any(), now, 10).Return(nil, nil)
+		repo.EXPECT().ClaimScheduleRuns(gomock.Any(), now, 10).Return([]entity.ScheduleRun{{ID: 1, Schedule: sch}, {ID: 2, Schedule: sch}}, nil)
+
+		_, err := scheduler.NewWorker(repo, nil, cl, cfg).RunOnce(canceled)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now)
+		repo.EXPECT().ReclaimScheduleRuns(gomock.Any(), gomock.Any(), now, 10).Return(nil, nil)
+		repo.EXPECT().ClaimScheduleRuns(gomock.Any(), now, 10).Return(nil, errors.New("db down"))
+		_, err := scheduler.NewWorker(repo, nil, cl, cfg).RunOnce(ctx)
+		assert.Error(t, err)
+	})
+}
+
+func TestScheduleService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	opTypes := entity.OperationType{
+		1: &entity.Operation{Description: "COMPRA A VISTA"},
+		4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true},
+	}
+
+	t.Run("create fixes the sign and computes the first run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now)
+		cl.EXPECT().Location().Return(time.UTC)
+		repo.EXPECT().FindAccountIDs(gomock.Any(), []int{1}).Return([]int{1}, nil)
+		repo.EXPECT().CreateSchedule(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s entity.Schedule) (int, error) {
+			assert.True(t, s.Amount.Equal(decimal.NewFromInt(-50)))
+			assert.Equal(t, entity.ScheduleActive, s.Status)
+			assert.Equal(t, time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC), *s.NextRunAt)
+			return 8, nil
+		})
+
+		sch, err := service.NewScheduleService(cl, repo, opTypes).CreateSchedule(ctx, entity.Schedule{
+			AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(50),
+			Recurrence: entity.RecurrenceMonthly, StartAt: time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 8, sch.ID)
+		assert.Equal(t, 5, *sch.Day)
+	})
+
+	t.Run("create reports every invalid field", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now)
+		_, err := service.NewScheduleService(cl, mocks.NewMockRepository(ctrl), opTypes).CreateSchedule(ctx, entity.Schedule{
+			AccountID: 1, OperationTypeID: 9, Amount: decimal.NewFromInt(50), Recurrence: entity.RecurrenceOnce, StartAt: now,
+		})
+		fields := map[string]bool{}
+		for _, fe := range entity.FieldErrors(err) {
+			fields[fe.Field] = true
+		}
+		assert.Equal(t, map[string]bool{"operation_type_id": true, "start_at": true}, fields)
+	})
+
+	t.Run("create with unknown account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now)
+		repo.EXPECT().FindAccountIDs(gomock.Any(), []int{2}).Return(nil, nil)
+		_, err := service.NewScheduleService(cl, repo, opTypes).CreateSchedule(ctx, entity.Schedule{
+			AccountID: 2, OperationTypeID: 4, Amount: decimal.NewFromInt(50), Recurrence: entity.RecurrenceOnce, StartAt: now.Add(time.Hour),
+		})
+		assert.ErrorIs(t, err, entity.ErrAccountNotFound)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Location().Return(time.UTC).AnyTimes()
+		id := 8
+		repo.EXPECT().FindSchedules(gomock.Any(), entity.ScheduleFilter{ID: &id}).Return([]entity.Schedule{{ID: 8}}, nil)
+		repo.EXPECT().CancelSchedule(gomock.Any(), 8).Return(entity.ErrScheduleNotActive)
+		err := service.NewScheduleService(cl, repo, opTypes).CancelSchedule(ctx, 8)
+		assert.ErrorIs(t, err, entity.ErrScheduleNotActive)
+
+		missing := 9
+		repo.EXPECT().FindSchedules(gomock.Any(), entity.ScheduleFilter{ID: &missing}).Return(nil, nil)
+		err = service.NewScheduleService(cl, repo, opTypes).CancelSchedule(ctx, 9)
+		assert.ErrorIs(t, err, entity.ErrScheduleNotFound)
+	})
+}
+
+func TestScheduleHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	schedSvc := mocks.NewMockScheduleService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Schedules: schedSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+		day := 31
+		schedSvc.EXPECT().CreateSchedule(gomock.Any(), entity.Schedule{
+			AccountID: 1, OperationTypeID: 4, Amount: decimal.RequireFromString("150"),
+			Recurrence: entity.RecurrenceMonthly, Day: &day, StartAt: start,
+		}).Return(entity.Schedule{ID: 5, Status: entity.ScheduleActive}, nil)
+		resp, body := do(http.MethodPost, "/v1/schedules", `{"account_id":1,"operation_type_id":4,"amount":"150","recurrence":"monthly","day":31,"start_at":"2024-01-31T09:00:00Z"}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/schedules/5", resp.Header.Get("Location"))
+		assert.Contains(t, body, `"status":"active"`)
+	})
+
+	t.Run("unknown recurrence", func(t *testing.T) {
+		resp, body := do(http.MethodPost, "/v1/schedules", `{"account_id":1,"operation_type_id":4,"amount":"150","recurrence":"daily","start_at":"2024-01-31T09:00:00Z"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "recurrence")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		id := 1
+		schedSvc.EXPECT().ListSchedules(gomock.Any(), entity.ScheduleFilter{AccountID: &id}).Return(nil, nil)
+		resp, body := do(http.MethodGet, "/v1/schedules?account_id=1", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"schedules":[]}`, body)
+	})
+
+	t.Run("runs", func(t *testing.T) {
+		txID := 42
+		schedSvc.EXPECT().ListRuns(gomock.Any(), 5).Return([]entity.ScheduleRun{{ID: 1, ScheduleID: 5, Status: entity.RunSucceeded, TransactionID: &txID}}, nil)
+		resp, body := do(http.MethodGet, "/v1/schedules/5/runs", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"transaction_id":42`)
+
+		schedSvc.EXPECT().ListRuns(gomock.Any(), 6).Return(nil, entity.ErrScheduleNotFound)
+		resp, _ = do(http.MethodGet, "/v1/schedules/6/runs", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		schedSvc.EXPECT().CancelSchedule(gomock.Any(), 5).Return(nil)
+		resp, _ := do(http.MethodDelete, "/v1/schedules/5", "")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		schedSvc.EXPECT().CancelSchedule(gomock.Any(), 5).Return(entity.ErrScheduleNotActive)
+		resp, _ = do(http.MethodDelete, "/v1/schedules/5", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
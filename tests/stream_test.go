@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/stream"
+	"transaction-routine/tests/mocks"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStreamHub(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	moved := entity.NewTransactionUpdated(entity.Transaction{ID: 1, AccountID: 2}, entity.Transaction{ID: 1, AccountID: 1})
+	moved.ID = 13
+
+	newHub := func(t *testing.T) (*stream.Hub, *mocks.MockRepository, *mocks.MockClock) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		hub := stream.NewHub(repo, cl, time.Second, 5*time.Second)
+		repo.EXPECT().LastEventID(gomock.Any()).Return(int64(10), nil)
+		_, err := hub.Poll(ctx)
+		require.NoError(t, err)
+		return hub, repo, cl
+	}
+	next := func(t *testing.T, sub *stream.Subscription) (entity.Event, error) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return sub.Next(ctx)
+	}
+
+	t.Run("routes events by account", func(t *testing.T) {
+		hub, repo, _ := newHub(t)
+		sub, err := hub.Subscribe(1, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+		repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 10, Limit: 500}).Return([]entity.Event{
+			{ID: 11, AccountID: 1}, {ID: 12, AccountID: 2}, moved,
+		}, nil)
+		n, err := hub.Poll(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		e, err := next(t, sub)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), e.ID)
+		e, err = next(t, sub)
+		require.NoError(t, err)
+		assert.Equal(t, int64(13), e.ID, "the account a transaction moved away from is told")
+		_, err = next(t, sub)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("waits for ids committed late", func(t *testing.T) {
+		hub, repo, cl := newHub(t)
+		sub, err := hub.Subscribe(1, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+		gomock.InOrder(
+			repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 10, Limit: 500}).Return([]entity.Event{{ID: 11, AccountID: 1}, {ID: 13, AccountID: 1}}, nil),
+			repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 11, Limit: 500}).Return([]entity.Event{{ID: 12, AccountID: 1}, {ID: 13, AccountID: 1}}, nil),
+			repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 13, Limit: 500}).Return([]entity.Event{{ID: 15, AccountID: 1}}, nil),
+			repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 13, Limit: 500}).Return([]entity.Event{{ID: 15, AccountID: 1}}, nil),
+		)
+		cl.EXPECT().Now().Return(now).Times(2)
+		n, _ := hub.Poll(ctx)
+		assert.Equal(t, 1, n)
+		n, _ = hub.Poll(ctx)
+		assert.Equal(t, 2, n)
+		n, _ = hub.Poll(ctx)
+		assert.Equal(t, 0, n)
+		// 14 was rolled back: it is given up on after the gap wait.
+		cl.EXPECT().Now().Return(now.Add(6 * time.Second))
+		n, _ = hub.Poll(ctx)
+		assert.Equal(t, 1, n)
+
+		for _, id := range []int64{11, 12, 13, 15} {
+			e, err := next(t, sub)
+			require.NoError(t, err)
+			assert.Equal(t, id, e.ID)
+		}
+	})
+
+	t.Run("resumes from the outbox", func(t *testing.T) {
+		hub, repo, _ := newHub(t)
+		sub, err := hub.Subscribe(1, 5)
+		require.NoError(t, err)
+		defer sub.Close()
+		repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AccountID: 1, AfterID: 5, UntilID: 10, Limit: 100}).
+			Return([]entity.Event{{ID: 6, AccountID: 1}, {ID: 9, AccountID: 1}}, nil)
+		repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AfterID: 10, Limit: 500}).Return([]entity.Event{{ID: 11, AccountID: 1}}, nil)
+		_, err = hub.Poll(ctx)
+		require.NoError(t, err)
+
+		for _, id := range []int64{6, 9, 11} {
+			e, err := next(t, sub)
+			require.NoError(t, err)
+			assert.Equal(t, id, e.ID)
+		}
+	})
+
+	t.Run("close ends subscriptions", func(t *testing.T) {
+		hub, _, _ := newHub(t)
+		sub, err := hub.Subscribe(1, 0)
+		require.NoError(t, err)
+		hub.Close()
+		_, err = next(t, sub)
+		assert.ErrorIs(t, err, stream.ErrClosed)
+		_, err = hub.Subscribe(1, 0)
+		assert.ErrorIs(t, err, stream.ErrClosed)
+	})
+
+	t.Run("not started", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		hub := stream.NewHub(mocks.NewMockRepository(ctrl), nil, time.Second, time.Second)
+		_, err := hub.Subscribe(1, 0)
+		assert.ErrorIs(t, err, stream.ErrNotReady)
+	})
+}
+
+func TestAccountEventsStream(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	accSvc := mocks.NewMockAccountService(ctrl)
+	hub := stream.NewHub(repo, nil, time.Second, time.Second)
+	repo.EXPECT().LastEventID(gomock.Any()).Return(int64(10), nil)
+	_, err := hub.Poll(ctx)
+	require.NoError(t, err)
+
+	cfg := &config.Config{AuthDisabled: true, StreamHeartbeat: 20 * time.Millisecond}
+	srv := server.NewServer(ctx, cfg, server.Deps{Accounts: accSvc, Hub: hub})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(ln) }()
+	url := "http://" + ln.Addr().String()
+	// A spare keep-alive connection would hold Shutdown up on its own.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	t.Run("unknown account", func(t *testing.T) {
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 2).Return(nil, nil)
+		resp, err := client.Get(url + "/v1/accounts/2/events")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		resp, err := client.Get(url + "/v1/accounts/1/events?last_event_id=x")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("resume, heartbeat and shutdown", func(t *testing.T) {
+		created := entity.NewTransactionCreated(entity.Transaction{ID: 3, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(25)})
+		created.ID = 7
+		accSvc.EXPECT().GetAccountByID(gomock.Any(), 1).Return(&entity.Account{ID: 1}, nil)
+		repo.EXPECT().FindEvents(gomock.Any(), entity.EventFilter{AccountID: 1, AfterID: 6, UntilID: 10, Limit: 100}).Return([]entity.Event{created}, nil)
+		live := entity.NewTransactionCreated(entity.Transaction{ID: 4, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(5)})
+		live.ID = 11
+		accSvc.EXPECT().GetAccountBalance(gomock.Any(), 1).Return(decimal.NewFromInt(30), nil)
+
+		req, err := http.NewRequest(http.MethodGet, url+"/v1/accounts/1/events", nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "6")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		lines := make(chan string)
+		go func() {
+			defer close(lines)
+			sc := bufio.NewScanner(resp.Body)
+			for sc.Scan() {
+				lines <- sc.Text()
+			}
+		}()
+		readUntil := func(prefix string) string {
+			for line := range lines {
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			}
+			t.Fatalf("stream ended before %q", prefix)
+			return ""
+		}
+
+		assert.Equal(t, "retry: 3000", readUntil("retry:"))
+		assert.Equal(t, "id: 7", readUntil("id:"))
+		assert.Equal(t, "event: TransactionCreated", readUntil("event:"))
+		var e entity.Event
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(readUntil("data:"), "data: ")), &e))
+		assert.Equal(t, int64(7), e.ID)
+		nextLine := func() string {
+			for line := range lines {
+				if line != "" {
+					return line
+				}
+			}
+			return ""
+		}
+		assert.Equal(t, ": heartbeat", nextLine(), "replayed events get no balance")
+		assert.Equal(t, ": heartbeat", readUntil(":"))
+
+		repo.EXPECT().FindEvents(gomock.Any(), gomock.Any()).Return([]entity.Event{live}, nil)
+		_, err = hub.Poll(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "id: 11", readUntil("id:"))
+		assert.Equal(t, "event: TransactionCreated", readUntil("event:"))
+		readUntil("data:")
+		assert.Equal(t, "event: balance", nextLine())
+		assert.Equal(t, `data: {"account_id":1,"balance":"30"}`, nextLine())
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		require.NoError(t, srv.Shutdown(shutdownCtx), "open streams must not hold up shutdown")
+		for range lines {
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/database"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	cl := mocks.NewMockClock(ctrl)
+	opTypes := entity.OperationType{1: &entity.Operation{Description: "COMPRA A VISTA"}}
+	txSvc := service.NewTransactionService(cl, database.NewInstrumented(repo), opTypes, entity.AmountLimits{}, nil)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Transactions: txSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	cl.EXPECT().Now().Return(time.Now())
+	repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+	repo.EXPECT().CreateTransaction(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/transactions", strings.NewReader(`{"account_id":1,"operation_type_id":1,"amount":10}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	names := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = span
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String(), span.Name())
+	}
+	for _, name := range []string{
+		"POST /transactions",
+		"TransactionService.CreateTransaction",
+		"Transaction.Validate",
+		"Repository.CreateTransaction",
+	} {
+		assert.Contains(t, names, name)
+	}
+	assert.Equal(t,
+		names["TransactionService.CreateTransaction"].SpanContext().SpanID(),
+		names["Repository.CreateTransaction"].Parent().SpanID(),
+	)
+}
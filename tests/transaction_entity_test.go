@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"transaction-routine/internal/entity"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionMarshalJSON(t *testing.T) {
+	t.Run("utc keeps numeric offset", func(t *testing.T) {
+		tx := entity.Transaction{ID: 1, AccountID: 1, OperationTypeID: 4, Amount: decimal.NewFromInt(10), EventDate: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+		b, err := json.Marshal(tx)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"account_id":1,"operation_type_id":4,"amount":"10","event_date":"2024-01-02T03:04:05+00:00"}`, string(b))
+	})
+
+	t.Run("round trip preserves instant", func(t *testing.T) {
+		loc := time.FixedZone("BRT", -3*60*60)
+		tx := entity.Transaction{EventDate: time.Date(2024, 1, 2, 3, 4, 5, 123456000, loc)}
+		b, err := json.Marshal(tx)
+		assert.NoError(t, err)
+		var got entity.Transaction
+		assert.NoError(t, json.Unmarshal(b, &got))
+		assert.True(t, tx.EventDate.Equal(got.EventDate))
+		_, offset := got.EventDate.Zone()
+		assert.Equal(t, -3*60*60, offset)
+	})
+
+	t.Run("amounts are strings", func(t *testing.T) {
+		tx := entity.Transaction{Amount: decimal.RequireFromString("-999999999999999.9999")}
+		b, err := json.Marshal(tx)
+		assert.NoError(t, err)
+		assert.Contains(t, string(b), `"amount":"-999999999999999.9999"`)
+	})
+}
+
+func TestTransactionValidate(t *testing.T) {
+	opTypes := entity.OperationType{1: {Description: "COMPRA A VISTA"}}
+
+	t.Run("reports every invalid field", func(t *testing.T) {
+		tx := entity.Transaction{OperationTypeID: 9}
+		err := tx.Validate(opTypes)
+		assert.ErrorIs(t, err, entity.ErrInvalidAccountID)
+		assert.ErrorIs(t, err, entity.ErrInvalidAmount)
+		assert.ErrorIs(t, err, entity.ErrInvalidEventDate)
+		assert.ErrorIs(t, err, entity.ErrInvalidOperationTypeID)
+
+		var fields []string
+		for _, fe := range entity.FieldErrors(err) {
+			fields = append(fields, fe.Field)
+		}
+		assert.Equal(t, []string{"account_id", "amount", "event_date", "operation_type_id"}, fields)
+	})
+
+	t.Run("amount out of the column bounds", func(t *testing.T) {
+		for amount, want := range map[string]error{
+			"0.0000001": entity.ErrAmountPrecision,
+			"1e30":      entity.ErrAmountOutOfRange,
+			"-1e15":     entity.ErrAmountOutOfRange,
+		} {
+			tx := entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.RequireFromString(amount), EventDate: time.Now()}
+			err := tx.Validate(opTypes)
+			assert.ErrorIs(t, err, want, amount)
+			assert.Len(t, entity.FieldErrors(err), 1)
+		}
+
+		tx := entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.RequireFromString("999999999999999.99990"), EventDate: time.Now()}
+		assert.NoError(t, tx.Validate(opTypes))
+	})
+
+	t.Run("system operation types", func(t *testing.T) {
+		opTypes := entity.OperationType{
+			5: {Description: entity.AdjustmentCreditDescription, PositiveAmount: true},
+			6: {Description: entity.AdjustmentDebitDescription},
+			8: {Description: entity.DisputeCreditDescription, PositiveAmount: true},
+			9: {Description: entity.DisputeReversalDescription},
+		}
+		for id := range opTypes {
+			tx := entity.Transaction{AccountID: 1, OperationTypeID: id, Amount: decimal.NewFromInt(10), EventDate: time.Now()}
+			err := tx.Validate(opTypes)
+			assert.ErrorIs(t, err, entity.ErrSystemOperationType)
+			assert.Equal(t, "operation_type_id", entity.FieldErrors(err)[0].Field)
+		}
+	})
+
+	t.Run("valid transaction", func(t *testing.T) {
+		tx := entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(10), EventDate: time.Now()}
+		assert.NoError(t, tx.Validate(opTypes))
+		assert.Nil(t, entity.FieldErrors(nil))
+	})
+}
+
+func TestDatesMarshalJSON(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("dispute", func(t *testing.T) {
+		b, err := json.Marshal(entity.Dispute{ID: 1, Amount: decimal.NewFromInt(10), CreditDueAt: at, ResolveBy: at, OpenedAt: at})
+		assert.NoError(t, err)
+		var got map[string]any
+		assert.NoError(t, json.Unmarshal(b, &got))
+		for _, field := range []string{"credit_due_at", "resolve_by", "opened_at"} {
+			assert.Equal(t, "2024-01-02T03:04:05+00:00", got[field], field)
+		}
+		assert.NotContains(t, got, "resolved_at")
+	})
+
+	t.Run("schedule run", func(t *testing.T) {
+		b, err := json.Marshal(entity.ScheduleRun{ID: 1, DueAt: at, StartedAt: at, FinishedAt: &at})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"schedule_id":0,"due_at":"2024-01-02T03:04:05+00:00","status":"","started_at":"2024-01-02T03:04:05+00:00","finished_at":"2024-01-02T03:04:05+00:00"}`, string(b))
+	})
+}
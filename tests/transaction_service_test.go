@@ -36,8 +36,15 @@ func (s *transactionSvcTestSuite) SetupTest() {
 	s.opTypes = entity.OperationType{
 		1: &entity.Operation{Description: "COMPRA A VISTA", PositiveAmount: false},
 		2: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true},
+		3: &entity.Operation{Description: entity.AdjustmentCreditDescription, PositiveAmount: true},
 	}
-	s.txSvc = service.NewTransactionService(s.cl, s.repo, s.opTypes)
+	s.txSvc = service.NewTransactionService(s.cl, s.repo, s.opTypes, entity.AmountLimits{}, nil)
+}
+
+// inBRL returns tx as converted for a BRL account, when entered in BRL.
+func inBRL(tx entity.Transaction) entity.Transaction {
+	tx.OriginalAmount, tx.OriginalCurrency, tx.FXRate = tx.Amount, "BRL", decimal.NewFromInt(1)
+	return tx
 }
 
 func (s *transactionSvcTestSuite) TestCreateTransaction() {
@@ -45,23 +52,26 @@ func (s *transactionSvcTestSuite) TestCreateTransaction() {
 	s.T().Run("success", func(t *testing.T) {
 		tx := entity.Transaction{AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(100), EventDate: now}
 		s.cl.EXPECT().Now().Return(now)
-		s.repo.EXPECT().CreateTransaction(gomock.Any(), tx).Return(nil)
-		err := s.txSvc.CreateTransaction(s.ctx, tx)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().CreateTransaction(gomock.Any(), inBRL(tx)).Return(3, nil)
+		created, err := s.txSvc.CreateTransaction(s.ctx, tx)
 		s.NoError(err)
+		s.Equal(3, created.ID)
 	})
 
 	s.T().Run("invalid transaction", func(t *testing.T) {
 		tx := entity.Transaction{AccountID: 1, OperationTypeID: 5, Amount: decimal.NewFromInt(100), EventDate: now}
 		s.cl.EXPECT().Now().Return(tx.EventDate)
-		err := s.txSvc.CreateTransaction(s.ctx, tx)
+		_, err := s.txSvc.CreateTransaction(s.ctx, tx)
 		s.Error(err)
 	})
 
 	s.T().Run("repo error", func(t *testing.T) {
 		tx := entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(-100), EventDate: now}
 		s.cl.EXPECT().Now().Return(tx.EventDate)
-		s.repo.EXPECT().CreateTransaction(gomock.Any(), tx).Return(errors.New("error"))
-		err := s.txSvc.CreateTransaction(s.ctx, tx)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().CreateTransaction(gomock.Any(), inBRL(tx)).Return(0, errors.New("error"))
+		_, err := s.txSvc.CreateTransaction(s.ctx, tx)
 		s.Error(err)
 	})
 }
@@ -71,7 +81,8 @@ func (s *transactionSvcTestSuite) TestUpdateTransaction() {
 	s.T().Run("success", func(t *testing.T) {
 		tx := entity.Transaction{ID: 1, AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(100), EventDate: now}
 		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &tx.ID}).Return([]entity.Transaction{tx}, nil)
-		s.repo.EXPECT().UpdateTransaction(gomock.Any(), tx).Return(nil)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().UpdateTransaction(gomock.Any(), inBRL(tx)).Return(nil)
 		err := s.txSvc.UpdateTransaction(s.ctx, tx)
 		s.NoError(err)
 	})
@@ -92,8 +103,196 @@ func (s *transactionSvcTestSuite) TestUpdateTransaction() {
 	s.T().Run("repo error", func(t *testing.T) {
 		tx := entity.Transaction{ID: 1, AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(100), EventDate: now}
 		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &tx.ID}).Return([]entity.Transaction{tx}, nil)
-		s.repo.EXPECT().UpdateTransaction(gomock.Any(), tx).Return(errors.New("error"))
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().UpdateTransaction(gomock.Any(), inBRL(tx)).Return(errors.New("error"))
 		err := s.txSvc.UpdateTransaction(s.ctx, tx)
 		s.Error(err)
 	})
 }
+
+func (s *transactionSvcTestSuite) TestPatchTransaction() {
+	eventDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	current := inBRL(entity.Transaction{ID: 1, AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(100), EventDate: eventDate})
+
+	s.T().Run("validates merged result", func(t *testing.T) {
+		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &current.ID}).Return([]entity.Transaction{current}, nil)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().UpdateTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tx entity.Transaction) error {
+			s.Equal(1, tx.ID)
+			s.Equal(1, tx.AccountID)
+			s.Equal(1, tx.OperationTypeID)
+			s.True(decimal.NewFromInt(-100).Equal(tx.Amount))
+			s.True(eventDate.Equal(tx.EventDate))
+			return nil
+		})
+		err := s.txSvc.PatchTransaction(s.ctx, current.ID, []byte(`{"operation_type_id":1}`))
+		s.NoError(err)
+	})
+
+	s.T().Run("null clears field", func(t *testing.T) {
+		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &current.ID}).Return([]entity.Transaction{current}, nil)
+		err := s.txSvc.PatchTransaction(s.ctx, current.ID, []byte(`{"amount":null}`))
+		s.ErrorIs(err, entity.ErrInvalidAmount)
+	})
+
+	s.T().Run("not found", func(t *testing.T) {
+		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &current.ID}).Return(nil, nil)
+		err := s.txSvc.PatchTransaction(s.ctx, current.ID, []byte(`{"amount":10}`))
+		s.ErrorIs(err, entity.ErrTransactionNotFound)
+	})
+
+	s.T().Run("system operation type", func(t *testing.T) {
+		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &current.ID}).Return([]entity.Transaction{current}, nil)
+		err := s.txSvc.PatchTransaction(s.ctx, current.ID, []byte(`{"operation_type_id":3}`))
+		s.ErrorIs(err, entity.ErrSystemOperationType)
+	})
+
+	s.T().Run("invalid patch", func(t *testing.T) {
+		s.repo.EXPECT().FindTransactions(gomock.Any(), entity.TransactionFilter{ID: &current.ID}).Return([]entity.Transaction{current}, nil)
+		err := s.txSvc.PatchTransaction(s.ctx, current.ID, []byte(`{"amount":"abc"}`))
+		s.ErrorIs(err, entity.ErrInvalidPatch)
+	})
+}
+
+func (s *transactionSvcTestSuite) TestCreateTransactions() {
+	now := time.Now()
+	items := func() []entity.BatchItem {
+		return []entity.BatchItem{
+			{Index: 0, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(10)}},
+			{Index: 1, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 9, Amount: decimal.NewFromInt(10)}},
+			{Index: 2, Transaction: entity.Transaction{AccountID: 7, OperationTypeID: 2, Amount: decimal.NewFromInt(10)}},
+			{Index: 3, Err: errors.New("malformed JSON")},
+			{Index: 4, Transaction: entity.Transaction{AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(5)}},
+		}
+	}
+
+	s.T().Run("best effort creates valid items", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), gomock.InAnyOrder([]int{1, 7})).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().CreateTransactions(gomock.Any(), []entity.Transaction{
+			inBRL(entity.Transaction{AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(-10), EventDate: now}),
+			inBRL(entity.Transaction{AccountID: 1, OperationTypeID: 2, Amount: decimal.NewFromInt(5), EventDate: now}),
+		}).Return([]int{100, 101}, nil)
+
+		result, err := s.txSvc.CreateTransactions(s.ctx, items(), entity.BatchBestEffort)
+		s.NoError(err)
+		s.Equal(2, result.Created)
+		s.Equal(3, result.Failed)
+		s.Equal(entity.BatchItemResult{Index: 0, Status: entity.BatchItemCreated, ID: 100}, result.Items[0])
+		s.Equal(entity.BatchItemResult{Index: 1, Status: entity.BatchItemFailed, Error: "operation_type_id: invalid operation type id"}, result.Items[1])
+		s.Equal(entity.BatchItemResult{Index: 2, Status: entity.BatchItemFailed, Error: "account_id: account not found"}, result.Items[2])
+		s.Equal(entity.BatchItemResult{Index: 3, Status: entity.BatchItemFailed, Error: "malformed JSON"}, result.Items[3])
+		s.Equal(entity.BatchItemResult{Index: 4, Status: entity.BatchItemCreated, ID: 101}, result.Items[4])
+	})
+
+	s.T().Run("all or nothing rejects every item", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), gomock.Any()).Return(map[int]entity.Currency{1: "BRL"}, nil)
+
+		result, err := s.txSvc.CreateTransactions(s.ctx, items(), entity.BatchAllOrNothing)
+		s.NoError(err)
+		s.Equal(0, result.Created)
+		s.Equal(5, result.Failed)
+		s.Equal(entity.BatchItemRejected, result.Items[0].Status)
+		s.Equal(entity.BatchItemFailed, result.Items[1].Status)
+		s.Equal(entity.BatchItemRejected, result.Items[4].Status)
+	})
+
+	s.T().Run("repo error", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindAccountCurrencies(gomock.Any(), []int{1}).Return(map[int]entity.Currency{1: "BRL"}, nil)
+		s.repo.EXPECT().CreateTransactions(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+
+		_, err := s.txSvc.CreateTransactions(s.ctx, items()[:1], entity.BatchAllOrNothing)
+		s.Error(err)
+	})
+}
+
+func (s *transactionSvcTestSuite) TestReconcileBalances() {
+	now := time.Now()
+	closingAsOf := now.Add(-24 * time.Hour)
+	opTypes := entity.OperationType{
+		1: &entity.Operation{Description: "COMPRA A VISTA"},
+		4: &entity.Operation{Description: "PAGAMENTO", PositiveAmount: true},
+		5: &entity.Operation{Description: entity.AdjustmentCreditDescription, PositiveAmount: true},
+		6: &entity.Operation{Description: entity.AdjustmentDebitDescription},
+	}
+	txSvc := service.NewTransactionService(s.cl, s.repo, opTypes, entity.AmountLimits{}, nil)
+	violations := []entity.SignViolation{
+		{TransactionID: 10, AccountID: 1, OperationTypeID: 1, Amount: decimal.NewFromInt(30)},
+		{TransactionID: 11, AccountID: 2, OperationTypeID: 4, Amount: decimal.NewFromInt(-5)},
+	}
+	balances := []entity.AccountBalance{
+		{AccountID: 1, Balance: decimal.NewFromInt(-20)},
+		{AccountID: 2, Balance: decimal.NewFromInt(15)},
+	}
+
+	s.T().Run("reports drifts and sign violations", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindSignViolations(gomock.Any()).Return(violations, nil)
+		s.repo.EXPECT().AccountBalances(gomock.Any(), nil).Return(balances, nil)
+		s.repo.EXPECT().AccountBalances(gomock.Any(), &closingAsOf).Return([]entity.AccountBalance{
+			{AccountID: 1, Balance: decimal.NewFromInt(10)},
+			{AccountID: 2, Balance: decimal.NewFromInt(7)},
+		}, nil)
+
+		report, err := txSvc.ReconcileBalances(s.ctx, entity.ReconciliationRequest{Closing: &entity.ClosingBalances{
+			AsOf: closingAsOf,
+			Balances: []entity.AccountBalance{
+				{AccountID: 1, Balance: decimal.NewFromInt(10)},
+				{AccountID: 2, Balance: decimal.NewFromInt(20)},
+				{AccountID: 3, Balance: decimal.NewFromInt(1)},
+			},
+		}})
+		s.NoError(err)
+		s.False(report.Clean())
+		s.Equal(balances, report.Balances)
+		s.Equal(violations, report.SignViolations)
+		s.Empty(report.Adjustments)
+		s.Equal([]entity.BalanceDrift{
+			{AccountID: 2, Expected: decimal.NewFromInt(20), Actual: decimal.NewFromInt(7)},
+			{AccountID: 3, Expected: decimal.NewFromInt(1), Actual: decimal.Zero},
+		}, report.Drifts)
+	})
+
+	s.T().Run("applies adjustments", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindSignViolations(gomock.Any()).Return(violations, nil)
+		s.repo.EXPECT().CreateAdjustments(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, adjs []entity.Adjustment) ([]int, error) {
+			s.Len(adjs, 2)
+			s.Equal(10, adjs[0].AdjustedTransactionID)
+			s.Equal(6, adjs[0].OperationTypeID)
+			s.True(decimal.NewFromInt(-60).Equal(adjs[0].Amount))
+			s.Equal(11, adjs[1].AdjustedTransactionID)
+			s.Equal(5, adjs[1].OperationTypeID)
+			s.True(decimal.NewFromInt(10).Equal(adjs[1].Amount))
+			s.Equal(now, adjs[1].EventDate)
+			s.NotEmpty(adjs[1].Reason)
+			return []int{100, 101}, nil
+		})
+		s.repo.EXPECT().AccountBalances(gomock.Any(), nil).Return(balances, nil)
+
+		report, err := txSvc.ReconcileBalances(s.ctx, entity.ReconciliationRequest{Apply: true})
+		s.NoError(err)
+		s.True(report.Clean())
+		s.Equal(100, report.Adjustments[0].TransactionID)
+		s.Equal(101, report.Adjustments[1].TransactionID)
+	})
+
+	s.T().Run("adjusted concurrently", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindSignViolations(gomock.Any()).Return(violations, nil)
+		s.repo.EXPECT().CreateAdjustments(gomock.Any(), gomock.Any()).Return(nil, entity.ErrAlreadyAdjusted)
+
+		_, err := txSvc.ReconcileBalances(s.ctx, entity.ReconciliationRequest{Apply: true})
+		s.ErrorIs(err, entity.ErrAlreadyAdjusted)
+	})
+
+	s.T().Run("adjustment types missing", func(t *testing.T) {
+		s.cl.EXPECT().Now().Return(now)
+		s.repo.EXPECT().FindSignViolations(gomock.Any()).Return(violations, nil)
+
+		_, err := s.txSvc.ReconcileBalances(s.ctx, entity.ReconciliationRequest{Apply: true})
+		s.ErrorIs(err, entity.ErrAdjustmentTypesMissing)
+	})
+}
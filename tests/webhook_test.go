@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"transaction-routine/internal/config"
+	"transaction-routine/internal/entity"
+	"transaction-routine/internal/outbox"
+	"transaction-routine/internal/server"
+	"transaction-routine/internal/service"
+	"transaction-routine/internal/webhook"
+	"transaction-routine/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":1}`)
+	header := webhook.Sign("secret", now, body)
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="))
+
+	assert.NoError(t, webhook.Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute))
+	assert.ErrorIs(t, webhook.Verify("other", header, body, now, 5*time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", header, []byte(`{"id":2}`), now, 5*time.Minute), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", header, body, now.Add(time.Hour), 5*time.Minute), webhook.ErrStaleSignature)
+	assert.ErrorIs(t, webhook.Verify("secret", "garbage", body, now, 5*time.Minute), webhook.ErrInvalidSignature)
+}
+
+func TestWebhookSender(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := webhook.Config{BatchSize: 10, Timeout: time.Second, MaxAttempts: 3, Backoff: 30 * time.Second, MaxBackoff: time.Minute}
+
+	var status int
+	var got *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("try later"))
+	}))
+	defer ts.Close()
+
+	delivery := func(attempts int) entity.WebhookDelivery {
+		return entity.WebhookDelivery{
+			ID: 7, SubscriptionID: 1, EventID: 42, EventType: entity.EventTransactionCreated,
+			Status: entity.DeliveryPending, Attempts: attempts,
+			Payload: []byte(`{"id":42}`), URL: ts.URL, Secret: "secret",
+		}
+	}
+	send := func(t *testing.T, d entity.WebhookDelivery) entity.WebhookDelivery {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		cl := mocks.NewMockClock(ctrl)
+		cl.EXPECT().Now().Return(now).AnyTimes()
+		repo.EXPECT().ClaimDeliveries(gomock.Any(), 10, gomock.Any()).Return([]entity.WebhookDelivery{d}, nil)
+		var updated entity.WebhookDelivery
+		repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d entity.WebhookDelivery) error {
+			updated = d
+			return nil
+		})
+		n, err := webhook.NewSender(repo, cl, cfg).SendOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		return updated
+	}
+
+	t.Run("delivered", func(t *testing.T) {
+		status = http.StatusNoContent
+		d := send(t, delivery(0))
+		assert.Equal(t, entity.DeliveryDelivered, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Equal(t, now, *d.DeliveredAt)
+		assert.Equal(t, `{"id":42}`, string(body))
+		assert.Equal(t, "7", got.Header.Get("X-Webhook-ID"))
+		assert.Equal(t, "TransactionCreated", got.Header.Get("X-Event-Type"))
+		assert.NoError(t, webhook.Verify("secret", got.Header.Get(webhook.SignatureHeader), body, now, time.Minute))
+	})
+
+	t.Run("retried with backoff", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		d := send(t, delivery(1))
+		assert.Equal(t, entity.DeliveryPending, d.Status)
+		assert.Equal(t, 2, d.Attempts)
+		assert.Equal(t, now.Add(time.Minute), d.NextAttemptAt)
+		assert.Equal(t, http.StatusServiceUnavailable, d.LastStatusCode)
+		assert.Contains(t, d.LastError, "try later")
+	})
+
+	t.Run("dead-lettered after the last attempt", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		d := send(t, delivery(2))
+		assert.Equal(t, entity.DeliveryDead, d.Status)
+		assert.Equal(t, 3, d.Attempts)
+		assert.Nil(t, d.DeliveredAt)
+	})
+
+	t.Run("backoff doubles up to the maximum", func(t *testing.T) {
+		s := webhook.NewSender(nil, nil, webhook.Config{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+		assert.Equal(t, time.Second, s.Backoff(1))
+		assert.Equal(t, 2*time.Second, s.Backoff(2))
+		assert.Equal(t, 4*time.Second, s.Backoff(3))
+		assert.Equal(t, 5*time.Second, s.Backoff(4))
+		assert.Equal(t, 5*time.Second, s.Backoff(40))
+	})
+}
+
+func TestWebhookFanout(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepository(ctrl)
+	pub := mocks.NewMockPublisher(ctrl)
+	event := entity.Event{ID: 1, Type: entity.EventAccountCreated, AccountID: 1}
+	fanout := outbox.Fanout{webhook.NewDispatcher(repo), pub}
+
+	repo.EXPECT().EnqueueDeliveries(gomock.Any(), event).Return(2, nil)
+	pub.EXPECT().Publish(gomock.Any(), event).Return(nil)
+	assert.NoError(t, fanout.Publish(ctx, event))
+
+	repo.EXPECT().EnqueueDeliveries(gomock.Any(), event).Return(0, errors.New("unavailable"))
+	assert.Error(t, fanout.Publish(ctx, event))
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		repo.EXPECT().FindAccountIDs(gomock.Any(), []int{1, 2}).Return([]int{1, 2}, nil)
+		repo.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, sub entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+			sub.ID = 5
+			return sub, nil
+		})
+		sub, err := service.NewWebhookService(repo).CreateWebhook(ctx, entity.WebhookSubscription{
+			URL:        "https://partner.example/hooks",
+			EventTypes: []entity.EventType{entity.EventTransactionCreated},
+			AccountIDs: []int{2, 1, 2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5, sub.ID)
+		assert.Equal(t, []int{1, 2}, sub.AccountIDs)
+		assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+	})
+
+	t.Run("invalid subscription", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		_, err := service.NewWebhookService(mocks.NewMockRepository(ctrl)).CreateWebhook(ctx, entity.WebhookSubscription{
+			URL:        "ftp://partner.example",
+			EventTypes: []entity.EventType{"Unknown"},
+		})
+		assert.ErrorIs(t, err, entity.ErrInvalidWebhookURL)
+		assert.ErrorIs(t, err, entity.ErrUnknownEventType)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		repo.EXPECT().FindAccountIDs(gomock.Any(), []int{1, 9}).Return([]int{1}, nil)
+		_, err := service.NewWebhookService(repo).CreateWebhook(ctx, entity.WebhookSubscription{URL: "http://localhost:9000", AccountIDs: []int{1, 9}})
+		assert.ErrorIs(t, err, entity.ErrAccountNotFound)
+	})
+
+	t.Run("deliveries of a missing subscription", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		repo.EXPECT().FindWebhook(gomock.Any(), 3).Return(nil, nil)
+		_, err := service.NewWebhookService(repo).ListDeliveries(ctx, entity.DeliveryFilter{SubscriptionID: 3})
+		assert.ErrorIs(t, err, entity.ErrWebhookNotFound)
+	})
+
+	t.Run("deliveries limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockRepository(ctrl)
+		repo.EXPECT().FindWebhook(gomock.Any(), 3).Return(&entity.WebhookSubscription{ID: 3}, nil)
+		repo.EXPECT().FindDeliveries(gomock.Any(), entity.DeliveryFilter{SubscriptionID: 3, Status: entity.DeliveryDead, Limit: entity.MaxDeliveryLimit}).Return(nil, nil)
+		_, err := service.NewWebhookService(repo).ListDeliveries(ctx, entity.DeliveryFilter{SubscriptionID: 3, Status: entity.DeliveryDead, Limit: 5000})
+		assert.NoError(t, err)
+	})
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	whSvc := mocks.NewMockWebhookService(ctrl)
+	srv := server.NewServer(ctx, &config.Config{AuthDisabled: true}, server.Deps{Webhooks: whSvc})
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		whSvc.EXPECT().CreateWebhook(gomock.Any(), entity.WebhookSubscription{
+			URL:        "https://partner.example/hooks",
+			EventTypes: []entity.EventType{entity.EventTransactionCreated},
+		}).Return(entity.WebhookSubscription{ID: 4, URL: "https://partner.example/hooks", Secret: "whsec_x"}, nil)
+		resp, body := do(http.MethodPost, "/v1/webhooks", `{"url":"https://partner.example/hooks","event_types":["TransactionCreated"]}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/v1/webhooks/4", resp.Header.Get("Location"))
+		var sub entity.WebhookSubscription
+		require.NoError(t, json.Unmarshal([]byte(body), &sub))
+		assert.Equal(t, "whsec_x", sub.Secret)
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		resp, body := do(http.MethodPost, "/v1/webhooks", `{"url":"https://partner.example/hooks","event_types":["Nope"]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, body, "event_types")
+	})
+
+	t.Run("dead letters", func(t *testing.T) {
+		whSvc.EXPECT().ListDeliveries(gomock.Any(), entity.DeliveryFilter{SubscriptionID: 4, Status: entity.DeliveryDead, Limit: 10}).
+			Return([]entity.WebhookDelivery{{ID: 9, SubscriptionID: 4, Status: entity.DeliveryDead, Attempts: 8}}, nil)
+		resp, body := do(http.MethodGet, "/v1/webhooks/4/deliveries?status=dead&limit=10", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"status":"dead"`)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		resp, _ := do(http.MethodGet, "/v1/webhooks/4/deliveries?status=lost", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		whSvc.EXPECT().ReplayDelivery(gomock.Any(), 4, int64(9)).Return(entity.WebhookDelivery{ID: 9, Status: entity.DeliveryPending}, nil)
+		resp, _ := do(http.MethodPost, "/v1/webhooks/4/deliveries/9/replay", "")
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		whSvc.EXPECT().ReplayDelivery(gomock.Any(), 4, int64(10)).Return(entity.WebhookDelivery{}, entity.ErrWebhookDeliveryNotFound)
+		resp, _ = do(http.MethodPost, "/v1/webhooks/4/deliveries/10/replay", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		whSvc.EXPECT().DeleteWebhook(gomock.Any(), 4).Return(nil)
+		resp, _ := do(http.MethodDelete, "/v1/webhooks/4", "")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		whSvc.EXPECT().DeleteWebhook(gomock.Any(), 5).Return(entity.ErrWebhookNotFound)
+		resp, _ = do(http.MethodDelete, "/v1/webhooks/5", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}